@@ -1,17 +1,26 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
 	"database/sql"
+	"fmt"
+	"github.com/alam/govtech/internal/adapter"
 	"github.com/alam/govtech/internal/controller"
+	"github.com/alam/govtech/internal/model"
 	"github.com/alam/govtech/internal/repository"
+	"github.com/alam/govtech/internal/screener"
+	"github.com/alam/govtech/internal/search"
 	"github.com/alam/govtech/internal/service"
 	_ "github.com/go-sql-driver/mysql"
 	"log"
 	"net/http"
+	"os"
+	"strconv"
 )
 
 func main() {
-	db, err := sql.Open("mysql", "root:admin@tcp(localhost:6603)/mysql?parseTime=true")
+	db, err := sql.Open("mysql", "root:admin@tcp(localhost:6603)/mysql?parseTime=true&clientFoundRows=true")
 	if err != nil {
 		log.Fatalln("error init db:", err)
 	}
@@ -19,11 +28,76 @@ func main() {
 	productRepo := repository.NewProductRepository(db)
 	reviewRepo := repository.NewProductReviewRepository(db)
 	categoryRepo := repository.NewCategoryRepository(db)
+	historyRepo := repository.NewProductHistoryRepository(db)
+	priceRepo := repository.NewProductPriceRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	txManager := repository.NewTxManager(db)
 
-	svc := service.NewService(productRepo, categoryRepo, reviewRepo)
+	ratingPrior, err := ratingPriorFromEnv()
+	if err != nil {
+		log.Fatalln("error init rating prior:", err)
+	}
+	if err := productRepo.RecomputeRatingScores(context.Background(), ratingPrior); err != nil {
+		log.Fatalln("error init rating scores:", err)
+	}
+
+	// Text queries are searched by MySQL unless the in-memory index is
+	// enabled with SEARCH_BACKEND=memory.
+	var searcher adapter.ProductSearcher
+	if os.Getenv("SEARCH_BACKEND") == "memory" {
+		memorySearcher := search.NewMemorySearcher()
+		if err := search.IndexAll(context.Background(), productRepo, memorySearcher); err != nil {
+			log.Fatalln("error init search index:", err)
+		}
+		searcher = memorySearcher
+	}
+
+	reviewScreener := screener.NewDefault(reviewRepo)
+
+	cursorKey := []byte(os.Getenv("CURSOR_SECRET"))
+	if len(cursorKey) == 0 {
+		log.Println("CURSOR_SECRET is not set, product list cursors will not survive a restart")
+		cursorKey = make([]byte, 32)
+		if _, err := rand.Read(cursorKey); err != nil {
+			log.Fatalln("error init cursor key:", err)
+		}
+	}
 
-	ctrl := controller.NewController(svc)
+	svc := service.NewService(productRepo, categoryRepo, reviewRepo, historyRepo, priceRepo, userRepo, txManager, searcher, reviewScreener, cursorKey, ratingPrior)
+
+	// Callers are identified by headers of the gateway, which must strip them
+	// from client requests, only when TRUST_IDENTITY_HEADERS=true.
+	auth := controller.AuthConfig{TrustIdentityHeaders: os.Getenv("TRUST_IDENTITY_HEADERS") == "true"}
+	if !auth.TrustIdentityHeaders {
+		log.Println("TRUST_IDENTITY_HEADERS is not set, every request is anonymous")
+	}
+
+	ctrl := controller.NewController(svc, controller.DefaultCacheConfig, auth)
 
 	log.Println("server started at :8080")
 	log.Fatalln(http.ListenAndServe(":8080", ctrl))
 }
+
+// defaultRatingPrior scores a product as if it also had 10 reviews of 3 stars.
+var defaultRatingPrior = model.RatingPrior{Mean: 3, Weight: 10}
+
+// ratingPriorFromEnv returns the global rating prior, read from
+// RATING_PRIOR_MEAN and RATING_PRIOR_WEIGHT when they are set.
+func ratingPriorFromEnv() (model.RatingPrior, error) {
+	prior := defaultRatingPrior
+	if value := os.Getenv("RATING_PRIOR_MEAN"); value != "" {
+		mean, err := strconv.ParseFloat(value, 64)
+		if err != nil || mean < 1 || mean > 5 {
+			return model.RatingPrior{}, fmt.Errorf("invalid RATING_PRIOR_MEAN: %q", value)
+		}
+		prior.Mean = mean
+	}
+	if value := os.Getenv("RATING_PRIOR_WEIGHT"); value != "" {
+		weight, err := strconv.ParseFloat(value, 64)
+		if err != nil || weight <= 0 {
+			return model.RatingPrior{}, fmt.Errorf("invalid RATING_PRIOR_WEIGHT: %q", value)
+		}
+		prior.Weight = weight
+	}
+	return prior, nil
+}
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE products ADD FULLTEXT INDEX ft_products_search (title, description, sku);
+ALTER TABLE categories ADD FULLTEXT INDEX ft_categories_name (name);
+
+-- +goose Down
+ALTER TABLE categories DROP INDEX ft_categories_name;
+ALTER TABLE products DROP INDEX ft_products_search;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE products ADD COLUMN deleted_at timestamp null default null;
+
+-- +goose Down
+ALTER TABLE products DROP COLUMN deleted_at;
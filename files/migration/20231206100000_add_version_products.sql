@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE products ADD COLUMN version int not null default 1;
+
+-- +goose Down
+ALTER TABLE products DROP COLUMN version;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE products ADD COLUMN updated_at timestamp(6) not null default current_timestamp(6) on update current_timestamp(6);
+
+-- +goose Down
+ALTER TABLE products DROP COLUMN updated_at;
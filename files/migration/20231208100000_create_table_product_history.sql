@@ -0,0 +1,38 @@
+-- +goose Up
+CREATE TABLE product_history(
+    id int not null auto_increment primary key,
+    product_id int not null,
+    action varchar(20) not null,
+    actor_id varchar(100) not null,
+    actor_name varchar(100) not null,
+    before_data json null,
+    after_data json null,
+    created_at timestamp(6) not null default current_timestamp(6),
+    index idx_product_history_product (product_id, id),
+    foreign key(product_id) references products(id)
+);
+
+-- Products created before the history existed get a creation entry, so every
+-- product has at least one.
+INSERT INTO product_history(product_id, action, actor_id, actor_name, after_data, created_at)
+SELECT
+    id,
+    'create',
+    'system',
+    '',
+    JSON_OBJECT(
+        'sku', sku,
+        'title', title,
+        'description', description,
+        'categoryId', category_id,
+        'imageUrl', image_url,
+        'weight', weight,
+        'price', price,
+        'rating', rating,
+        'version', version
+    ),
+    created_at
+FROM products;
+
+-- +goose Down
+DROP TABLE product_history;
@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE product_prices(
+    id int not null auto_increment primary key,
+    product_id int not null,
+    price int not null,
+    effective_from timestamp(6) not null,
+    effective_to timestamp(6) null,
+    index idx_product_prices_product (product_id, effective_from),
+    foreign key(product_id) references products(id)
+);
+
+-- The current price of existing products is assumed valid since creation.
+INSERT INTO product_prices(product_id, price, effective_from)
+SELECT id, price, created_at FROM products;
+
+-- +goose Down
+DROP TABLE product_prices;
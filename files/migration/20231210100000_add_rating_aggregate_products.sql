@@ -0,0 +1,20 @@
+-- +goose Up
+ALTER TABLE products
+    ADD COLUMN rating_sum bigint not null default 0,
+    ADD COLUMN rating_count int not null default 0;
+
+UPDATE products p
+JOIN (
+    SELECT product_id, SUM(rating) AS rating_sum, COUNT(id) AS rating_count
+    FROM product_reviews
+    GROUP BY product_id
+) r ON r.product_id = p.id
+SET
+    p.rating_sum = r.rating_sum,
+    p.rating_count = r.rating_count,
+    p.rating = r.rating_sum / r.rating_count;
+
+-- +goose Down
+ALTER TABLE products
+    DROP COLUMN rating_sum,
+    DROP COLUMN rating_count;
@@ -0,0 +1,37 @@
+-- +goose Up
+CREATE TABLE users(
+    id int not null auto_increment primary key,
+    subject varchar(100) not null unique,
+    name varchar(100) not null,
+    created_at timestamp(6) not null default current_timestamp(6)
+);
+
+-- Reviews written before reviewers were identified were given random user
+-- ids, each of them becomes a legacy user.
+INSERT INTO users(id, subject, name)
+SELECT DISTINCT user_id, CONCAT('legacy:', user_id), ''
+FROM product_reviews;
+
+-- A legacy user could review a product more than once. Every review but the
+-- latest one is moved to a legacy user of its own, so no review is lost.
+INSERT INTO users(subject, name)
+SELECT CONCAT('legacy-review:', r1.id), ''
+FROM product_reviews r1
+WHERE EXISTS (
+    SELECT 1 FROM product_reviews r2
+    WHERE r2.user_id = r1.user_id AND r2.product_id = r1.product_id AND r2.id > r1.id
+);
+
+UPDATE product_reviews r
+JOIN users u ON u.subject = CONCAT('legacy-review:', r.id)
+SET r.user_id = u.id;
+
+ALTER TABLE product_reviews
+    ADD UNIQUE KEY uq_product_reviews_user_product (user_id, product_id),
+    ADD CONSTRAINT fk_product_reviews_user FOREIGN KEY (user_id) REFERENCES users(id);
+
+-- +goose Down
+ALTER TABLE product_reviews DROP FOREIGN KEY fk_product_reviews_user;
+ALTER TABLE product_reviews DROP INDEX uq_product_reviews_user_product;
+
+DROP TABLE users;
@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE product_reviews
+    ADD COLUMN created_at timestamp(6) not null default current_timestamp(6),
+    ADD COLUMN updated_at timestamp(6) not null default current_timestamp(6) on update current_timestamp(6),
+    ADD INDEX idx_product_reviews_product_created (product_id, created_at);
+
+-- +goose Down
+ALTER TABLE product_reviews
+    DROP INDEX idx_product_reviews_product_created,
+    DROP COLUMN created_at,
+    DROP COLUMN updated_at;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- A category without a prior uses the global one configured on the service.
+ALTER TABLE categories
+    ADD COLUMN rating_prior_mean double null,
+    ADD COLUMN rating_prior_weight double null,
+    ADD CONSTRAINT chk_categories_rating_prior CHECK (rating_prior_mean BETWEEN 1 AND 5 AND rating_prior_weight > 0);
+
+-- Scores are computed by the service on startup, as they depend on its
+-- configuration.
+ALTER TABLE products
+    ADD COLUMN rating_score double not null default 0,
+    ADD INDEX idx_products_rating_score (rating_score, id);
+
+-- +goose Down
+ALTER TABLE products
+    DROP INDEX idx_products_rating_score,
+    DROP COLUMN rating_score;
+
+ALTER TABLE categories
+    DROP CHECK chk_categories_rating_prior,
+    DROP COLUMN rating_prior_mean,
+    DROP COLUMN rating_prior_weight;
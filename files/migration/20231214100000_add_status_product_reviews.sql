@@ -0,0 +1,16 @@
+-- +goose Up
+-- Reviews written before moderation are already public, they stay approved.
+ALTER TABLE product_reviews
+    ADD COLUMN status varchar(16) not null default 'approved',
+    ADD COLUMN flag_reason varchar(256) not null default '',
+    ADD INDEX idx_product_reviews_status_created (status, created_at),
+    ADD INDEX idx_product_reviews_comment (comment);
+
+ALTER TABLE product_reviews ALTER COLUMN status SET DEFAULT 'pending';
+
+-- +goose Down
+ALTER TABLE product_reviews
+    DROP INDEX idx_product_reviews_comment,
+    DROP INDEX idx_product_reviews_status_created,
+    DROP COLUMN flag_reason,
+    DROP COLUMN status;
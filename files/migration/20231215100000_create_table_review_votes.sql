@@ -0,0 +1,28 @@
+-- +goose Up
+CREATE TABLE review_votes(
+    review_id int not null,
+    user_id int not null,
+    helpful boolean not null,
+    created_at timestamp(6) not null default current_timestamp(6),
+    updated_at timestamp(6) not null default current_timestamp(6) on update current_timestamp(6),
+    primary key (review_id, user_id),
+    foreign key (review_id) references product_reviews(id) on delete cascade,
+    foreign key (user_id) references users(id)
+);
+
+-- helpful_score is the Wilson lower bound of the share of helpful votes, kept
+-- up to date with the counts so the most helpful reviews can use an index.
+ALTER TABLE product_reviews
+    ADD COLUMN helpful_count int not null default 0,
+    ADD COLUMN unhelpful_count int not null default 0,
+    ADD COLUMN helpful_score double not null default 0,
+    ADD INDEX idx_product_reviews_product_helpful (product_id, helpful_score);
+
+-- +goose Down
+ALTER TABLE product_reviews
+    DROP INDEX idx_product_reviews_product_helpful,
+    DROP COLUMN helpful_score,
+    DROP COLUMN unhelpful_count,
+    DROP COLUMN helpful_count;
+
+DROP TABLE review_votes;
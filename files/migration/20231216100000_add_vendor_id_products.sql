@@ -0,0 +1,10 @@
+-- +goose Up
+-- The vendor of a product is the user who listed it, products listed before
+-- vendors were recorded have none.
+ALTER TABLE products
+    ADD COLUMN vendor_id int null,
+    ADD CONSTRAINT fk_products_vendor FOREIGN KEY (vendor_id) REFERENCES users(id);
+
+-- +goose Down
+ALTER TABLE products DROP FOREIGN KEY fk_products_vendor;
+ALTER TABLE products DROP COLUMN vendor_id;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE review_replies(
+    review_id int not null primary key,
+    vendor_id int not null,
+    comment varchar(256) not null,
+    created_at timestamp(6) not null default current_timestamp(6),
+    updated_at timestamp(6) not null default current_timestamp(6) on update current_timestamp(6),
+    foreign key (review_id) references product_reviews(id) on delete cascade,
+    foreign key (vendor_id) references users(id)
+);
+
+-- +goose Down
+DROP TABLE review_replies;
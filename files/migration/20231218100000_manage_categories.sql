@@ -0,0 +1,15 @@
+-- +goose Up
+-- products.category_id references categories.id, MySQL only lets a referenced
+-- column be modified with the foreign key checks disabled.
+SET FOREIGN_KEY_CHECKS = 0;
+ALTER TABLE categories MODIFY id int not null auto_increment;
+SET FOREIGN_KEY_CHECKS = 1;
+
+ALTER TABLE categories ADD UNIQUE INDEX uq_categories_name (name);
+
+-- +goose Down
+ALTER TABLE categories DROP INDEX uq_categories_name;
+
+SET FOREIGN_KEY_CHECKS = 0;
+ALTER TABLE categories MODIFY id int not null;
+SET FOREIGN_KEY_CHECKS = 1;
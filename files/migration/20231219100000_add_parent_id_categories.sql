@@ -0,0 +1,9 @@
+-- +goose Up
+-- Categories without a parent are the roots of the category tree.
+ALTER TABLE categories
+    ADD COLUMN parent_id int null,
+    ADD CONSTRAINT fk_categories_parent FOREIGN KEY (parent_id) REFERENCES categories(id);
+
+-- +goose Down
+ALTER TABLE categories DROP FOREIGN KEY fk_categories_parent;
+ALTER TABLE categories DROP COLUMN parent_id;
@@ -3,6 +3,7 @@ module github.com/alam/govtech
 go 1.21.2
 
 require (
+	github.com/DATA-DOG/go-sqlmock v1.5.2
 	github.com/go-sql-driver/mysql v1.7.1
 	github.com/gorilla/mux v1.8.1
 	github.com/stretchr/testify v1.8.4
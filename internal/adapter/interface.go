@@ -3,22 +3,88 @@ package adapter
 import (
 	"context"
 	"github.com/alam/govtech/internal/model"
+	"time"
 )
 
+// TxManager runs a unit of work in a single transaction. Repositories called
+// with the ctx passed to fn take part in that transaction.
+type TxManager interface {
+	// WithinTx commits when fn returns nil and rolls back otherwise. When
+	// ctx already carries a transaction fn joins it.
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// ProductRepository writes record the change in the product history, made by
+// actor.
 type ProductRepository interface {
 	GetProduct(ctx context.Context, id int64) (model.Product, error)
 	GetProductBySKU(ctx context.Context, sku string) (model.Product, error)
 	GetProductList(ctx context.Context, filter model.GetProductListFilter) ([]model.Product, error)
-	InsertProduct(ctx context.Context, product model.Product) error
-	UpdateProduct(ctx context.Context, id int64, product model.Product) error
-	UpdateProductRating(ctx context.Context, id int64, rating float64) error
+	CountProductList(ctx context.Context, filter model.GetProductListFilter) (int64, error)
+	GetProductFacets(ctx context.Context, filter model.GetProductListFilter) (model.ProductFacets, error)
+	InsertProduct(ctx context.Context, product model.Product, actor model.User) (int64, error)
+	UpdateProduct(ctx context.Context, id int64, product model.Product, actor model.User) error
+	AddProductRating(ctx context.Context, id int64, delta model.RatingDelta, prior model.RatingPrior, actor model.User) error
+	RecomputeRatingScores(ctx context.Context, prior model.RatingPrior) error
+	DeleteProduct(ctx context.Context, id int64, actor model.User) error
+	RestoreProduct(ctx context.Context, id int64, actor model.User) error
+	MoveCategoryProducts(ctx context.Context, fromCategoryID, toCategoryID int64, actor model.User) error
+}
+
+type ProductHistoryRepository interface {
+	GetProductHistory(ctx context.Context, productID int64, limit, offset int64) ([]model.ProductHistory, error)
+	CountProductHistory(ctx context.Context, productID int64) (int64, error)
+}
+
+type ProductPriceRepository interface {
+	GetProductPrices(ctx context.Context, productID int64, limit, offset int64) ([]model.ProductPrice, error)
+	CountProductPrices(ctx context.Context, productID int64) (int64, error)
+	GetProductPriceAt(ctx context.Context, productID int64, at time.Time) (model.ProductPrice, error)
 }
 
 type ProductReviewRepository interface {
+	GetReviewList(ctx context.Context, filter model.GetReviewListFilter) ([]model.ProductReview, error)
+	CountReviewList(ctx context.Context, filter model.GetReviewListFilter) (int64, error)
+	CountReviewsWithComment(ctx context.Context, comment string, userID int64) (int64, error)
+	CountUserReviewsSince(ctx context.Context, userID int64, since time.Time) (int64, error)
+	GetReview(ctx context.Context, productID, id int64) (model.ProductReview, error)
+	GetReviewByID(ctx context.Context, id int64) (model.ProductReview, error)
+	GetUserReview(ctx context.Context, productID, userID int64) (model.ProductReview, error)
 	InsertReview(ctx context.Context, review model.ProductReview) error
+	UpdateReview(ctx context.Context, review model.ProductReview) error
+	DeleteReview(ctx context.Context, id int64) error
 	GetReviewStatistic(ctx context.Context, productID int64) (model.Statistic, error)
+	GetReviewVote(ctx context.Context, reviewID, userID int64) (model.ReviewVote, error)
+	InsertReviewVote(ctx context.Context, vote model.ReviewVote) error
+	UpdateReviewVote(ctx context.Context, vote model.ReviewVote) error
+	AddReviewVotes(ctx context.Context, reviewID int64, delta model.VoteDelta) error
+	UpsertReviewReply(ctx context.Context, reply model.ReviewReply) error
+	DeleteReviewReply(ctx context.Context, reviewID int64) error
+}
+
+// ReviewScreener checks a review before it is saved. A review screened with a
+// non-empty reason is held for moderation instead of being published.
+type ReviewScreener interface {
+	Screen(ctx context.Context, review model.ProductReview) (string, error)
+}
+
+type UserRepository interface {
+	UpsertUser(ctx context.Context, user model.User) (int64, error)
 }
 
 type CategoryRepository interface {
 	GetCategory(ctx context.Context, id int64) (model.Category, error)
+	LockCategory(ctx context.Context, id int64) (model.Category, error)
+	GetCategoryList(ctx context.Context) ([]model.Category, error)
+	InsertCategory(ctx context.Context, category model.Category) (int64, error)
+	UpdateCategory(ctx context.Context, category model.Category) error
+	DeleteCategory(ctx context.Context, id int64) error
+}
+
+type ProductSearcher interface {
+	IndexProduct(ctx context.Context, product model.Product) error
+	// Search returns model.ErrUnsupportedQuery for a query the searcher can
+	// not answer.
+	Search(ctx context.Context, query string) ([]model.SearchHit, error)
+	RemoveProduct(ctx context.Context, id int64) error
 }
@@ -1,14 +1,285 @@
 package api
 
-import "errors"
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
 
 type GetProductListFilter struct {
-	Search     string
-	CategoryID int64
-	SortColumn string
-	SortType   string
-	Page       int64
-	Size       int64
+	Search      string
+	CategoryIDs []int64
+	// IncludeSubcategories extends CategoryIDs to the subcategories of the
+	// categories, at any depth.
+	IncludeSubcategories bool
+	MinPrice             int64
+	MaxPrice             int64
+	MinWeight            int32
+	MaxWeight            int32
+	MinRating            float64
+	SortColumn           string
+	SortType             string
+	Cursor               string
+	Page                 int64
+	Size                 int64
+}
+
+type GetProductFilter struct {
+	// AsOf is an RFC 3339 timestamp, when it is set the product is returned
+	// with the price valid at that time.
+	AsOf string
+}
+
+// AsOfTime returns the parsed AsOf, the zero time when it is not set.
+func (filter GetProductFilter) AsOfTime() (time.Time, error) {
+	if filter.AsOf == "" {
+		return time.Time{}, nil
+	}
+	asOf, err := time.Parse(time.RFC3339Nano, filter.AsOf)
+	if err != nil {
+		return time.Time{}, errors.New("invalid as_of")
+	}
+	return asOf, nil
+}
+
+// ProductPatch is an RFC 7396 merge patch of a product. A nil field is left
+// unchanged.
+type ProductPatch struct {
+	SKU         *string
+	Title       *string
+	Description *string
+	Category    *Category
+	ImageURL    *string
+	Weight      *int32
+	Price       *int64
+	// Version is the product version the patch was made from, it is read
+	// from the If-Match header, see VersionAny.
+	Version int64
+}
+
+// UnmarshalJSON decodes a merge patch document. Every product field is
+// required, so a null member, which removes the field in a merge patch, is
+// rejected the same way as read only or unknown members.
+func (p *ProductPatch) UnmarshalJSON(data []byte) error {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+	if members == nil {
+		return errors.New("patch must be a JSON object")
+	}
+
+	fields := map[string]interface{}{
+		"sku":         &p.SKU,
+		"title":       &p.Title,
+		"description": &p.Description,
+		"category":    &p.Category,
+		"imageUrl":    &p.ImageURL,
+		"weight":      &p.Weight,
+		"price":       &p.Price,
+	}
+	for name, raw := range members {
+		field, ok := fields[name]
+		if !ok {
+			return fmt.Errorf("field %q can not be patched", name)
+		}
+		if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+			return fmt.Errorf("field %q can not be removed", name)
+		}
+		if err := json.Unmarshal(raw, field); err != nil {
+			return fmt.Errorf("invalid field %q: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// Validate checks the fields present in the patch only.
+func (p ProductPatch) Validate() error {
+	if p.SKU != nil && *p.SKU == "" {
+		return errors.New("empty SKU")
+	}
+	if p.Title != nil && *p.Title == "" {
+		return errors.New("empty title")
+	}
+	if p.Description != nil && *p.Description == "" {
+		return errors.New("empty description")
+	}
+	if p.ImageURL != nil && *p.ImageURL == "" {
+		return errors.New("empty image url")
+	}
+	if p.Category != nil && p.Category.ID == 0 {
+		return errors.New("empty category id")
+	}
+	if p.Weight != nil && *p.Weight < 0 {
+		return errors.New("invalid weight")
+	}
+	if p.Price != nil && *p.Price <= 0 {
+		return errors.New("empty price")
+	}
+
+	return nil
+}
+
+// PageFilter selects a page of a list nested under a product.
+type PageFilter struct {
+	Page int64
+	Size int64
+}
+
+func (filter *PageFilter) Validate() error {
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.Size <= 0 {
+		filter.Size = 10
+	}
+	return nil
+}
+
+// URL returns the link of the given page of the list at path.
+func (filter PageFilter) URL(path string, page int64) string {
+	values := url.Values{}
+	values.Set("page", strconv.FormatInt(page, 10))
+	values.Set("size", strconv.FormatInt(filter.Size, 10))
+
+	return path + "?" + values.Encode()
+}
+
+// reviewSorts are the orders a review list can be sorted in.
+var reviewSorts = map[string]bool{
+	"newest":  true,
+	"highest": true,
+	"lowest":  true,
+	"helpful": true,
+}
+
+type GetReviewListFilter struct {
+	Rating int32
+	Sort   string
+	Page   int64
+	Size   int64
+}
+
+func (filter *GetReviewListFilter) Validate() error {
+	if filter.Rating < 0 || filter.Rating > 5 {
+		return errors.New("invalid rating")
+	}
+	if filter.Sort == "" {
+		filter.Sort = "newest"
+	}
+	if !reviewSorts[filter.Sort] {
+		return errors.New("invalid sort")
+	}
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.Size <= 0 {
+		filter.Size = 10
+	}
+	return nil
+}
+
+// URL returns the link of the given page of the review list at path.
+func (filter GetReviewListFilter) URL(path string, page int64) string {
+	values := url.Values{}
+	if filter.Rating > 0 {
+		values.Set("rating", strconv.FormatInt(int64(filter.Rating), 10))
+	}
+	values.Set("sort", filter.Sort)
+	values.Set("page", strconv.FormatInt(page, 10))
+	values.Set("size", strconv.FormatInt(filter.Size, 10))
+
+	return path + "?" + values.Encode()
+}
+
+type GetPendingReviewListFilter struct {
+	Page int64
+	Size int64
+}
+
+func (filter *GetPendingReviewListFilter) Validate() error {
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.Size <= 0 {
+		filter.Size = 10
+	}
+	return nil
+}
+
+// URL returns the link of the given page of the pending review list at path.
+func (filter GetPendingReviewListFilter) URL(path string, page int64) string {
+	values := url.Values{}
+	values.Set("page", strconv.FormatInt(page, 10))
+	values.Set("size", strconv.FormatInt(filter.Size, 10))
+
+	return path + "?" + values.Encode()
+}
+
+// ModerateReviewRequest decides a pending review, Status is either approved or
+// rejected.
+type ModerateReviewRequest struct {
+	Status string `json:"status"`
+}
+
+func (req ModerateReviewRequest) Validate() error {
+	if req.Status != "approved" && req.Status != "rejected" {
+		return errors.New("invalid status")
+	}
+	return nil
+}
+
+// DeleteCategoryRequest deletes a category. Its products are moved to the
+// category ReassignTo, when it is zero a category with products is not deleted.
+type DeleteCategoryRequest struct {
+	ReassignTo int64
+}
+
+func (req DeleteCategoryRequest) Validate(id int64) error {
+	if req.ReassignTo < 0 {
+		return errors.New("invalid reassign_to")
+	}
+	if req.ReassignTo == id {
+		return errors.New("reassign_to must be another category")
+	}
+	return nil
+}
+
+// VoteReviewRequest is a vote on whether a review is helpful.
+type VoteReviewRequest struct {
+	Helpful *bool `json:"helpful"`
+}
+
+func (req VoteReviewRequest) Validate() error {
+	if req.Helpful == nil {
+		return errors.New("helpful is required")
+	}
+	return nil
+}
+
+// maxReplyLength is the longest reply a vendor can write, in characters.
+const maxReplyLength = 256
+
+// ReplyReviewRequest is the answer of the vendor of a product to a review.
+type ReplyReviewRequest struct {
+	Comment string `json:"comment"`
+}
+
+func (req ReplyReviewRequest) Validate() error {
+	if strings.TrimSpace(req.Comment) == "" {
+		return errors.New("empty comment")
+	}
+	if utf8.RuneCountInString(req.Comment) > maxReplyLength {
+		return errors.New("comment is too long")
+	}
+	return nil
 }
 
 type ReviewProductRequest struct {
@@ -27,9 +298,32 @@ func (filter *GetProductListFilter) Validate() error {
 	if filter.SortType != "" && filter.SortType != "asc" && filter.SortType != "desc" {
 		return errors.New("invalid sort type")
 	}
-	if filter.SortColumn != "" && filter.SortColumn != "created_at" && filter.SortColumn != "rating" {
+	if filter.SortColumn != "" && filter.SortColumn != "created_at" && filter.SortColumn != "rating" && filter.SortColumn != "score" && filter.SortColumn != "relevance" {
 		return errors.New("invalid sort column")
 	}
+	if filter.SortColumn == "relevance" && filter.Search == "" {
+		return errors.New("sort by relevance requires search")
+	}
+	if filter.MinPrice < 0 || filter.MaxPrice < 0 {
+		return errors.New("invalid price range")
+	}
+	if filter.MaxPrice > 0 && filter.MinPrice > filter.MaxPrice {
+		return errors.New("invalid price range")
+	}
+	if filter.MinWeight < 0 || filter.MaxWeight < 0 {
+		return errors.New("invalid weight range")
+	}
+	if filter.MaxWeight > 0 && filter.MinWeight > filter.MaxWeight {
+		return errors.New("invalid weight range")
+	}
+	if filter.MinRating < 0 || filter.MinRating > 5 {
+		return errors.New("invalid minimum rating")
+	}
+	for _, id := range filter.CategoryIDs {
+		if id <= 0 {
+			return errors.New("invalid category")
+		}
+	}
 	if filter.Page <= 0 {
 		filter.Page = 1
 	}
@@ -38,3 +332,62 @@ func (filter *GetProductListFilter) Validate() error {
 	}
 	return nil
 }
+
+// URL returns the product list link of the given page with the same filter.
+func (filter GetProductListFilter) URL(page int64) string {
+	values := filter.values()
+	values.Set("page", strconv.FormatInt(page, 10))
+	values.Set("size", strconv.FormatInt(filter.Size, 10))
+
+	return "/products?" + values.Encode()
+}
+
+// CursorURL returns the product list link of the page after cursor with the
+// same filter.
+func (filter GetProductListFilter) CursorURL(cursor string) string {
+	values := filter.values()
+	values.Set("cursor", cursor)
+	values.Set("size", strconv.FormatInt(filter.Size, 10))
+
+	return "/products?" + values.Encode()
+}
+
+func (filter GetProductListFilter) values() url.Values {
+	values := url.Values{}
+	if filter.Search != "" {
+		values.Set("search", filter.Search)
+	}
+	if len(filter.CategoryIDs) > 0 {
+		ids := make([]string, len(filter.CategoryIDs))
+		for i, id := range filter.CategoryIDs {
+			ids[i] = strconv.FormatInt(id, 10)
+		}
+		values.Set("category", strings.Join(ids, ","))
+	}
+	if filter.IncludeSubcategories {
+		values.Set("include_subcategories", "true")
+	}
+	if filter.MinPrice > 0 {
+		values.Set("min_price", strconv.FormatInt(filter.MinPrice, 10))
+	}
+	if filter.MaxPrice > 0 {
+		values.Set("max_price", strconv.FormatInt(filter.MaxPrice, 10))
+	}
+	if filter.MinWeight > 0 {
+		values.Set("min_weight", strconv.FormatInt(int64(filter.MinWeight), 10))
+	}
+	if filter.MaxWeight > 0 {
+		values.Set("max_weight", strconv.FormatInt(int64(filter.MaxWeight), 10))
+	}
+	if filter.MinRating > 0 {
+		values.Set("min_rating", strconv.FormatFloat(filter.MinRating, 'f', -1, 64))
+	}
+	if filter.SortColumn != "" {
+		values.Set("sort", filter.SortColumn)
+	}
+	if filter.SortType != "" {
+		values.Set("sort_type", filter.SortType)
+	}
+
+	return values
+}
@@ -1,6 +1,11 @@
 package api
 
-import "testing"
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
 
 func TestReviewProductRequest_Validate(t *testing.T) {
 	type fields struct {
@@ -76,10 +81,15 @@ func TestReviewProductRequest_Validate(t *testing.T) {
 
 func TestGetProductListFilter_Validate(t *testing.T) {
 	type fields struct {
-		Search     string
-		CategoryID int64
-		SortColumn string
-		SortType   string
+		Search      string
+		CategoryIDs []int64
+		MinPrice    int64
+		MaxPrice    int64
+		MinWeight   int32
+		MaxWeight   int32
+		MinRating   float64
+		SortColumn  string
+		SortType    string
 	}
 	tests := []struct {
 		name    string
@@ -89,51 +99,140 @@ func TestGetProductListFilter_Validate(t *testing.T) {
 		{
 			name: "invalid sort type",
 			fields: fields{
-				Search:     "",
-				CategoryID: 0,
-				SortColumn: "created_at",
-				SortType:   "laksdfj",
+				Search:      "",
+				CategoryIDs: nil,
+				SortColumn:  "created_at",
+				SortType:    "laksdfj",
 			},
 			wantErr: true,
 		},
 		{
 			name: "invalid sort column",
 			fields: fields{
-				Search:     "",
-				CategoryID: 0,
-				SortColumn: "lksjaf",
-				SortType:   "asc",
+				Search:      "",
+				CategoryIDs: nil,
+				SortColumn:  "lksjaf",
+				SortType:    "asc",
 			},
 			wantErr: true,
 		},
 		{
 			name: "created at asc",
 			fields: fields{
-				Search:     "",
-				CategoryID: 0,
-				SortColumn: "created_at",
-				SortType:   "asc",
+				Search:      "",
+				CategoryIDs: nil,
+				SortColumn:  "created_at",
+				SortType:    "asc",
 			},
 			wantErr: false,
 		},
 		{
-			name: "rating desc",
+			name: "score desc",
 			fields: fields{
-				Search:     "",
-				CategoryID: 0,
-				SortColumn: "rating",
+				SortColumn: "score",
 				SortType:   "desc",
 			},
 			wantErr: false,
 		},
+		{
+			name: "rating desc",
+			fields: fields{
+				Search:      "",
+				CategoryIDs: nil,
+				SortColumn:  "rating",
+				SortType:    "desc",
+			},
+			wantErr: false,
+		},
+		{
+			name: "min price above max price",
+			fields: fields{
+				MinPrice: 20000,
+				MaxPrice: 10000,
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative price",
+			fields: fields{
+				MinPrice: -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "min weight above max weight",
+			fields: fields{
+				MinWeight: 5,
+				MaxWeight: 2,
+			},
+			wantErr: true,
+		},
+		{
+			name: "min rating out of range",
+			fields: fields{
+				MinRating: 5.5,
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid category",
+			fields: fields{
+				CategoryIDs: []int64{1, 0},
+			},
+			wantErr: true,
+		},
+		{
+			name: "facet filters",
+			fields: fields{
+				CategoryIDs: []int64{1, 3},
+				MinPrice:    10000,
+				MaxPrice:    50000,
+				MinWeight:   1,
+				MaxWeight:   10,
+				MinRating:   4,
+			},
+			wantErr: false,
+		},
+		{
+			name: "only max price",
+			fields: fields{
+				MaxPrice: 50000,
+			},
+			wantErr: false,
+		},
+		{
+			name: "relevance without search",
+			fields: fields{
+				Search:      "",
+				CategoryIDs: nil,
+				SortColumn:  "relevance",
+				SortType:    "",
+			},
+			wantErr: true,
+		},
+		{
+			name: "relevance with search",
+			fields: fields{
+				Search:      "keju",
+				CategoryIDs: nil,
+				SortColumn:  "relevance",
+				SortType:    "",
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			filter := GetProductListFilter{
-				Search:     tt.fields.Search,
-				CategoryID: tt.fields.CategoryID,
-				SortColumn: tt.fields.SortColumn,
-				SortType:   tt.fields.SortType,
+				Search:      tt.fields.Search,
+				CategoryIDs: tt.fields.CategoryIDs,
+				MinPrice:    tt.fields.MinPrice,
+				MaxPrice:    tt.fields.MaxPrice,
+				MinWeight:   tt.fields.MinWeight,
+				MaxWeight:   tt.fields.MaxWeight,
+				MinRating:   tt.fields.MinRating,
+				SortColumn:  tt.fields.SortColumn,
+				SortType:    tt.fields.SortType,
 			}
 			if err := filter.Validate(); (err != nil) != tt.wantErr {
 				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
@@ -141,3 +240,219 @@ func TestGetProductListFilter_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestProductPatch_UnmarshalJSON(t *testing.T) {
+	title := "Pototo Keju"
+	price := int64(12000)
+	tests := []struct {
+		name    string
+		data    string
+		want    ProductPatch
+		wantErr bool
+	}{
+		{
+			name: "empty patch",
+			data: `{}`,
+			want: ProductPatch{},
+		},
+		{
+			name: "only supplied fields are set",
+			data: `{"title": "Pototo Keju", "price": 12000, "category": {"id": 3}}`,
+			want: ProductPatch{
+				Title:    &title,
+				Price:    &price,
+				Category: &Category{ID: 3},
+			},
+		},
+		{
+			name:    "null removes a required field",
+			data:    `{"title": null}`,
+			wantErr: true,
+		},
+		{
+			name:    "read only field",
+			data:    `{"rating": 5}`,
+			wantErr: true,
+		},
+		{
+			name:    "wrong type",
+			data:    `{"price": "free"}`,
+			wantErr: true,
+		},
+		{
+			name:    "not an object",
+			data:    `null`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got ProductPatch
+			err := json.Unmarshal([]byte(tt.data), &got)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UnmarshalJSON() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalJSON() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductPatch_Validate(t *testing.T) {
+	empty := ""
+	zero := int64(0)
+	negative := int32(-1)
+	tests := []struct {
+		name    string
+		patch   ProductPatch
+		wantErr bool
+	}{
+		{
+			name:    "empty patch",
+			patch:   ProductPatch{},
+			wantErr: false,
+		},
+		{
+			name:    "empty sku",
+			patch:   ProductPatch{SKU: &empty},
+			wantErr: true,
+		},
+		{
+			name:    "empty image url",
+			patch:   ProductPatch{ImageURL: &empty},
+			wantErr: true,
+		},
+		{
+			name:    "empty category id",
+			patch:   ProductPatch{Category: &Category{Name: "Food"}},
+			wantErr: true,
+		},
+		{
+			name:    "zero price",
+			patch:   ProductPatch{Price: &zero},
+			wantErr: true,
+		},
+		{
+			name:    "negative weight",
+			patch:   ProductPatch{Weight: &negative},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.patch.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetReviewListFilter_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  GetReviewListFilter
+		want    GetReviewListFilter
+		wantErr bool
+	}{
+		{
+			name:    "defaults",
+			filter:  GetReviewListFilter{},
+			want:    GetReviewListFilter{Sort: "newest", Page: 1, Size: 10},
+			wantErr: false,
+		},
+		{
+			name:    "star filter and lowest first",
+			filter:  GetReviewListFilter{Rating: 1, Sort: "lowest", Page: 2, Size: 5},
+			want:    GetReviewListFilter{Rating: 1, Sort: "lowest", Page: 2, Size: 5},
+			wantErr: false,
+		},
+		{
+			name:    "most helpful first",
+			filter:  GetReviewListFilter{Sort: "helpful"},
+			want:    GetReviewListFilter{Sort: "helpful", Page: 1, Size: 10},
+			wantErr: false,
+		},
+		{
+			name:    "rating out of range",
+			filter:  GetReviewListFilter{Rating: 6},
+			wantErr: true,
+		},
+		{
+			name:    "unknown sort",
+			filter:  GetReviewListFilter{Sort: "pr.id; DROP TABLE product_reviews"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(tt.filter, tt.want) {
+				t.Errorf("Validate() filter = %+v, want %+v", tt.filter, tt.want)
+			}
+		})
+	}
+}
+
+func TestVoteReviewRequest_Validate(t *testing.T) {
+	helpful := false
+	tests := []struct {
+		name    string
+		req     VoteReviewRequest
+		wantErr bool
+	}{
+		{
+			name:    "missing vote",
+			req:     VoteReviewRequest{},
+			wantErr: true,
+		},
+		{
+			name:    "not helpful",
+			req:     VoteReviewRequest{Helpful: &helpful},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.req.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReplyReviewRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     ReplyReviewRequest
+		wantErr bool
+	}{
+		{
+			name:    "blank comment",
+			req:     ReplyReviewRequest{Comment: " \n"},
+			wantErr: true,
+		},
+		{
+			name:    "too long",
+			req:     ReplyReviewRequest{Comment: strings.Repeat("a", 257)},
+			wantErr: true,
+		},
+		{
+			name:    "longest comment",
+			req:     ReplyReviewRequest{Comment: strings.Repeat("é", 256)},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.req.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
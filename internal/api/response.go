@@ -1,5 +1,7 @@
 package api
 
+import "time"
+
 type MutationResponse struct {
 	Success bool `json:"success"`
 }
@@ -8,3 +10,141 @@ type ErrorResponse struct {
 	Success bool   `json:"success"`
 	Error   string `json:"error"`
 }
+
+type Pagination struct {
+	Page       int64  `json:"page,omitempty"`
+	Size       int64  `json:"size"`
+	TotalItems int64  `json:"totalItems"`
+	TotalPages int64  `json:"totalPages"`
+	Next       string `json:"next,omitempty"`
+	Prev       string `json:"prev,omitempty"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+type ProductListResponse struct {
+	Items []Product `json:"items"`
+	Pagination
+	Facets ProductFacets `json:"facets"`
+	// Truncated is set when a text search matched more products than are
+	// listed. The items, the total and the facets then only cover the most
+	// relevant matches.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+type ProductFacets struct {
+	Categories []CategoryFacet `json:"categories"`
+	Prices     []PriceFacet    `json:"prices"`
+	Ratings    []RatingFacet   `json:"ratings"`
+}
+
+type CategoryListResponse struct {
+	Items []Category `json:"items"`
+}
+
+type CategoryTreeResponse struct {
+	Items []CategoryTree `json:"items"`
+}
+
+// CategoryTree is a category with its subcategories.
+type CategoryTree struct {
+	ID       int64          `json:"id"`
+	Name     string         `json:"name"`
+	Children []CategoryTree `json:"children"`
+}
+
+type CategoryFacet struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+type PriceFacet struct {
+	Min   int64  `json:"min"`
+	Max   *int64 `json:"max"`
+	Count int64  `json:"count"`
+}
+
+type RatingFacet struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Count int64   `json:"count"`
+}
+
+type ProductHistoryResponse struct {
+	Items []ProductHistory `json:"items"`
+	Pagination
+}
+
+type ProductHistory struct {
+	ID        int64            `json:"id"`
+	Action    string           `json:"action"`
+	Actor     Actor            `json:"actor"`
+	Before    *ProductSnapshot `json:"before"`
+	After     *ProductSnapshot `json:"after"`
+	CreatedAt time.Time        `json:"createdAt"`
+}
+
+type ProductPriceResponse struct {
+	Items []ProductPrice `json:"items"`
+	Pagination
+}
+
+type ProductPrice struct {
+	Price         int64      `json:"price"`
+	EffectiveFrom time.Time  `json:"effectiveFrom"`
+	EffectiveTo   *time.Time `json:"effectiveTo"`
+}
+
+type ProductReviewListResponse struct {
+	Items []ProductReview `json:"items"`
+	Pagination
+}
+
+type ProductReview struct {
+	ID             int64  `json:"id"`
+	Rating         int32  `json:"rating"`
+	Comment        string `json:"comment"`
+	Reviewer       Actor  `json:"reviewer"`
+	HelpfulCount   int64  `json:"helpfulCount"`
+	UnhelpfulCount int64  `json:"unhelpfulCount"`
+	// Reply is null until the vendor of the product replies.
+	Reply     *ReviewReply `json:"reply"`
+	CreatedAt time.Time    `json:"createdAt"`
+	UpdatedAt time.Time    `json:"updatedAt"`
+}
+
+type ReviewReply struct {
+	Comment   string    `json:"comment"`
+	Vendor    Actor     `json:"vendor"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type PendingReviewListResponse struct {
+	Items []PendingReview `json:"items"`
+	Pagination
+}
+
+// PendingReview is a review waiting for moderation.
+type PendingReview struct {
+	ProductReview
+	ProductID  int64  `json:"productId"`
+	FlagReason string `json:"flagReason"`
+}
+
+type Actor struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+type ProductSnapshot struct {
+	SKU         string  `json:"sku"`
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	CategoryID  int64   `json:"categoryId"`
+	ImageURL    string  `json:"imageUrl"`
+	Weight      int32   `json:"weight"`
+	Price       int64   `json:"price"`
+	Rating      float32 `json:"rating"`
+	Version     int64   `json:"version"`
+}
@@ -1,6 +1,11 @@
 package api
 
-import "errors"
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
 
 type Product struct {
 	ID          int64    `json:"id"`
@@ -12,11 +17,75 @@ type Product struct {
 	Weight      int32    `json:"weight"`
 	Price       int64    `json:"price"`
 	Rating      float32  `json:"rating"`
+	RatingScore float64  `json:"ratingScore"`
+	Relevance   float64  `json:"relevance,omitempty"`
+	// ReviewCount and RatingDistribution are only set on the product detail.
+	// The distribution maps every star rating to its number of reviews.
+	ReviewCount        *int64          `json:"reviewCount,omitempty"`
+	RatingDistribution map[int32]int64 `json:"ratingDistribution,omitempty"`
+	// AsOf is set when Price is the price valid at that time instead of the
+	// current one.
+	AsOf *time.Time `json:"asOf,omitempty"`
+	// Version and UpdatedAt are sent as the ETag and Last-Modified headers
+	// instead of the body.
+	Version   int64     `json:"-"`
+	UpdatedAt time.Time `json:"-"`
 }
 
+// Values of the Version of a write request, read from the If-Match header,
+// other than a product version.
+const (
+	// VersionMissing is the Version of a request without If-Match header.
+	VersionMissing int64 = 0
+	// VersionNone never matches, the header has no product version.
+	VersionNone int64 = -1
+	// VersionAny matches any version of an existing product.
+	VersionAny int64 = -2
+)
+
 type Category struct {
 	ID   int64  `json:"id"`
 	Name string `json:"name"`
+	// ParentID is zero for a root category.
+	ParentID int64 `json:"parentId,omitempty"`
+	// Path is the breadcrumb of the category on products, from its root
+	// category down to the category itself.
+	Path []Category `json:"path,omitempty"`
+	// RatingPrior is only set on the category endpoints, a category without
+	// one scores its products with the global prior.
+	RatingPrior *RatingPrior `json:"ratingPrior,omitempty"`
+}
+
+// RatingPrior scores a product as if it also had Weight reviews rating Mean.
+type RatingPrior struct {
+	Mean   float64 `json:"mean"`
+	Weight float64 `json:"weight"`
+}
+
+// maxCategoryNameLength is the longest category name, in characters.
+const maxCategoryNameLength = 50
+
+// Validate validates a category to create or to replace.
+func (c Category) Validate() error {
+	if strings.TrimSpace(c.Name) == "" {
+		return errors.New("empty name")
+	}
+	if utf8.RuneCountInString(c.Name) > maxCategoryNameLength {
+		return errors.New("name is too long")
+	}
+	if c.ParentID < 0 {
+		return errors.New("invalid parent id")
+	}
+	if c.RatingPrior != nil {
+		if c.RatingPrior.Mean < 1 || c.RatingPrior.Mean > 5 {
+			return errors.New("invalid rating prior mean")
+		}
+		if c.RatingPrior.Weight <= 0 {
+			return errors.New("invalid rating prior weight")
+		}
+	}
+
+	return nil
 }
 
 func (p Product) ValidateCreate() error {
@@ -35,13 +104,17 @@ func (p Product) ValidateCreate() error {
 	if p.Category.ID == 0 {
 		return errors.New("empty category id")
 	}
-	if p.Price == 0 {
+	if p.Weight < 0 {
+		return errors.New("invalid weight")
+	}
+	if p.Price <= 0 {
 		return errors.New("empty price")
 	}
 
 	return nil
 }
 
+// ValidateUpdate validates a full replacement of the mutable product fields.
 func (p Product) ValidateUpdate() error {
 	if p.SKU == "" {
 		return errors.New("empty SKU")
@@ -52,9 +125,18 @@ func (p Product) ValidateUpdate() error {
 	if p.Description == "" {
 		return errors.New("empty description")
 	}
+	if p.ImageURL == "" {
+		return errors.New("empty image url")
+	}
 	if p.Category.ID == 0 {
 		return errors.New("empty category id")
 	}
+	if p.Weight < 0 {
+		return errors.New("invalid weight")
+	}
+	if p.Price <= 0 {
+		return errors.New("empty price")
+	}
 
 	return nil
 }
@@ -1,6 +1,9 @@
 package api
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestProduct_ValidateCreate(t *testing.T) {
 	type fields struct {
@@ -121,6 +124,40 @@ func TestProduct_ValidateCreate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "negative price",
+			fields: fields{
+				ID:          3,
+				SKU:         "SKU001",
+				Title:       "title",
+				Description: "test",
+				Category: Category{
+					ID: 5,
+				},
+				ImageURL: "https://foo.bar/foo.jpg",
+				Weight:   1,
+				Price:    -1000,
+				Rating:   0,
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative weight",
+			fields: fields{
+				ID:          3,
+				SKU:         "SKU001",
+				Title:       "title",
+				Description: "test",
+				Category: Category{
+					ID: 5,
+				},
+				ImageURL: "https://foo.bar/foo.jpg",
+				Weight:   -1,
+				Price:    1000,
+				Rating:   0,
+			},
+			wantErr: true,
+		},
 		{
 			name: "success",
 			fields: fields{
@@ -241,7 +278,7 @@ func TestProduct_ValidateUpdate(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name: "success",
+			name: "empty image url",
 			fields: fields{
 				ID:          3,
 				SKU:         "SKU001",
@@ -254,6 +291,57 @@ func TestProduct_ValidateUpdate(t *testing.T) {
 				Price:  1000,
 				Rating: 0,
 			},
+			wantErr: true,
+		},
+		{
+			name: "empty price",
+			fields: fields{
+				ID:          3,
+				SKU:         "SKU001",
+				Title:       "title",
+				Description: "test",
+				Category: Category{
+					ID: 5,
+				},
+				ImageURL: "https://foo.bar/foo.jpg",
+				Weight:   1,
+				Price:    0,
+				Rating:   0,
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative weight",
+			fields: fields{
+				ID:          3,
+				SKU:         "SKU001",
+				Title:       "title",
+				Description: "test",
+				Category: Category{
+					ID: 5,
+				},
+				ImageURL: "https://foo.bar/foo.jpg",
+				Weight:   -1,
+				Price:    1000,
+				Rating:   0,
+			},
+			wantErr: true,
+		},
+		{
+			name: "success",
+			fields: fields{
+				ID:          3,
+				SKU:         "SKU001",
+				Title:       "title",
+				Description: "test",
+				Category: Category{
+					ID: 5,
+				},
+				ImageURL: "https://foo.bar/foo.jpg",
+				Weight:   1,
+				Price:    1000,
+				Rating:   0,
+			},
 			wantErr: false,
 		},
 	}
@@ -276,3 +364,44 @@ func TestProduct_ValidateUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestCategory_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		category Category
+		wantErr  bool
+	}{
+		{
+			name:     "empty name",
+			category: Category{Name: " "},
+			wantErr:  true,
+		},
+		{
+			name:     "name too long",
+			category: Category{Name: strings.Repeat("a", 51)},
+			wantErr:  true,
+		},
+		{
+			name:     "prior mean out of range",
+			category: Category{Name: "Toys", RatingPrior: &RatingPrior{Mean: 0.5, Weight: 5}},
+			wantErr:  true,
+		},
+		{
+			name:     "prior without weight",
+			category: Category{Name: "Toys", RatingPrior: &RatingPrior{Mean: 3}},
+			wantErr:  true,
+		},
+		{
+			name:     "valid",
+			category: Category{Name: "Toys", RatingPrior: &RatingPrior{Mean: 3, Weight: 5}},
+			wantErr:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.category.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -3,12 +3,36 @@ package controller
 import (
 	"github.com/alam/govtech/internal/api"
 	"github.com/alam/govtech/internal/service"
+	"github.com/alam/govtech/internal/util/authhelper"
 	"github.com/alam/govtech/internal/util/httphelper"
 	"github.com/gorilla/mux"
 	"net/http"
 )
 
-func NewController(svc service.Service) http.Handler {
+// CacheConfig holds the Cache-Control header sent by each cacheable route, an
+// empty value sends none.
+type CacheConfig struct {
+	Product     string
+	ProductList string
+}
+
+// DefaultCacheConfig lets clients and shared caches keep product reads but
+// makes them revalidate with the ETag before every reuse.
+var DefaultCacheConfig = CacheConfig{
+	Product:     "public, no-cache",
+	ProductList: "public, no-cache",
+}
+
+// AuthConfig controls how callers are identified.
+type AuthConfig struct {
+	// TrustIdentityHeaders reads the actor from the X-User-ID, X-User-Name and
+	// X-User-Roles headers. It must only be set behind a gateway that
+	// authenticates callers and strips these headers from their requests,
+	// otherwise every request is anonymous.
+	TrustIdentityHeaders bool
+}
+
+func NewController(svc service.Service, cache CacheConfig, auth AuthConfig) http.Handler {
 	r := mux.NewRouter()
 
 	ctrl := controller{
@@ -16,10 +40,32 @@ func NewController(svc service.Service) http.Handler {
 	}
 
 	r.HandleFunc("/products", ctrl.CreateProduct).Methods(http.MethodPost)
-	r.HandleFunc("/products", ctrl.GetProductList).Methods(http.MethodGet)
-	r.HandleFunc("/products/{productID}", ctrl.GetProduct).Methods(http.MethodGet)
+	r.Handle("/products", httphelper.CacheControl(cache.ProductList, ctrl.GetProductList)).Methods(http.MethodGet)
+	r.Handle("/products/{productID}", httphelper.CacheControl(cache.Product, ctrl.GetProduct)).Methods(http.MethodGet)
 	r.HandleFunc("/products/{productID}", ctrl.UpdateProduct).Methods(http.MethodPut)
+	r.HandleFunc("/products/{productID}", ctrl.PatchProduct).Methods(http.MethodPatch)
+	r.HandleFunc("/products/{productID}", ctrl.DeleteProduct).Methods(http.MethodDelete)
+	r.HandleFunc("/products/{productID}/action/restore", ctrl.RestoreProduct).Methods(http.MethodPost)
 	r.HandleFunc("/products/{productID}/action/review", ctrl.ReviewProduct).Methods(http.MethodPost)
+	r.HandleFunc("/products/{productID}/reviews", ctrl.GetProductReviews).Methods(http.MethodGet)
+	r.HandleFunc("/products/{productID}/reviews/{reviewID}", ctrl.UpdateReview).Methods(http.MethodPut)
+	r.HandleFunc("/products/{productID}/reviews/{reviewID}", ctrl.DeleteReview).Methods(http.MethodDelete)
+	r.HandleFunc("/products/{productID}/reviews/{reviewID}/action/vote", ctrl.VoteReview).Methods(http.MethodPost)
+	r.HandleFunc("/products/{productID}/reviews/{reviewID}/reply", ctrl.ReplyReview).Methods(http.MethodPut)
+	r.HandleFunc("/products/{productID}/reviews/{reviewID}/reply", ctrl.DeleteReviewReply).Methods(http.MethodDelete)
+	r.HandleFunc("/products/{productID}/history", ctrl.GetProductHistory).Methods(http.MethodGet)
+	r.HandleFunc("/products/{productID}/prices", ctrl.GetProductPrices).Methods(http.MethodGet)
+	r.HandleFunc("/categories", ctrl.GetCategoryList).Methods(http.MethodGet)
+	r.HandleFunc("/categories", ctrl.CreateCategory).Methods(http.MethodPost)
+	r.HandleFunc("/categories/tree", ctrl.GetCategoryTree).Methods(http.MethodGet)
+	r.HandleFunc("/categories/{categoryID}", ctrl.GetCategory).Methods(http.MethodGet)
+	r.HandleFunc("/categories/{categoryID}", ctrl.UpdateCategory).Methods(http.MethodPut)
+	r.HandleFunc("/categories/{categoryID}", ctrl.DeleteCategory).Methods(http.MethodDelete)
+	r.HandleFunc("/categories/{categoryID}/breadcrumbs", ctrl.GetCategoryBreadcrumbs).Methods(http.MethodGet)
+	r.HandleFunc("/admin/reviews/pending", ctrl.GetPendingReviews).Methods(http.MethodGet)
+	r.HandleFunc("/admin/reviews/{reviewID}/action/moderate", ctrl.ModerateReview).Methods(http.MethodPost)
+
+	r.Use(authhelper.Middleware(auth.TrustIdentityHeaders))
 
 	return r
 }
@@ -47,12 +93,19 @@ func (c *controller) CreateProduct(w http.ResponseWriter, r *http.Request) {
 
 func (c *controller) GetProductList(w http.ResponseWriter, r *http.Request) {
 	filter := api.GetProductListFilter{
-		Search:     r.URL.Query().Get("search"),
-		CategoryID: httphelper.ReadQueryParamInt(r, "category"),
-		SortColumn: r.URL.Query().Get("sort"),
-		SortType:   r.URL.Query().Get("sort_type"),
-		Page:       httphelper.ReadQueryParamInt(r, "page"),
-		Size:       httphelper.ReadQueryParamInt(r, "size"),
+		Search:               r.URL.Query().Get("search"),
+		CategoryIDs:          httphelper.ReadQueryParamIntList(r, "category"),
+		IncludeSubcategories: httphelper.ReadQueryParamBool(r, "include_subcategories"),
+		MinPrice:             httphelper.ReadQueryParamInt(r, "min_price"),
+		MaxPrice:             httphelper.ReadQueryParamInt(r, "max_price"),
+		MinWeight:            int32(httphelper.ReadQueryParamInt(r, "min_weight")),
+		MaxWeight:            int32(httphelper.ReadQueryParamInt(r, "max_weight")),
+		MinRating:            httphelper.ReadQueryParamFloat(r, "min_rating"),
+		SortColumn:           r.URL.Query().Get("sort"),
+		SortType:             r.URL.Query().Get("sort_type"),
+		Cursor:               r.URL.Query().Get("cursor"),
+		Page:                 httphelper.ReadQueryParamInt(r, "page"),
+		Size:                 httphelper.ReadQueryParamInt(r, "size"),
 	}
 
 	res, err := c.svc.GetProductList(r.Context(), filter)
@@ -61,19 +114,35 @@ func (c *controller) GetProductList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	httphelper.Write(w, res)
+	// A list also changes when one of its products is deleted, which no item
+	// timestamp reflects, so it is only validated by the hash of its body.
+	httphelper.WriteConditional(w, r, httphelper.Validators{}, res)
 }
 
 func (c *controller) GetProduct(w http.ResponseWriter, r *http.Request) {
 	id := httphelper.ReadPathVarInt(r, "productID")
 
-	res, err := c.svc.GetProduct(r.Context(), id)
+	filter := api.GetProductFilter{
+		AsOf: r.URL.Query().Get("as_of"),
+	}
+
+	res, err := c.svc.GetProduct(r.Context(), id, filter)
 	if err != nil {
 		httphelper.WriteError(w, err)
 		return
 	}
 
-	httphelper.Write(w, res)
+	// A past price is not described by the current version, so it is only
+	// validated by the hash of its body.
+	if res.AsOf != nil {
+		httphelper.WriteConditional(w, r, httphelper.Validators{}, res)
+		return
+	}
+
+	httphelper.WriteConditional(w, r, httphelper.Validators{
+		ETag:         httphelper.VersionETag(res.Version, res.UpdatedAt),
+		LastModified: res.UpdatedAt,
+	}, res)
 }
 
 func (c *controller) UpdateProduct(w http.ResponseWriter, r *http.Request) {
@@ -85,6 +154,7 @@ func (c *controller) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 		httphelper.WriteError(w, err)
 		return
 	}
+	body.Version = readIfMatchVersion(r)
 
 	res, err := c.svc.UpdateProduct(r.Context(), id, body)
 	if err != nil {
@@ -95,6 +165,56 @@ func (c *controller) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	httphelper.Write(w, res)
 }
 
+func (c *controller) PatchProduct(w http.ResponseWriter, r *http.Request) {
+	id := httphelper.ReadPathVarInt(r, "productID")
+
+	err := httphelper.RequireContentType(r, "application/merge-patch+json", "application/json")
+	if err != nil {
+		httphelper.WriteError(w, err)
+		return
+	}
+
+	var body api.ProductPatch
+	err = httphelper.ReadBody(r, &body)
+	if err != nil {
+		httphelper.WriteError(w, err)
+		return
+	}
+	body.Version = readIfMatchVersion(r)
+
+	res, err := c.svc.PatchProduct(r.Context(), id, body)
+	if err != nil {
+		httphelper.WriteError(w, err)
+		return
+	}
+
+	httphelper.Write(w, res)
+}
+
+func (c *controller) DeleteProduct(w http.ResponseWriter, r *http.Request) {
+	id := httphelper.ReadPathVarInt(r, "productID")
+
+	res, err := c.svc.DeleteProduct(r.Context(), id)
+	if err != nil {
+		httphelper.WriteError(w, err)
+		return
+	}
+
+	httphelper.Write(w, res)
+}
+
+func (c *controller) RestoreProduct(w http.ResponseWriter, r *http.Request) {
+	id := httphelper.ReadPathVarInt(r, "productID")
+
+	res, err := c.svc.RestoreProduct(r.Context(), id)
+	if err != nil {
+		httphelper.WriteError(w, err)
+		return
+	}
+
+	httphelper.Write(w, res)
+}
+
 func (c *controller) ReviewProduct(w http.ResponseWriter, r *http.Request) {
 	id := httphelper.ReadPathVarInt(r, "productID")
 
@@ -113,3 +233,282 @@ func (c *controller) ReviewProduct(w http.ResponseWriter, r *http.Request) {
 
 	httphelper.Write(w, res)
 }
+
+func (c *controller) GetProductHistory(w http.ResponseWriter, r *http.Request) {
+	id := httphelper.ReadPathVarInt(r, "productID")
+	filter := api.PageFilter{
+		Page: httphelper.ReadQueryParamInt(r, "page"),
+		Size: httphelper.ReadQueryParamInt(r, "size"),
+	}
+
+	res, err := c.svc.GetProductHistory(r.Context(), id, filter)
+	if err != nil {
+		httphelper.WriteError(w, err)
+		return
+	}
+
+	httphelper.Write(w, res)
+}
+
+func (c *controller) GetProductPrices(w http.ResponseWriter, r *http.Request) {
+	id := httphelper.ReadPathVarInt(r, "productID")
+	filter := api.PageFilter{
+		Page: httphelper.ReadQueryParamInt(r, "page"),
+		Size: httphelper.ReadQueryParamInt(r, "size"),
+	}
+
+	res, err := c.svc.GetProductPrices(r.Context(), id, filter)
+	if err != nil {
+		httphelper.WriteError(w, err)
+		return
+	}
+
+	httphelper.Write(w, res)
+}
+
+func (c *controller) GetProductReviews(w http.ResponseWriter, r *http.Request) {
+	id := httphelper.ReadPathVarInt(r, "productID")
+	filter := api.GetReviewListFilter{
+		Rating: int32(httphelper.ReadQueryParamInt(r, "rating")),
+		Sort:   r.URL.Query().Get("sort"),
+		Page:   httphelper.ReadQueryParamInt(r, "page"),
+		Size:   httphelper.ReadQueryParamInt(r, "size"),
+	}
+
+	res, err := c.svc.GetProductReviews(r.Context(), id, filter)
+	if err != nil {
+		httphelper.WriteError(w, err)
+		return
+	}
+
+	httphelper.Write(w, res)
+}
+
+func (c *controller) UpdateReview(w http.ResponseWriter, r *http.Request) {
+	productID := httphelper.ReadPathVarInt(r, "productID")
+	reviewID := httphelper.ReadPathVarInt(r, "reviewID")
+
+	var body api.ReviewProductRequest
+	err := httphelper.ReadBody(r, &body)
+	if err != nil {
+		httphelper.WriteError(w, err)
+		return
+	}
+
+	res, err := c.svc.UpdateReview(r.Context(), productID, reviewID, body)
+	if err != nil {
+		httphelper.WriteError(w, err)
+		return
+	}
+
+	httphelper.Write(w, res)
+}
+
+func (c *controller) DeleteReview(w http.ResponseWriter, r *http.Request) {
+	productID := httphelper.ReadPathVarInt(r, "productID")
+	reviewID := httphelper.ReadPathVarInt(r, "reviewID")
+
+	res, err := c.svc.DeleteReview(r.Context(), productID, reviewID)
+	if err != nil {
+		httphelper.WriteError(w, err)
+		return
+	}
+
+	httphelper.Write(w, res)
+}
+
+func (c *controller) VoteReview(w http.ResponseWriter, r *http.Request) {
+	productID := httphelper.ReadPathVarInt(r, "productID")
+	reviewID := httphelper.ReadPathVarInt(r, "reviewID")
+
+	var body api.VoteReviewRequest
+	err := httphelper.ReadBody(r, &body)
+	if err != nil {
+		httphelper.WriteError(w, err)
+		return
+	}
+
+	res, err := c.svc.VoteReview(r.Context(), productID, reviewID, body)
+	if err != nil {
+		httphelper.WriteError(w, err)
+		return
+	}
+
+	httphelper.Write(w, res)
+}
+
+func (c *controller) ReplyReview(w http.ResponseWriter, r *http.Request) {
+	productID := httphelper.ReadPathVarInt(r, "productID")
+	reviewID := httphelper.ReadPathVarInt(r, "reviewID")
+
+	var body api.ReplyReviewRequest
+	err := httphelper.ReadBody(r, &body)
+	if err != nil {
+		httphelper.WriteError(w, err)
+		return
+	}
+
+	res, err := c.svc.ReplyReview(r.Context(), productID, reviewID, body)
+	if err != nil {
+		httphelper.WriteError(w, err)
+		return
+	}
+
+	httphelper.Write(w, res)
+}
+
+func (c *controller) DeleteReviewReply(w http.ResponseWriter, r *http.Request) {
+	productID := httphelper.ReadPathVarInt(r, "productID")
+	reviewID := httphelper.ReadPathVarInt(r, "reviewID")
+
+	res, err := c.svc.DeleteReviewReply(r.Context(), productID, reviewID)
+	if err != nil {
+		httphelper.WriteError(w, err)
+		return
+	}
+
+	httphelper.Write(w, res)
+}
+
+func (c *controller) GetPendingReviews(w http.ResponseWriter, r *http.Request) {
+	filter := api.GetPendingReviewListFilter{
+		Page: httphelper.ReadQueryParamInt(r, "page"),
+		Size: httphelper.ReadQueryParamInt(r, "size"),
+	}
+
+	res, err := c.svc.GetPendingReviews(r.Context(), filter)
+	if err != nil {
+		httphelper.WriteError(w, err)
+		return
+	}
+
+	httphelper.Write(w, res)
+}
+
+func (c *controller) ModerateReview(w http.ResponseWriter, r *http.Request) {
+	id := httphelper.ReadPathVarInt(r, "reviewID")
+
+	var body api.ModerateReviewRequest
+	err := httphelper.ReadBody(r, &body)
+	if err != nil {
+		httphelper.WriteError(w, err)
+		return
+	}
+
+	res, err := c.svc.ModerateReview(r.Context(), id, body)
+	if err != nil {
+		httphelper.WriteError(w, err)
+		return
+	}
+
+	httphelper.Write(w, res)
+}
+
+func (c *controller) GetCategoryList(w http.ResponseWriter, r *http.Request) {
+	res, err := c.svc.GetCategoryList(r.Context())
+	if err != nil {
+		httphelper.WriteError(w, err)
+		return
+	}
+
+	httphelper.Write(w, res)
+}
+
+func (c *controller) GetCategoryTree(w http.ResponseWriter, r *http.Request) {
+	res, err := c.svc.GetCategoryTree(r.Context())
+	if err != nil {
+		httphelper.WriteError(w, err)
+		return
+	}
+
+	httphelper.Write(w, res)
+}
+
+func (c *controller) GetCategoryBreadcrumbs(w http.ResponseWriter, r *http.Request) {
+	id := httphelper.ReadPathVarInt(r, "categoryID")
+
+	res, err := c.svc.GetCategoryBreadcrumbs(r.Context(), id)
+	if err != nil {
+		httphelper.WriteError(w, err)
+		return
+	}
+
+	httphelper.Write(w, res)
+}
+
+func (c *controller) GetCategory(w http.ResponseWriter, r *http.Request) {
+	id := httphelper.ReadPathVarInt(r, "categoryID")
+
+	res, err := c.svc.GetCategory(r.Context(), id)
+	if err != nil {
+		httphelper.WriteError(w, err)
+		return
+	}
+
+	httphelper.Write(w, res)
+}
+
+func (c *controller) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	var body api.Category
+	err := httphelper.ReadBody(r, &body)
+	if err != nil {
+		httphelper.WriteError(w, err)
+		return
+	}
+
+	res, err := c.svc.CreateCategory(r.Context(), body)
+	if err != nil {
+		httphelper.WriteError(w, err)
+		return
+	}
+
+	httphelper.Write(w, res)
+}
+
+func (c *controller) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	id := httphelper.ReadPathVarInt(r, "categoryID")
+
+	var body api.Category
+	err := httphelper.ReadBody(r, &body)
+	if err != nil {
+		httphelper.WriteError(w, err)
+		return
+	}
+
+	res, err := c.svc.UpdateCategory(r.Context(), id, body)
+	if err != nil {
+		httphelper.WriteError(w, err)
+		return
+	}
+
+	httphelper.Write(w, res)
+}
+
+func (c *controller) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	id := httphelper.ReadPathVarInt(r, "categoryID")
+	req := api.DeleteCategoryRequest{
+		ReassignTo: httphelper.ReadQueryParamInt(r, "reassign_to"),
+	}
+
+	res, err := c.svc.DeleteCategory(r.Context(), id, req)
+	if err != nil {
+		httphelper.WriteError(w, err)
+		return
+	}
+
+	httphelper.Write(w, res)
+}
+
+// readIfMatchVersion returns the product version a write is made from.
+func readIfMatchVersion(r *http.Request) int64 {
+	switch version := httphelper.ReadIfMatchVersion(r); version {
+	case httphelper.IfMatchMissing:
+		return api.VersionMissing
+	case httphelper.IfMatchNone:
+		return api.VersionNone
+	case httphelper.IfMatchAny:
+		return api.VersionAny
+	default:
+		return version
+	}
+}
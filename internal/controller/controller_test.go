@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"github.com/alam/govtech/internal/model"
+	"github.com/alam/govtech/internal/service"
+	"github.com/alam/govtech/mocks"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_controller_ifMatch(t *testing.T) {
+	const (
+		putBody   = `{"sku":"IND005","title":"title","description":"description","category":{"id":5},"imageUrl":"https://foo.bar/bar.jpg","weight":3,"price":20000}`
+		patchBody = `{"title":"title"}`
+	)
+
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		ifMatch    string
+		statusCode int
+	}{
+		{
+			name:       "put without If-Match",
+			method:     http.MethodPut,
+			body:       putBody,
+			statusCode: http.StatusPreconditionRequired,
+		},
+		{
+			name:       "put with weak tag",
+			method:     http.MethodPut,
+			body:       putBody,
+			ifMatch:    `W/"4"`,
+			statusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:       "put with foreign tag",
+			method:     http.MethodPut,
+			body:       putBody,
+			ifMatch:    `"abc"`,
+			statusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:       "put with stale version",
+			method:     http.MethodPut,
+			body:       putBody,
+			ifMatch:    `"3"`,
+			statusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:       "put with any version",
+			method:     http.MethodPut,
+			body:       putBody,
+			ifMatch:    "*",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "patch without If-Match",
+			method:     http.MethodPatch,
+			body:       patchBody,
+			statusCode: http.StatusPreconditionRequired,
+		},
+		{
+			name:       "patch with weak tag",
+			method:     http.MethodPatch,
+			body:       patchBody,
+			ifMatch:    `W/"4"`,
+			statusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:       "patch with current version",
+			method:     http.MethodPatch,
+			body:       patchBody,
+			ifMatch:    `"4.1701943200000000"`,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "patch with any version",
+			method:     http.MethodPatch,
+			body:       patchBody,
+			ifMatch:    "*",
+			statusCode: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			productRepo := new(mocks.ProductRepository)
+			categoryRepo := new(mocks.CategoryRepository)
+			categoryRepo.On("GetCategory", mock.Anything, int64(5)).
+				Return(model.Category{ID: 5}, nil).Maybe()
+			productRepo.On("GetProduct", mock.Anything, int64(3)).
+				Return(model.Product{ID: 3, SKU: "IND005", Category: model.Category{ID: 5}, Version: 4}, nil).Maybe()
+			productRepo.On("UpdateProduct", mock.Anything, int64(3), mock.MatchedBy(func(product model.Product) bool {
+				return product.Version == 4
+			}), mock.Anything).Return(nil).Maybe()
+
+			svc := service.NewService(productRepo, categoryRepo, nil, nil, nil, nil, nil, nil, nil, nil, model.RatingPrior{})
+			handler := NewController(svc, DefaultCacheConfig, AuthConfig{})
+
+			r := httptest.NewRequest(tt.method, "/products/3", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/json")
+			if tt.ifMatch != "" {
+				r.Header.Set("If-Match", tt.ifMatch)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.statusCode {
+				t.Errorf("%s /products/3 = %v %s, want %v", tt.method, w.Code, w.Body.String(), tt.statusCode)
+			}
+		})
+	}
+}
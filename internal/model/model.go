@@ -1,6 +1,39 @@
 package model
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+// ErrDuplicateSKU is returned when a product is saved with the sku of another
+// product. Deleted products keep their sku, so it can not be reused until the
+// product is restored or purged.
+var ErrDuplicateSKU = errors.New("duplicate sku")
+
+// ErrVersionConflict is returned when a product is saved from a version that is
+// no longer the current one.
+var ErrVersionConflict = errors.New("version conflict")
+
+// ErrDuplicateReview is returned when a user reviews a product they have
+// already reviewed.
+var ErrDuplicateReview = errors.New("duplicate review")
+
+// ErrDuplicateVote is returned when a user votes on a review they have already
+// voted on.
+var ErrDuplicateVote = errors.New("duplicate vote")
+
+// ErrUnsupportedQuery is returned by a searcher for a query it can not answer,
+// such as one without any word or one with literal wildcard characters. Such
+// queries are searched by the product repository instead.
+var ErrUnsupportedQuery = errors.New("unsupported query")
+
+// ErrDuplicateCategoryName is returned when a category is saved with the name
+// of another category.
+var ErrDuplicateCategoryName = errors.New("duplicate category name")
+
+// ErrCategoryInUse is returned when a category is deleted while products,
+// deleted ones included, or subcategories still belong to it.
+var ErrCategoryInUse = errors.New("category in use")
 
 type Product struct {
 	ID          int64
@@ -12,32 +45,220 @@ type Product struct {
 	Weight      int32
 	Price       int64
 	Rating      float32
+	// RatingScore is the Bayesian average of the ratings, see RatingPrior.
+	RatingScore float64
 	CreatedAt   time.Time
+	Relevance   float64
+	// Version is incremented on every update of the product.
+	Version int64
+	// UpdatedAt changes on every write, including rating changes which do
+	// not increment Version.
+	UpdatedAt time.Time
+	// VendorID is the user who listed the product, zero when it is unknown.
+	VendorID int64
 }
 
 type GetProductListFilter struct {
-	Search     string
-	CategoryID int64
-	SortColumn string
-	SortType   string
-	Limit      int64
-	Offset     int64
+	Search      string
+	IDs         []int64
+	CategoryIDs []int64
+	MinPrice    int64
+	MaxPrice    int64
+	MinWeight   int32
+	MaxWeight   int32
+	MinRating   float64
+	SortColumn  string
+	SortType    string
+	After       *ProductCursor
+	Limit       int64
+	Offset      int64
+}
+
+// ProductCursor is the position of the last product of a page, the next page
+// starts right after it in the current sort order.
+type ProductCursor struct {
+	// Value is the sort value of the product, it is ignored when the list is
+	// only sorted by id.
+	Value interface{}
+	ID    int64
+}
+
+type ProductFacets struct {
+	Categories []CategoryFacet
+	Prices     []PriceFacet
+	Ratings    []RatingFacet
+}
+
+type CategoryFacet struct {
+	Category Category
+	Count    int64
+}
+
+// PriceFacet counts products with Min <= price < Max. A zero Max means the
+// bucket has no upper bound.
+type PriceFacet struct {
+	Min   int64
+	Max   int64
+	Count int64
+}
+
+// RatingFacet counts products with Min <= rating < Max, the last bucket also
+// includes products rated exactly Max.
+type RatingFacet struct {
+	Min   float64
+	Max   float64
+	Count int64
+}
+
+type SearchHit struct {
+	ProductID int64
+	Score     float64
+}
+
+// Product history actions.
+const (
+	HistoryActionCreate  = "create"
+	HistoryActionUpdate  = "update"
+	HistoryActionDelete  = "delete"
+	HistoryActionRestore = "restore"
+	HistoryActionRating  = "rating"
+)
+
+// ProductHistory is one change of a product. Before is nil for a creation and
+// After is nil for a deletion.
+type ProductHistory struct {
+	ID        int64
+	ProductID int64
+	Action    string
+	ActorID   string
+	ActorName string
+	Before    *ProductSnapshot
+	After     *ProductSnapshot
+	CreatedAt time.Time
+}
+
+// ProductSnapshot is the stored state of a product at one point of its
+// history.
+type ProductSnapshot struct {
+	SKU         string  `json:"sku"`
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	CategoryID  int64   `json:"categoryId"`
+	ImageURL    string  `json:"imageUrl"`
+	Weight      int32   `json:"weight"`
+	Price       int64   `json:"price"`
+	Rating      float32 `json:"rating"`
+	Version     int64   `json:"version"`
+}
+
+// ProductPrice is a price of a product valid from EffectiveFrom, inclusive, to
+// EffectiveTo, exclusive. A nil EffectiveTo means it is the current price.
+type ProductPrice struct {
+	ID            int64
+	ProductID     int64
+	Price         int64
+	EffectiveFrom time.Time
+	EffectiveTo   *time.Time
 }
 
 type Category struct {
 	ID   int64
 	Name string
+	// ParentID is the category this one is a subcategory of, zero for a root
+	// category.
+	ParentID int64
+	// RatingPrior scores the products of the category, nil when they use the
+	// prior configured on the service.
+	RatingPrior *RatingPrior
+}
+
+// User is a reviewer identified by the subject of the gateway.
+type User struct {
+	ID      int64
+	Subject string
+	Name    string
 }
 
+// Review statuses. Only approved reviews are public and count in the rating of
+// their product.
+const (
+	ReviewStatusPending  = "pending"
+	ReviewStatusApproved = "approved"
+	ReviewStatusRejected = "rejected"
+)
+
 type ProductReview struct {
 	ID        int64
 	UserID    int64
 	ProductID int64
 	Rating    int32
 	Comment   string
+	Status    string
+	// FlagReason is why the screener held the review for moderation.
+	FlagReason     string
+	HelpfulCount   int64
+	UnhelpfulCount int64
+	User           User
+	// Reply is the public answer of the vendor of the product, nil when the
+	// vendor has not replied.
+	Reply     *ReviewReply
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ReviewReply is the answer of the vendor of a product to one of its reviews.
+type ReviewReply struct {
+	ReviewID  int64
+	VendorID  int64
+	Comment   string
+	Vendor    User
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ReviewVote is the vote of a user on whether a review is helpful.
+type ReviewVote struct {
+	ReviewID int64
+	UserID   int64
+	Helpful  bool
+}
+
+// VoteDelta is a change to the vote counts of a review.
+type VoteDelta struct {
+	Helpful   int64
+	Unhelpful int64
+}
+
+// GetReviewListFilter selects reviews. A zero ProductID selects the reviews of
+// every product, a zero Rating every rating and an empty Status every status.
+type GetReviewListFilter struct {
+	ProductID int64
+	Rating    int32
+	Status    string
+	Sort      string
+	Limit     int64
+	Offset    int64
+}
+
+// RatingDelta is a change to the ratings of a product, added to its running
+// sum and count.
+type RatingDelta struct {
+	Sum   int64
+	Count int64
+}
+
+// RatingPrior is the prior of the Bayesian average rating of products. A
+// product is scored as if it also had Weight reviews rating Mean, so products
+// with few reviews rank close to Mean until they gather more.
+type RatingPrior struct {
+	Mean   float64
+	Weight float64
 }
 
 type Statistic struct {
 	Count   int64
 	Average float64
+	// Distribution is the number of reviews of each star rating, one star
+	// first.
+	Distribution [5]int64
 }
@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/alam/govtech/internal/model"
+)
+
+func (r *repository) GetCategory(ctx context.Context, id int64) (model.Category, error) {
+	return r.getCategory(ctx, id, false)
+}
+
+// LockCategory reads a category and locks it until the end of the transaction
+// of ctx, so its ancestors can be checked before it is moved in the tree.
+func (r *repository) LockCategory(ctx context.Context, id int64) (model.Category, error) {
+	return r.getCategory(ctx, id, true)
+}
+
+func (r *repository) getCategory(ctx context.Context, id int64, lock bool) (model.Category, error) {
+	query := `
+		SELECT 
+		    id,
+		    name,
+		    COALESCE(parent_id, 0),
+		    rating_prior_mean,
+		    rating_prior_weight
+		FROM categories 
+		WHERE id = ?
+`
+	if lock {
+		query += "\t\tFOR UPDATE\n"
+	}
+
+	var res model.Category
+	var mean, weight sql.NullFloat64
+	err := r.conn(ctx).QueryRowContext(ctx, query, id).Scan(
+		&res.ID,
+		&res.Name,
+		&res.ParentID,
+		&mean,
+		&weight,
+	)
+	if err != nil {
+		return model.Category{}, err
+	}
+	res.RatingPrior = toRatingPrior(mean, weight)
+
+	return res, nil
+}
+
+func (r *repository) GetCategoryList(ctx context.Context) ([]model.Category, error) {
+	query := `
+		SELECT 
+		    id,
+		    name,
+		    COALESCE(parent_id, 0),
+		    rating_prior_mean,
+		    rating_prior_weight
+		FROM categories 
+		ORDER BY id
+`
+	var res []model.Category
+	rows, err := r.conn(ctx).QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var data model.Category
+		var mean, weight sql.NullFloat64
+		err := rows.Scan(
+			&data.ID,
+			&data.Name,
+			&data.ParentID,
+			&mean,
+			&weight,
+		)
+		if err != nil {
+			return nil, err
+		}
+		data.RatingPrior = toRatingPrior(mean, weight)
+		res = append(res, data)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// InsertCategory returns model.ErrDuplicateCategoryName when another category
+// has the same name.
+func (r *repository) InsertCategory(ctx context.Context, category model.Category) (int64, error) {
+	query := `
+		INSERT INTO categories(name, parent_id, rating_prior_mean, rating_prior_weight)
+		VALUES(?, NULLIF(?, 0), ?, ?)
+`
+	mean, weight := fromRatingPrior(category.RatingPrior)
+	result, err := r.conn(ctx).ExecContext(ctx, query, category.Name, category.ParentID, mean, weight)
+	if isDuplicateEntry(err) {
+		return 0, model.ErrDuplicateCategoryName
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+// UpdateCategory returns model.ErrDuplicateCategoryName when another category
+// has the same name. Products show their category and its ancestors, so the
+// products of the category and of its subcategories are marked as updated as
+// well.
+func (r *repository) UpdateCategory(ctx context.Context, category model.Category) error {
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		query := `
+		UPDATE 
+		    categories 
+		SET 
+		    name = ?,
+		    parent_id = NULLIF(?, 0),
+		    rating_prior_mean = ?,
+		    rating_prior_weight = ?
+		WHERE id = ?
+`
+		mean, weight := fromRatingPrior(category.RatingPrior)
+		_, err := tx.ExecContext(ctx, query, category.Name, category.ParentID, mean, weight, category.ID)
+		if isDuplicateEntry(err) {
+			return model.ErrDuplicateCategoryName
+		}
+		if err != nil {
+			return err
+		}
+
+		query = `
+		UPDATE 
+		    products 
+		SET 
+		    updated_at = CURRENT_TIMESTAMP(6)
+		WHERE category_id IN (
+		    WITH RECURSIVE subtree AS (
+		        SELECT id FROM categories WHERE id = ?
+		        UNION ALL
+		        SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+		    )
+		    SELECT id FROM subtree
+		)
+`
+		if _, err := tx.ExecContext(ctx, query, category.ID); err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// DeleteCategory returns model.ErrCategoryInUse when products, deleted ones
+// included, still belong to the category.
+func (r *repository) DeleteCategory(ctx context.Context, id int64) error {
+	query := `
+		DELETE FROM categories 
+		WHERE id = ?
+`
+	_, err := r.conn(ctx).ExecContext(ctx, query, id)
+	if isRowReferenced(err) {
+		return model.ErrCategoryInUse
+	}
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// toRatingPrior returns the prior stored in the nullable columns of a
+// category, nil when it has none.
+func toRatingPrior(mean, weight sql.NullFloat64) *model.RatingPrior {
+	if !mean.Valid || !weight.Valid {
+		return nil
+	}
+	return &model.RatingPrior{
+		Mean:   mean.Float64,
+		Weight: weight.Float64,
+	}
+}
+
+// fromRatingPrior returns the column values of a category prior, NULL when it
+// is nil.
+func fromRatingPrior(prior *model.RatingPrior) (sql.NullFloat64, sql.NullFloat64) {
+	if prior == nil {
+		return sql.NullFloat64{}, sql.NullFloat64{}
+	}
+	return sql.NullFloat64{Float64: prior.Mean, Valid: true}, sql.NullFloat64{Float64: prior.Weight, Valid: true}
+}
@@ -0,0 +1,258 @@
+package repository
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alam/govtech/internal/model"
+	"github.com/go-sql-driver/mysql"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+var categoryColumns = []string{"id", "name", "parent_id", "rating_prior_mean", "rating_prior_weight"}
+
+func Test_repository_GetCategory(t *testing.T) {
+	tests := []struct {
+		name  string
+		get   func(r *repository, ctx context.Context, id int64) (model.Category, error)
+		query string
+	}{
+		{
+			name:  "plain read",
+			get:   (*repository).GetCategory,
+			query: regexp.QuoteMeta("FROM categories \n\t\tWHERE id = ?") + "$",
+		},
+		{
+			name:  "locking read",
+			get:   (*repository).LockCategory,
+			query: regexp.QuoteMeta("FROM categories \n\t\tWHERE id = ?\n\t\tFOR UPDATE"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to open sqlmock: %v", err)
+			}
+			defer db.Close()
+
+			mock.ExpectQuery(tt.query).
+				WithArgs(int64(3)).
+				WillReturnRows(sqlmock.NewRows(categoryColumns).AddRow(3, "Cat", 2, 4.2, 5))
+
+			got, err := tt.get(&repository{db: db}, context.Background(), 3)
+			if err != nil {
+				t.Fatalf("%v error = %v", tt.name, err)
+			}
+			want := model.Category{ID: 3, Name: "Cat", ParentID: 2, RatingPrior: &model.RatingPrior{Mean: 4.2, Weight: 5}}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%v got = %v, want %v", tt.name, got, want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("%v unmet expectation: %v", tt.name, err)
+			}
+		})
+	}
+}
+
+func Test_repository_GetCategoryList(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM categories \n\t\tORDER BY id")).
+		WillReturnRows(sqlmock.NewRows(categoryColumns).
+			AddRow(1, "Food", 0, nil, nil).
+			AddRow(2, "Pet", 0, 4.2, 5).
+			AddRow(3, "Cat", 2, nil, nil))
+
+	r := &repository{db: db}
+	got, err := r.GetCategoryList(context.Background())
+	if err != nil {
+		t.Fatalf("GetCategoryList() error = %v", err)
+	}
+	want := []model.Category{
+		{ID: 1, Name: "Food"},
+		{ID: 2, Name: "Pet", RatingPrior: &model.RatingPrior{Mean: 4.2, Weight: 5}},
+		{ID: 3, Name: "Cat", ParentID: 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetCategoryList() got = %v, want %v", got, want)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("GetCategoryList() unmet expectation: %v", err)
+	}
+}
+
+func Test_repository_InsertCategory(t *testing.T) {
+	tests := []struct {
+		name     string
+		category model.Category
+		prepare  func(mock sqlmock.Sqlmock)
+		want     int64
+		wantErr  error
+	}{
+		{
+			name:     "without prior",
+			category: model.Category{Name: "Toys"},
+			prepare: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO categories(name, parent_id, rating_prior_mean, rating_prior_weight)")).
+					WithArgs("Toys", int64(0), nil, nil).
+					WillReturnResult(sqlmock.NewResult(5, 1))
+			},
+			want:    5,
+			wantErr: nil,
+		},
+		{
+			name:     "subcategory with prior",
+			category: model.Category{Name: "Toys", ParentID: 2, RatingPrior: &model.RatingPrior{Mean: 4, Weight: 20}},
+			prepare: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta("VALUES(?, NULLIF(?, 0), ?, ?)")).
+					WithArgs("Toys", int64(2), 4.0, 20.0).
+					WillReturnResult(sqlmock.NewResult(5, 1))
+			},
+			want:    5,
+			wantErr: nil,
+		},
+		{
+			name:     "duplicate name",
+			category: model.Category{Name: "food"},
+			prepare: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO categories")).
+					WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'food' for key 'categories.uq_categories_name'"})
+			},
+			want:    0,
+			wantErr: model.ErrDuplicateCategoryName,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to open sqlmock: %v", err)
+			}
+			defer db.Close()
+			tt.prepare(mock)
+
+			r := &repository{db: db}
+			got, err := r.InsertCategory(context.Background(), tt.category)
+			if err != tt.wantErr {
+				t.Errorf("InsertCategory() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("InsertCategory() got = %v, want %v", got, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("InsertCategory() unmet expectation: %v", err)
+			}
+		})
+	}
+}
+
+func Test_repository_UpdateCategory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("rating_prior_weight = ?\n\t\tWHERE id = ?")).
+		WithArgs("Pets", int64(1), nil, nil, int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("updated_at = CURRENT_TIMESTAMP(6)\n\t\tWHERE category_id IN (\n\t\t    WITH RECURSIVE subtree AS")).
+		WithArgs(int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	r := &repository{db: db}
+	if err := r.UpdateCategory(context.Background(), model.Category{ID: 2, Name: "Pets", ParentID: 1}); err != nil {
+		t.Errorf("UpdateCategory() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("UpdateCategory() unmet expectation: %v", err)
+	}
+}
+
+func Test_repository_DeleteCategory_inUse(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM categories")).
+		WithArgs(int64(2)).
+		WillReturnError(&mysql.MySQLError{Number: 1451, Message: "Cannot delete or update a parent row: a foreign key constraint fails"})
+
+	r := &repository{db: db}
+	err = r.DeleteCategory(context.Background(), 2)
+	if err != model.ErrCategoryInUse {
+		t.Errorf("DeleteCategory() error = %v, want %v", err, model.ErrCategoryInUse)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("DeleteCategory() unmet expectation: %v", err)
+	}
+}
+
+func Test_repository_MoveCategoryProducts(t *testing.T) {
+	ctx := context.Background()
+	actor := model.User{Subject: "admin", Name: "Admin"}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	// Deleted products are moved as well, as they still reference the
+	// category.
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE category_id = ?\n\t\tORDER BY id\n\t\tFOR UPDATE")).
+		WithArgs(int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "deleted"}).
+			AddRow(3, false).
+			AddRow(4, true))
+	mock.ExpectQuery(regexp.QuoteMeta(lockActiveProduct)).
+		WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows(snapshotColumns).
+			AddRow("IND003", "title", "description", 2, "https://foo.bar/foo.jpg", 2, 10000, 4, 1))
+	mock.ExpectExec(regexp.QuoteMeta("category_id = ?,\n\t\t    version = version + 1\n\t\tWHERE id = ?")).
+		WithArgs(int64(1), int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(lockActiveProduct)).
+		WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows(snapshotColumns).
+			AddRow("IND003", "title", "description", 1, "https://foo.bar/foo.jpg", 2, 10000, 4, 2))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO product_history")).
+		WithArgs(int64(3), "update", "admin", "Admin",
+			[]byte(`{"sku":"IND003","title":"title","description":"description","categoryId":2,"imageUrl":"https://foo.bar/foo.jpg","weight":2,"price":10000,"rating":4,"version":1}`),
+			[]byte(`{"sku":"IND003","title":"title","description":"description","categoryId":1,"imageUrl":"https://foo.bar/foo.jpg","weight":2,"price":10000,"rating":4,"version":2}`)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(lockDeletedProduct)).
+		WithArgs(int64(4)).
+		WillReturnRows(sqlmock.NewRows(snapshotColumns).
+			AddRow("IND004", "title", "description", 2, "https://foo.bar/foo.jpg", 2, 10000, 0, 3))
+	mock.ExpectExec(regexp.QuoteMeta("category_id = ?,\n\t\t    version = version + 1\n\t\tWHERE id = ?")).
+		WithArgs(int64(1), int64(4)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(lockDeletedProduct)).
+		WithArgs(int64(4)).
+		WillReturnRows(sqlmock.NewRows(snapshotColumns).
+			AddRow("IND004", "title", "description", 1, "https://foo.bar/foo.jpg", 2, 10000, 0, 4))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO product_history")).
+		WithArgs(int64(4), "update", "admin", "Admin", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+
+	r := &repository{db: db}
+	if err := r.MoveCategoryProducts(ctx, 2, 1, actor); err != nil {
+		t.Errorf("MoveCategoryProducts() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("MoveCategoryProducts() unmet expectation: %v", err)
+	}
+}
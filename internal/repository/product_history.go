@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/alam/govtech/internal/adapter"
+	"github.com/alam/govtech/internal/model"
+	"strings"
+)
+
+func NewProductHistoryRepository(db *sql.DB) adapter.ProductHistoryRepository {
+	return &repository{db: db}
+}
+
+// lockProductSnapshot reads the current state of a product and locks its row
+// until the end of tx. sql.ErrNoRows is returned when the product does not
+// exist or is not in the requested deleted state.
+func (r *repository) lockProductSnapshot(ctx context.Context, tx *sql.Tx, id int64, deleted bool) (model.ProductSnapshot, error) {
+	query := `
+		SELECT 
+		    sku,
+		    title,
+		    description,
+		    category_id,
+		    image_url,
+		    weight,
+		    price,
+		    rating,
+		    version
+		FROM products 
+		WHERE id = ? AND deleted_at IS NULL
+		FOR UPDATE
+`
+	if deleted {
+		query = strings.Replace(query, "deleted_at IS NULL", "deleted_at IS NOT NULL", 1)
+	}
+
+	var res model.ProductSnapshot
+	err := tx.QueryRowContext(ctx, query, id).Scan(
+		&res.SKU,
+		&res.Title,
+		&res.Description,
+		&res.CategoryID,
+		&res.ImageURL,
+		&res.Weight,
+		&res.Price,
+		&res.Rating,
+		&res.Version,
+	)
+	if err != nil {
+		return model.ProductSnapshot{}, err
+	}
+
+	return res, nil
+}
+
+// recordHistory reads the state of a product after a change in tx and stores
+// the change made by actor.
+func (r *repository) recordHistory(ctx context.Context, tx *sql.Tx, id int64, action string, actor model.User, before *model.ProductSnapshot) error {
+	after, err := r.lockProductSnapshot(ctx, tx, id, false)
+	if err != nil {
+		return err
+	}
+
+	return r.insertHistory(ctx, tx, id, action, actor, before, &after)
+}
+
+// insertHistory stores a change of a product made by actor, identified by its
+// subject.
+func (r *repository) insertHistory(ctx context.Context, tx *sql.Tx, id int64, action string, actor model.User, before, after *model.ProductSnapshot) error {
+	beforeJSON, err := marshalSnapshot(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := marshalSnapshot(after)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO product_history(product_id, action, actor_id, actor_name, before_data, after_data)
+		VALUES(?, ?, ?, ?, ?, ?)
+`
+	_, err = tx.ExecContext(ctx, query, id, action, actor.Subject, actor.Name, beforeJSON, afterJSON)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *repository) GetProductHistory(ctx context.Context, productID int64, limit, offset int64) ([]model.ProductHistory, error) {
+	query := `
+		SELECT 
+		    id,
+		    product_id,
+		    action,
+		    actor_id,
+		    actor_name,
+		    before_data,
+		    after_data,
+		    created_at
+		FROM product_history 
+		WHERE product_id = ?
+		ORDER BY id DESC
+		LIMIT ? OFFSET ?
+`
+	var res []model.ProductHistory
+	rows, err := r.conn(ctx).QueryContext(ctx, query, productID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var data model.ProductHistory
+		var before, after []byte
+		err := rows.Scan(
+			&data.ID,
+			&data.ProductID,
+			&data.Action,
+			&data.ActorID,
+			&data.ActorName,
+			&before,
+			&after,
+			&data.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if data.Before, err = unmarshalSnapshot(before); err != nil {
+			return nil, err
+		}
+		if data.After, err = unmarshalSnapshot(after); err != nil {
+			return nil, err
+		}
+
+		res = append(res, data)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *repository) CountProductHistory(ctx context.Context, productID int64) (int64, error) {
+	query := `
+		SELECT 
+		    COUNT(id)
+		FROM product_history 
+		WHERE product_id = ?
+`
+	var res int64
+	err := r.conn(ctx).QueryRowContext(ctx, query, productID).Scan(&res)
+	if err != nil {
+		return 0, err
+	}
+
+	return res, nil
+}
+
+// marshalSnapshot returns the JSON column value of a snapshot, NULL for nil.
+func marshalSnapshot(snapshot *model.ProductSnapshot) (interface{}, error) {
+	if snapshot == nil {
+		return nil, nil
+	}
+	return json.Marshal(snapshot)
+}
+
+func unmarshalSnapshot(data []byte) (*model.ProductSnapshot, error) {
+	if data == nil {
+		return nil, nil
+	}
+	var res model.ProductSnapshot
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
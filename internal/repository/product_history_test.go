@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alam/govtech/internal/model"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func Test_repository_GetProductHistory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	createdAt := time.Date(2023, 12, 8, 10, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE product_id = ?\n\t\tORDER BY id DESC\n\t\tLIMIT ? OFFSET ?")).
+		WithArgs(int64(3), int64(10), int64(0)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "product_id", "action", "actor_id", "actor_name", "before_data", "after_data", "created_at"}).
+			AddRow(2, 3, "delete", "u1", "Budi", []byte(`{"sku":"IND003","price":10000,"version":2}`), nil, createdAt).
+			AddRow(1, 3, "create", "system", "", nil, []byte(`{"sku":"IND003","price":10000,"version":1}`), createdAt))
+
+	r := &repository{db: db}
+	got, err := r.GetProductHistory(context.Background(), 3, 10, 0)
+	if err != nil {
+		t.Fatalf("GetProductHistory() error = %v", err)
+	}
+	want := []model.ProductHistory{
+		{
+			ID:        2,
+			ProductID: 3,
+			Action:    "delete",
+			ActorID:   "u1",
+			ActorName: "Budi",
+			Before:    &model.ProductSnapshot{SKU: "IND003", Price: 10000, Version: 2},
+			CreatedAt: createdAt,
+		},
+		{
+			ID:        1,
+			ProductID: 3,
+			Action:    "create",
+			ActorID:   "system",
+			After:     &model.ProductSnapshot{SKU: "IND003", Price: 10000, Version: 1},
+			CreatedAt: createdAt,
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetProductHistory() got = %+v, want %+v", got, want)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("GetProductHistory() unmet expectation: %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/alam/govtech/internal/adapter"
+	"github.com/alam/govtech/internal/model"
+	"time"
+)
+
+func NewProductPriceRepository(db *sql.DB) adapter.ProductPriceRepository {
+	return &repository{db: db}
+}
+
+// insertPrice opens the price of a new product, valid from its creation. Both
+// timestamps come from the database clock, like every other timestamp of the
+// product, so the price is valid at the creation time the product reports.
+func (r *repository) insertPrice(ctx context.Context, tx *sql.Tx, productID int64) error {
+	query := `
+		INSERT INTO product_prices(product_id, price, effective_from)
+		SELECT id, price, created_at FROM products WHERE id = ?
+`
+	_, err := tx.ExecContext(ctx, query, productID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// changePrice closes the current price of a product and opens its updated price
+// at the update time of the product, so there is exactly one price valid at any
+// time.
+func (r *repository) changePrice(ctx context.Context, tx *sql.Tx, productID int64) error {
+	query := `
+		UPDATE 
+		    product_prices pp
+		JOIN products p ON p.id = pp.product_id
+		SET 
+		    pp.effective_to = p.updated_at
+		WHERE pp.product_id = ? AND pp.effective_to IS NULL
+`
+	_, err := tx.ExecContext(ctx, query, productID)
+	if err != nil {
+		return err
+	}
+
+	query = `
+		INSERT INTO product_prices(product_id, price, effective_from)
+		SELECT id, price, updated_at FROM products WHERE id = ?
+`
+	_, err = tx.ExecContext(ctx, query, productID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *repository) GetProductPrices(ctx context.Context, productID int64, limit, offset int64) ([]model.ProductPrice, error) {
+	query := `
+		SELECT 
+		    id,
+		    product_id,
+		    price,
+		    effective_from,
+		    effective_to
+		FROM product_prices 
+		WHERE product_id = ?
+		ORDER BY effective_from DESC, id DESC
+		LIMIT ? OFFSET ?
+`
+	var res []model.ProductPrice
+	rows, err := r.conn(ctx).QueryContext(ctx, query, productID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var data model.ProductPrice
+		var effectiveTo sql.NullTime
+		err := rows.Scan(
+			&data.ID,
+			&data.ProductID,
+			&data.Price,
+			&data.EffectiveFrom,
+			&effectiveTo,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if effectiveTo.Valid {
+			data.EffectiveTo = &effectiveTo.Time
+		}
+
+		res = append(res, data)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *repository) CountProductPrices(ctx context.Context, productID int64) (int64, error) {
+	query := `
+		SELECT 
+		    COUNT(id)
+		FROM product_prices 
+		WHERE product_id = ?
+`
+	var res int64
+	err := r.conn(ctx).QueryRowContext(ctx, query, productID).Scan(&res)
+	if err != nil {
+		return 0, err
+	}
+
+	return res, nil
+}
+
+// GetProductPriceAt returns the price of a product valid at the given time.
+// sql.ErrNoRows is returned when the product had no price yet.
+func (r *repository) GetProductPriceAt(ctx context.Context, productID int64, at time.Time) (model.ProductPrice, error) {
+	query := `
+		SELECT 
+		    id,
+		    product_id,
+		    price,
+		    effective_from,
+		    effective_to
+		FROM product_prices 
+		WHERE product_id = ? AND effective_from <= ? AND (effective_to IS NULL OR effective_to > ?)
+		ORDER BY effective_from DESC
+		LIMIT 1
+`
+	var res model.ProductPrice
+	var effectiveTo sql.NullTime
+	err := r.conn(ctx).QueryRowContext(ctx, query, productID, at, at).Scan(
+		&res.ID,
+		&res.ProductID,
+		&res.Price,
+		&res.EffectiveFrom,
+		&effectiveTo,
+	)
+	if err != nil {
+		return model.ProductPrice{}, err
+	}
+	if effectiveTo.Valid {
+		res.EffectiveTo = &effectiveTo.Time
+	}
+
+	return res, nil
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alam/govtech/internal/model"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+var priceColumns = []string{"id", "product_id", "price", "effective_from", "effective_to"}
+
+func Test_repository_GetProductPrices(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	createdAt := time.Date(2023, 12, 1, 10, 0, 0, 0, time.UTC)
+	changedAt := time.Date(2023, 12, 9, 10, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE product_id = ?\n\t\tORDER BY effective_from DESC, id DESC\n\t\tLIMIT ? OFFSET ?")).
+		WithArgs(int64(3), int64(10), int64(0)).
+		WillReturnRows(sqlmock.NewRows(priceColumns).
+			AddRow(2, 3, 12000, changedAt, nil).
+			AddRow(1, 3, 10000, createdAt, changedAt))
+
+	r := &repository{db: db}
+	got, err := r.GetProductPrices(context.Background(), 3, 10, 0)
+	if err != nil {
+		t.Fatalf("GetProductPrices() error = %v", err)
+	}
+	want := []model.ProductPrice{
+		{ID: 2, ProductID: 3, Price: 12000, EffectiveFrom: changedAt},
+		{ID: 1, ProductID: 3, Price: 10000, EffectiveFrom: createdAt, EffectiveTo: &changedAt},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetProductPrices() got = %+v, want %+v", got, want)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("GetProductPrices() unmet expectation: %v", err)
+	}
+}
+
+func Test_repository_GetProductPriceAt(t *testing.T) {
+	createdAt := time.Date(2023, 12, 1, 10, 0, 0, 0, time.UTC)
+	changedAt := time.Date(2023, 12, 9, 10, 0, 0, 0, time.UTC)
+	at := time.Date(2023, 12, 5, 0, 0, 0, 0, time.UTC)
+	query := regexp.QuoteMeta("WHERE product_id = ? AND effective_from <= ? AND (effective_to IS NULL OR effective_to > ?)")
+
+	tests := []struct {
+		name    string
+		prepare func(mock sqlmock.Sqlmock)
+		want    model.ProductPrice
+		wantErr error
+	}{
+		{
+			name: "closed price",
+			prepare: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(query).
+					WithArgs(int64(3), at, at).
+					WillReturnRows(sqlmock.NewRows(priceColumns).
+						AddRow(1, 3, 10000, createdAt, changedAt))
+			},
+			want:    model.ProductPrice{ID: 1, ProductID: 3, Price: 10000, EffectiveFrom: createdAt, EffectiveTo: &changedAt},
+			wantErr: nil,
+		},
+		{
+			name: "before the product existed",
+			prepare: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(query).
+					WithArgs(int64(3), at, at).
+					WillReturnRows(sqlmock.NewRows(priceColumns))
+			},
+			want:    model.ProductPrice{},
+			wantErr: sql.ErrNoRows,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to open sqlmock: %v", err)
+			}
+			defer db.Close()
+			tt.prepare(mock)
+
+			r := &repository{db: db}
+			got, err := r.GetProductPriceAt(context.Background(), 3, at)
+			if err != tt.wantErr {
+				t.Errorf("GetProductPriceAt() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetProductPriceAt() got = %+v, want %+v", got, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("GetProductPriceAt() unmet expectation: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,305 @@
+package repository
+
+import (
+	"fmt"
+	"github.com/alam/govtech/internal/model"
+	"strings"
+	"unicode"
+)
+
+// likeEscape is the escape character declared in every LIKE clause built here.
+// A character with no special meaning in MySQL string literals is used so the
+// clause behaves the same regardless of NO_BACKSLASH_ESCAPES.
+const likeEscape = "!"
+
+var likeEscaper = strings.NewReplacer(
+	likeEscape, likeEscape+likeEscape,
+	"%", likeEscape+"%",
+	"_", likeEscape+"_",
+)
+
+const (
+	productFulltextMatch  = "MATCH(p.title, p.description, p.sku) AGAINST(? IN BOOLEAN MODE)"
+	categoryFulltextMatch = "MATCH(c.name) AGAINST(? IN BOOLEAN MODE)"
+)
+
+// productLikeColumns are the columns searched when the FULLTEXT indexes are not
+// available.
+var productLikeColumns = []string{"p.title", "p.description", "p.sku", "c.name"}
+
+// productSortColumns whitelists the columns GetProductList can order by. Only
+// values from this map are ever written into the ORDER BY clause.
+var productSortColumns = map[string]string{
+	"created_at": "p.created_at",
+	"rating":     "p.rating",
+	"score":      "p.rating_score",
+	"relevance":  "relevance",
+}
+
+// productPriceBuckets are the lower bounds of the price facet buckets, the
+// last bucket has no upper bound.
+var productPriceBuckets = []int64{0, 10000, 50000, 100000, 500000, 1000000}
+
+// productRatingBuckets are the lower bounds of the rating facet buckets, the
+// last bucket ends at the maximum rating.
+var productRatingBuckets = []float64{0, 1, 2, 3, 4}
+
+const maxRating = 5
+
+var productSortTypes = map[string]string{
+	"asc":  "ASC",
+	"desc": "DESC",
+}
+
+type searchMode int
+
+const (
+	searchModeFulltext searchMode = iota
+	searchModeLike
+)
+
+// productListQuery builds the dynamic parts of a product list query. User input
+// only ever ends up in the returned arguments, never in the SQL text.
+type productListQuery struct {
+	filter model.GetProductListFilter
+	mode   searchMode
+}
+
+func newProductListQuery(filter model.GetProductListFilter) productListQuery {
+	mode := searchModeFulltext
+	if filter.Search != "" && fulltextTerms(filter.Search) == "" {
+		mode = searchModeLike
+	}
+	return productListQuery{filter: filter, mode: mode}
+}
+
+// fallback returns the same query using LIKE matching instead of FULLTEXT.
+func (q productListQuery) fallback() productListQuery {
+	q.mode = searchModeLike
+	return q
+}
+
+// Facet counts of one dimension ignore the filter on that same dimension, so
+// clients can show how many products every other option would return.
+
+func (q productListQuery) withoutCategories() productListQuery {
+	q.filter.CategoryIDs = nil
+	return q
+}
+
+func (q productListQuery) withoutPriceRange() productListQuery {
+	q.filter.MinPrice = 0
+	q.filter.MaxPrice = 0
+	return q
+}
+
+func (q productListQuery) withoutMinRating() productListQuery {
+	q.filter.MinRating = 0
+	return q
+}
+
+// relevance returns the select expression of the relevance score.
+func (q productListQuery) relevance() (string, []interface{}) {
+	if q.filter.Search == "" {
+		return "0", nil
+	}
+
+	if q.mode == searchModeFulltext {
+		terms := fulltextTerms(q.filter.Search)
+		return productFulltextMatch + " + " + categoryFulltextMatch, []interface{}{terms, terms}
+	}
+
+	// Without a FULLTEXT index the score is the number of matching columns.
+	pattern := containsPattern(q.filter.Search)
+	var exprs []string
+	var args []interface{}
+	for _, column := range productLikeColumns {
+		exprs = append(exprs, fmt.Sprintf("(%s LIKE ? ESCAPE '%s')", column, likeEscape))
+		args = append(args, pattern)
+	}
+	return strings.Join(exprs, " + "), args
+}
+
+// where returns the WHERE clause together with its bound arguments. Deleted
+// products are always excluded.
+func (q productListQuery) where() (string, []interface{}) {
+	clauses := []string{"p.deleted_at IS NULL"}
+	var args []interface{}
+
+	if q.filter.Search != "" {
+		if q.mode == searchModeFulltext {
+			terms := fulltextTerms(q.filter.Search)
+			clauses = append(clauses, fmt.Sprintf("(%s OR %s)", productFulltextMatch, categoryFulltextMatch))
+			args = append(args, terms, terms)
+		} else {
+			pattern := containsPattern(q.filter.Search)
+			var likes []string
+			for _, column := range productLikeColumns {
+				likes = append(likes, fmt.Sprintf("%s LIKE ? ESCAPE '%s'", column, likeEscape))
+				args = append(args, pattern)
+			}
+			clauses = append(clauses, "("+strings.Join(likes, " OR ")+")")
+		}
+	}
+	if len(q.filter.IDs) > 0 {
+		clauses = append(clauses, fmt.Sprintf("p.id IN (%s)", placeholders(len(q.filter.IDs))))
+		for _, id := range q.filter.IDs {
+			args = append(args, id)
+		}
+	}
+	if len(q.filter.CategoryIDs) > 0 {
+		clauses = append(clauses, fmt.Sprintf("p.category_id IN (%s)", placeholders(len(q.filter.CategoryIDs))))
+		for _, id := range q.filter.CategoryIDs {
+			args = append(args, id)
+		}
+	}
+	if q.filter.MinPrice > 0 {
+		clauses = append(clauses, "p.price >= ?")
+		args = append(args, q.filter.MinPrice)
+	}
+	if q.filter.MaxPrice > 0 {
+		clauses = append(clauses, "p.price <= ?")
+		args = append(args, q.filter.MaxPrice)
+	}
+	if q.filter.MinWeight > 0 {
+		clauses = append(clauses, "p.weight >= ?")
+		args = append(args, q.filter.MinWeight)
+	}
+	if q.filter.MaxWeight > 0 {
+		clauses = append(clauses, "p.weight <= ?")
+		args = append(args, q.filter.MaxWeight)
+	}
+	if q.filter.MinRating > 0 {
+		clauses = append(clauses, "p.rating >= ?")
+		args = append(args, q.filter.MinRating)
+	}
+
+	return " WHERE " + strings.Join(clauses, " AND "), args
+}
+
+// sortKey returns the expression products are ordered by together with its
+// bound arguments and direction. Sort options outside the whitelist are
+// rejected instead of being interpolated.
+func (q productListQuery) sortKey() (string, []interface{}, string, error) {
+	if q.filter.SortColumn == "" {
+		return "p.id", nil, "ASC", nil
+	}
+
+	column, ok := productSortColumns[q.filter.SortColumn]
+	if !ok {
+		return "", nil, "", fmt.Errorf("invalid sort column: %q", q.filter.SortColumn)
+	}
+
+	sortType := "ASC"
+	if q.filter.SortColumn == "relevance" {
+		sortType = "DESC"
+	}
+	if q.filter.SortType != "" {
+		sortType, ok = productSortTypes[q.filter.SortType]
+		if !ok {
+			return "", nil, "", fmt.Errorf("invalid sort type: %q", q.filter.SortType)
+		}
+	}
+
+	if q.filter.SortColumn == "relevance" {
+		// Products found by an external searcher come already ranked, so
+		// the relevance order is the position in the id list.
+		if q.filter.Search == "" && len(q.filter.IDs) > 0 {
+			reverse := map[string]string{"ASC": "DESC", "DESC": "ASC"}
+			args := make([]interface{}, len(q.filter.IDs))
+			for i, id := range q.filter.IDs {
+				args[i] = id
+			}
+			return fmt.Sprintf("FIELD(p.id, %s)", placeholders(len(q.filter.IDs))), args, reverse[sortType], nil
+		}
+
+		expr, args := q.relevance()
+		return "(" + expr + ")", args, sortType, nil
+	}
+
+	return column, nil, sortType, nil
+}
+
+// order returns the ORDER BY clause together with its bound arguments. The
+// product id is always the last sort key so pages are stable.
+func (q productListQuery) order() (string, []interface{}, error) {
+	expr, args, sortType, err := q.sortKey()
+	if err != nil {
+		return "", nil, err
+	}
+
+	if q.filter.SortColumn == "" {
+		return " ORDER BY p.id ASC", nil, nil
+	}
+	// The selected relevance is reused instead of matching every row again.
+	if q.filter.SortColumn == "relevance" && q.filter.Search != "" {
+		expr, args = "relevance", nil
+	}
+
+	return fmt.Sprintf(" ORDER BY %s %s, p.id %s", expr, sortType, sortType), args, nil
+}
+
+// keyset returns the condition selecting the products after the cursor of the
+// filter, or an empty string when the filter has no cursor.
+func (q productListQuery) keyset() (string, []interface{}, error) {
+	if q.filter.After == nil {
+		return "", nil, nil
+	}
+
+	expr, args, sortType, err := q.sortKey()
+	if err != nil {
+		return "", nil, err
+	}
+
+	op := ">"
+	if sortType == "DESC" {
+		op = "<"
+	}
+
+	if q.filter.SortColumn == "" {
+		return "p.id " + op + " ?", []interface{}{q.filter.After.ID}, nil
+	}
+
+	return fmt.Sprintf("(%s, p.id) %s (?, ?)", expr, op), append(args, q.filter.After.Value, q.filter.After.ID), nil
+}
+
+// bucket returns an expression evaluating to the index of the bucket column
+// falls in, given the ascending lower bounds of every bucket.
+func bucket[T int64 | float64](column string, bounds []T) (string, []interface{}) {
+	var args []interface{}
+	expr := "CASE"
+	for i, bound := range bounds[1:] {
+		expr += fmt.Sprintf(" WHEN %s < ? THEN %d", column, i)
+		args = append(args, bound)
+	}
+	expr += fmt.Sprintf(" ELSE %d END", len(bounds)-1)
+	return expr, args
+}
+
+// escapeLike escapes LIKE wildcards so the keyword is matched literally.
+func escapeLike(keyword string) string {
+	return likeEscaper.Replace(keyword)
+}
+
+// containsPattern returns a LIKE pattern matching any value containing keyword.
+func containsPattern(keyword string) string {
+	return "%" + escapeLike(keyword) + "%"
+}
+
+// fulltextTerms converts a search keyword into a boolean mode FULLTEXT query
+// where every word is prefix matched. Boolean operators typed by the user are
+// dropped together with any other punctuation.
+func fulltextTerms(keyword string) string {
+	words := strings.FieldsFunc(keyword, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = word + "*"
+	}
+	return strings.Join(words, " ")
+}
+
+// placeholders returns n comma separated bind parameters.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
@@ -0,0 +1,388 @@
+package repository
+
+import (
+	"github.com/alam/govtech/internal/model"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_escapeLike(t *testing.T) {
+	tests := []struct {
+		name    string
+		keyword string
+		want    string
+	}{
+		{
+			name:    "plain keyword",
+			keyword: "keju",
+			want:    "keju",
+		},
+		{
+			name:    "percent",
+			keyword: "100%",
+			want:    "100!%",
+		},
+		{
+			name:    "underscore",
+			keyword: "IND_001",
+			want:    "IND!_001",
+		},
+		{
+			name:    "escape character",
+			keyword: "wow!",
+			want:    "wow!!",
+		},
+		{
+			name:    "quote and backslash are kept as is",
+			keyword: `it's \ fine`,
+			want:    `it's \ fine`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := escapeLike(tt.keyword); got != tt.want {
+				t.Errorf("escapeLike() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_fulltextTerms(t *testing.T) {
+	tests := []struct {
+		name    string
+		keyword string
+		want    string
+	}{
+		{
+			name:    "single word",
+			keyword: "keju",
+			want:    "keju*",
+		},
+		{
+			name:    "multiple words",
+			keyword: "  pototo   keju ",
+			want:    "pototo* keju*",
+		},
+		{
+			name:    "boolean operators are dropped",
+			keyword: `+keju -"pedas" (asin)~ @2`,
+			want:    "keju* pedas* asin* 2*",
+		},
+		{
+			name:    "punctuation only",
+			keyword: "%'_;--",
+			want:    "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fulltextTerms(tt.keyword); got != tt.want {
+				t.Errorf("fulltextTerms() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_productListQuery_where(t *testing.T) {
+	tests := []struct {
+		name      string
+		filter    model.GetProductListFilter
+		fallback  bool
+		wantQuery string
+		wantArgs  []interface{}
+	}{
+		{
+			name:      "no filter",
+			filter:    model.GetProductListFilter{},
+			wantQuery: " WHERE p.deleted_at IS NULL",
+			wantArgs:  nil,
+		},
+		{
+			name: "fulltext search and category",
+			filter: model.GetProductListFilter{
+				Search:      "keju",
+				CategoryIDs: []int64{2},
+			},
+			wantQuery: " WHERE p.deleted_at IS NULL AND (MATCH(p.title, p.description, p.sku) AGAINST(? IN BOOLEAN MODE) OR MATCH(c.name) AGAINST(? IN BOOLEAN MODE)) AND p.category_id IN (?)",
+			wantArgs:  []interface{}{"keju*", "keju*", int64(2)},
+		},
+		{
+			name: "fulltext tautology injection",
+			filter: model.GetProductListFilter{
+				Search: "' OR '1'='1",
+			},
+			wantQuery: " WHERE p.deleted_at IS NULL AND (MATCH(p.title, p.description, p.sku) AGAINST(? IN BOOLEAN MODE) OR MATCH(c.name) AGAINST(? IN BOOLEAN MODE))",
+			wantArgs:  []interface{}{"OR* 1* 1*", "OR* 1* 1*"},
+		},
+		{
+			name: "searcher ids and category",
+			filter: model.GetProductListFilter{
+				IDs:         []int64{7, 3},
+				CategoryIDs: []int64{2},
+			},
+			wantQuery: " WHERE p.deleted_at IS NULL AND p.id IN (?, ?) AND p.category_id IN (?)",
+			wantArgs:  []interface{}{int64(7), int64(3), int64(2)},
+		},
+		{
+			name: "facet filters",
+			filter: model.GetProductListFilter{
+				CategoryIDs: []int64{1, 3},
+				MinPrice:    1000,
+				MaxPrice:    50000,
+				MinWeight:   2,
+				MaxWeight:   10,
+				MinRating:   3.5,
+			},
+			wantQuery: " WHERE p.deleted_at IS NULL AND p.category_id IN (?, ?) AND p.price >= ? AND p.price <= ? AND p.weight >= ? AND p.weight <= ? AND p.rating >= ?",
+			wantArgs:  []interface{}{int64(1), int64(3), int64(1000), int64(50000), int32(2), int32(10), 3.5},
+		},
+		{
+			name: "punctuation only search uses like",
+			filter: model.GetProductListFilter{
+				Search: "_%",
+			},
+			wantQuery: " WHERE p.deleted_at IS NULL AND (p.title LIKE ? ESCAPE '!' OR p.description LIKE ? ESCAPE '!' OR p.sku LIKE ? ESCAPE '!' OR c.name LIKE ? ESCAPE '!')",
+			wantArgs:  []interface{}{"%!_!%%", "%!_!%%", "%!_!%%", "%!_!%%"},
+		},
+		{
+			name: "like stacked query injection",
+			filter: model.GetProductListFilter{
+				Search: "%'); DROP TABLE products; --",
+			},
+			fallback:  true,
+			wantQuery: " WHERE p.deleted_at IS NULL AND (p.title LIKE ? ESCAPE '!' OR p.description LIKE ? ESCAPE '!' OR p.sku LIKE ? ESCAPE '!' OR c.name LIKE ? ESCAPE '!')",
+			wantArgs: []interface{}{
+				"%!%'); DROP TABLE products; --%",
+				"%!%'); DROP TABLE products; --%",
+				"%!%'); DROP TABLE products; --%",
+				"%!%'); DROP TABLE products; --%",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newProductListQuery(tt.filter)
+			if tt.fallback {
+				q = q.fallback()
+			}
+			gotQuery, gotArgs := q.where()
+			if gotQuery != tt.wantQuery {
+				t.Errorf("where() query = %v, want %v", gotQuery, tt.wantQuery)
+			}
+			if !reflect.DeepEqual(gotArgs, tt.wantArgs) {
+				t.Errorf("where() args = %v, want %v", gotArgs, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func Test_productListQuery_order(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   model.GetProductListFilter
+		want     string
+		wantArgs []interface{}
+		wantErr  bool
+	}{
+		{
+			name:   "no sort",
+			filter: model.GetProductListFilter{},
+			want:   " ORDER BY p.id ASC",
+		},
+		{
+			name: "created at asc",
+			filter: model.GetProductListFilter{
+				SortColumn: "created_at",
+				SortType:   "asc",
+			},
+			want: " ORDER BY p.created_at ASC, p.id ASC",
+		},
+		{
+			name: "rating without sort type",
+			filter: model.GetProductListFilter{
+				SortColumn: "rating",
+			},
+			want: " ORDER BY p.rating ASC, p.id ASC",
+		},
+		{
+			name: "bayesian score desc",
+			filter: model.GetProductListFilter{
+				SortColumn: "score",
+				SortType:   "desc",
+			},
+			want: " ORDER BY p.rating_score DESC, p.id DESC",
+		},
+		{
+			name: "relevance without sort type",
+			filter: model.GetProductListFilter{
+				Search:     "keju",
+				SortColumn: "relevance",
+			},
+			want: " ORDER BY relevance DESC, p.id DESC",
+		},
+		{
+			name: "relevance of searcher ranked ids",
+			filter: model.GetProductListFilter{
+				IDs:        []int64{7, 3, 5},
+				SortColumn: "relevance",
+			},
+			want:     " ORDER BY FIELD(p.id, ?, ?, ?) ASC, p.id ASC",
+			wantArgs: []interface{}{int64(7), int64(3), int64(5)},
+		},
+		{
+			name: "column outside whitelist",
+			filter: model.GetProductListFilter{
+				SortColumn: "price",
+				SortType:   "desc",
+			},
+			wantErr: true,
+		},
+		{
+			name: "injected column",
+			filter: model.GetProductListFilter{
+				SortColumn: "rating; DROP TABLE products",
+				SortType:   "desc",
+			},
+			wantErr: true,
+		},
+		{
+			name: "injected sort type",
+			filter: model.GetProductListFilter{
+				SortColumn: "rating",
+				SortType:   "desc, (SELECT SLEEP(10))",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotArgs, err := newProductListQuery(tt.filter).order()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("order() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("order() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(gotArgs, tt.wantArgs) {
+				t.Errorf("order() args = %v, want %v", gotArgs, tt.wantArgs)
+			}
+			if strings.Contains(got, ";") {
+				t.Errorf("order() = %v, contains statement separator", got)
+			}
+		})
+	}
+}
+
+func Test_productListQuery_keyset(t *testing.T) {
+	createdAt := time.Date(2023, 11, 20, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		filter   model.GetProductListFilter
+		want     string
+		wantArgs []interface{}
+		wantErr  bool
+	}{
+		{
+			name:   "no cursor",
+			filter: model.GetProductListFilter{},
+			want:   "",
+		},
+		{
+			name: "id order",
+			filter: model.GetProductListFilter{
+				After: &model.ProductCursor{ID: 12},
+			},
+			want:     "p.id > ?",
+			wantArgs: []interface{}{int64(12)},
+		},
+		{
+			name: "created at asc",
+			filter: model.GetProductListFilter{
+				SortColumn: "created_at",
+				SortType:   "asc",
+				After:      &model.ProductCursor{Value: createdAt, ID: 12},
+			},
+			want:     "(p.created_at, p.id) > (?, ?)",
+			wantArgs: []interface{}{createdAt, int64(12)},
+		},
+		{
+			name: "rating desc",
+			filter: model.GetProductListFilter{
+				SortColumn: "rating",
+				SortType:   "desc",
+				After:      &model.ProductCursor{Value: 4.5, ID: 12},
+			},
+			want:     "(p.rating, p.id) < (?, ?)",
+			wantArgs: []interface{}{4.5, int64(12)},
+		},
+		{
+			name: "bayesian score desc",
+			filter: model.GetProductListFilter{
+				SortColumn: "score",
+				SortType:   "desc",
+				After:      &model.ProductCursor{Value: 4.2, ID: 12},
+			},
+			want:     "(p.rating_score, p.id) < (?, ?)",
+			wantArgs: []interface{}{4.2, int64(12)},
+		},
+		{
+			name: "fulltext relevance",
+			filter: model.GetProductListFilter{
+				Search:     "keju",
+				SortColumn: "relevance",
+				After:      &model.ProductCursor{Value: 1.5, ID: 12},
+			},
+			want:     "((MATCH(p.title, p.description, p.sku) AGAINST(? IN BOOLEAN MODE) + MATCH(c.name) AGAINST(? IN BOOLEAN MODE)), p.id) < (?, ?)",
+			wantArgs: []interface{}{"keju*", "keju*", 1.5, int64(12)},
+		},
+		{
+			name: "searcher ranked ids",
+			filter: model.GetProductListFilter{
+				IDs:        []int64{7, 3, 5},
+				SortColumn: "relevance",
+				After:      &model.ProductCursor{Value: 2, ID: 3},
+			},
+			want:     "(FIELD(p.id, ?, ?, ?), p.id) > (?, ?)",
+			wantArgs: []interface{}{int64(7), int64(3), int64(5), 2, int64(3)},
+		},
+		{
+			name: "column outside whitelist",
+			filter: model.GetProductListFilter{
+				SortColumn: "price",
+				After:      &model.ProductCursor{Value: 1, ID: 3},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotArgs, err := newProductListQuery(tt.filter).keyset()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("keyset() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("keyset() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(gotArgs, tt.wantArgs) {
+				t.Errorf("keyset() args = %v, want %v", gotArgs, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func Test_bucket(t *testing.T) {
+	gotExpr, gotArgs := bucket("p.price", []int64{0, 100, 500})
+	wantExpr := "CASE WHEN p.price < ? THEN 0 WHEN p.price < ? THEN 1 ELSE 2 END"
+	wantArgs := []interface{}{int64(100), int64(500)}
+	if gotExpr != wantExpr {
+		t.Errorf("bucket() expr = %v, want %v", gotExpr, wantExpr)
+	}
+	if !reflect.DeepEqual(gotArgs, wantArgs) {
+		t.Errorf("bucket() args = %v, want %v", gotArgs, wantArgs)
+	}
+}
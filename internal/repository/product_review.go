@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/alam/govtech/internal/model"
+	"strings"
+	"time"
+)
+
+// reviewSortOrders whitelists the orders GetReviewList can sort by. Reviews
+// with the same sort value are listed newest first. The oldest order is the
+// moderation queue.
+var reviewSortOrders = map[string]string{
+	"":        "pr.created_at DESC, pr.id DESC",
+	"newest":  "pr.created_at DESC, pr.id DESC",
+	"highest": "pr.rating DESC, pr.created_at DESC, pr.id DESC",
+	"lowest":  "pr.rating ASC, pr.created_at DESC, pr.id DESC",
+	"helpful": "pr.helpful_score DESC, pr.created_at DESC, pr.id DESC",
+	"oldest":  "pr.created_at ASC, pr.id ASC",
+}
+
+// reviewListWhere returns the WHERE clause of a review list together with its
+// bound arguments.
+func reviewListWhere(filter model.GetReviewListFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	if filter.ProductID > 0 {
+		conditions = append(conditions, "pr.product_id = ?")
+		args = append(args, filter.ProductID)
+	}
+	if filter.Rating > 0 {
+		conditions = append(conditions, "pr.rating = ?")
+		args = append(args, filter.Rating)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "pr.status = ?")
+		args = append(args, filter.Status)
+	}
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+func (r *repository) GetReviewList(ctx context.Context, filter model.GetReviewListFilter) ([]model.ProductReview, error) {
+	order, ok := reviewSortOrders[filter.Sort]
+	if !ok {
+		return nil, fmt.Errorf("invalid sort: %q", filter.Sort)
+	}
+
+	query := `
+		SELECT 
+		    pr.id,
+		    pr.user_id,
+		    pr.product_id,
+		    pr.rating,
+		    pr.comment,
+		    pr.status,
+		    pr.flag_reason,
+		    pr.helpful_count,
+		    pr.unhelpful_count,
+		    pr.created_at,
+		    pr.updated_at,
+		    u.subject,
+		    u.name,
+		    rr.review_id,
+		    COALESCE(rr.vendor_id, 0),
+		    COALESCE(rr.comment, ''),
+		    rr.created_at,
+		    rr.updated_at,
+		    COALESCE(vu.subject, ''),
+		    COALESCE(vu.name, '')
+		FROM product_reviews pr
+		JOIN users u ON u.id = pr.user_id
+		LEFT JOIN review_replies rr ON rr.review_id = pr.id
+		LEFT JOIN users vu ON vu.id = rr.vendor_id
+`
+	where, args := reviewListWhere(filter)
+	query += where + " ORDER BY " + order + " LIMIT ? OFFSET ?"
+	args = append(args, filter.Limit, filter.Offset)
+
+	var res []model.ProductReview
+	rows, err := r.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var data model.ProductReview
+		var reply model.ReviewReply
+		var replyID sql.NullInt64
+		var replyCreatedAt, replyUpdatedAt sql.NullTime
+		err := rows.Scan(
+			&data.ID,
+			&data.UserID,
+			&data.ProductID,
+			&data.Rating,
+			&data.Comment,
+			&data.Status,
+			&data.FlagReason,
+			&data.HelpfulCount,
+			&data.UnhelpfulCount,
+			&data.CreatedAt,
+			&data.UpdatedAt,
+			&data.User.Subject,
+			&data.User.Name,
+			&replyID,
+			&reply.VendorID,
+			&reply.Comment,
+			&replyCreatedAt,
+			&replyUpdatedAt,
+			&reply.Vendor.Subject,
+			&reply.Vendor.Name,
+		)
+		if err != nil {
+			return nil, err
+		}
+		data.User.ID = data.UserID
+		if replyID.Valid {
+			reply.ReviewID = replyID.Int64
+			reply.Vendor.ID = reply.VendorID
+			reply.CreatedAt = replyCreatedAt.Time
+			reply.UpdatedAt = replyUpdatedAt.Time
+			data.Reply = &reply
+		}
+
+		res = append(res, data)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *repository) CountReviewList(ctx context.Context, filter model.GetReviewListFilter) (int64, error) {
+	query := `
+		SELECT 
+		    COUNT(pr.id)
+		FROM product_reviews pr
+`
+	where, args := reviewListWhere(filter)
+	query += where
+
+	var res int64
+	err := r.conn(ctx).QueryRowContext(ctx, query, args...).Scan(&res)
+	if err != nil {
+		return 0, err
+	}
+
+	return res, nil
+}
+
+// CountReviewsWithComment counts the reviews of users other than userID with the
+// given comment. Rejected reviews are not counted.
+func (r *repository) CountReviewsWithComment(ctx context.Context, comment string, userID int64) (int64, error) {
+	query := `
+		SELECT 
+		    COUNT(id)
+		FROM product_reviews 
+		WHERE comment = ? AND user_id <> ? AND status <> ?
+`
+	var res int64
+	err := r.conn(ctx).QueryRowContext(ctx, query, comment, userID, model.ReviewStatusRejected).Scan(&res)
+	if err != nil {
+		return 0, err
+	}
+
+	return res, nil
+}
+
+// CountUserReviewsSince counts the reviews a user has written since the given
+// time.
+func (r *repository) CountUserReviewsSince(ctx context.Context, userID int64, since time.Time) (int64, error) {
+	query := `
+		SELECT 
+		    COUNT(id)
+		FROM product_reviews 
+		WHERE user_id = ? AND created_at >= ?
+`
+	var res int64
+	err := r.conn(ctx).QueryRowContext(ctx, query, userID, since).Scan(&res)
+	if err != nil {
+		return 0, err
+	}
+
+	return res, nil
+}
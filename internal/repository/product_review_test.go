@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alam/govtech/internal/model"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func Test_repository_GetReviewList(t *testing.T) {
+	createdAt := time.Date(2023, 12, 12, 10, 0, 0, 0, time.UTC)
+	repliedAt := time.Date(2023, 12, 13, 10, 0, 0, 0, time.UTC)
+	columns := []string{"id", "user_id", "product_id", "rating", "comment", "status", "flag_reason", "helpful_count", "unhelpful_count", "created_at", "updated_at", "subject", "name",
+		"reply_review_id", "reply_vendor_id", "reply_comment", "reply_created_at", "reply_updated_at", "vendor_subject", "vendor_name"}
+
+	tests := []struct {
+		name    string
+		filter  model.GetReviewListFilter
+		prepare func(mock sqlmock.Sqlmock)
+		want    []model.ProductReview
+		wantErr bool
+	}{
+		{
+			name:   "newest by default",
+			filter: model.GetReviewListFilter{ProductID: 4, Limit: 10},
+			prepare: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(" WHERE pr.product_id = ? ORDER BY pr.created_at DESC, pr.id DESC LIMIT ? OFFSET ?")).
+					WithArgs(int64(4), int64(10), int64(0)).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(3, 7, 4, 5, "enak", "approved", "", 4, 1, createdAt, createdAt, "u1", "Budi", 3, 9, "terima kasih", repliedAt, repliedAt, "v1", "Toko Budi"))
+			},
+			want: []model.ProductReview{
+				{
+					ID:             3,
+					UserID:         7,
+					ProductID:      4,
+					Rating:         5,
+					Comment:        "enak",
+					Status:         "approved",
+					HelpfulCount:   4,
+					UnhelpfulCount: 1,
+					User:           model.User{ID: 7, Subject: "u1", Name: "Budi"},
+					Reply: &model.ReviewReply{
+						ReviewID:  3,
+						VendorID:  9,
+						Comment:   "terima kasih",
+						Vendor:    model.User{ID: 9, Subject: "v1", Name: "Toko Budi"},
+						CreatedAt: repliedAt,
+						UpdatedAt: repliedAt,
+					},
+					CreatedAt: createdAt,
+					UpdatedAt: createdAt,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:   "pending reviews of every product oldest first",
+			filter: model.GetReviewListFilter{Status: "pending", Sort: "oldest", Limit: 10},
+			prepare: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("LEFT JOIN users vu ON vu.id = rr.vendor_id\n WHERE pr.status = ? ORDER BY pr.created_at ASC, pr.id ASC LIMIT ? OFFSET ?")).
+					WithArgs("pending", int64(10), int64(0)).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(5, 8, 2, 1, "spam", "pending", "duplicate comment", 0, 0, createdAt, createdAt, "u2", "", nil, 0, "", nil, nil, "", ""))
+			},
+			want: []model.ProductReview{
+				{
+					ID:         5,
+					UserID:     8,
+					ProductID:  2,
+					Rating:     1,
+					Comment:    "spam",
+					Status:     "pending",
+					FlagReason: "duplicate comment",
+					User:       model.User{ID: 8, Subject: "u2"},
+					CreatedAt:  createdAt,
+					UpdatedAt:  createdAt,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:   "star filter highest first",
+			filter: model.GetReviewListFilter{ProductID: 4, Rating: 5, Sort: "highest", Limit: 10, Offset: 10},
+			prepare: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(" WHERE pr.product_id = ? AND pr.rating = ? ORDER BY pr.rating DESC, pr.created_at DESC, pr.id DESC LIMIT ? OFFSET ?")).
+					WithArgs(int64(4), int32(5), int64(10), int64(10)).
+					WillReturnRows(sqlmock.NewRows(columns))
+			},
+			want:    nil,
+			wantErr: false,
+		},
+		{
+			name:   "most helpful first",
+			filter: model.GetReviewListFilter{ProductID: 4, Status: "approved", Sort: "helpful", Limit: 10},
+			prepare: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(" WHERE pr.product_id = ? AND pr.status = ? ORDER BY pr.helpful_score DESC, pr.created_at DESC, pr.id DESC LIMIT ? OFFSET ?")).
+					WithArgs(int64(4), "approved", int64(10), int64(0)).
+					WillReturnRows(sqlmock.NewRows(columns))
+			},
+			want:    nil,
+			wantErr: false,
+		},
+		{
+			name:    "sort outside whitelist",
+			filter:  model.GetReviewListFilter{ProductID: 4, Sort: "pr.id; DROP TABLE product_reviews"},
+			prepare: func(mock sqlmock.Sqlmock) {},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to open sqlmock: %v", err)
+			}
+			defer db.Close()
+			tt.prepare(mock)
+
+			r := &repository{db: db}
+			got, err := r.GetReviewList(context.Background(), tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetReviewList() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetReviewList() got = %+v, want %+v", got, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("GetReviewList() unmet expectation: %v", err)
+			}
+		})
+	}
+}
+
+func Test_repository_GetReviewStatistic(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta("COALESCE(SUM(rating = 5), 0)\n\t\tFROM product_reviews \n\t\tWHERE product_id = ? AND status = ?")).
+		WithArgs(int64(4), "approved").
+		WillReturnRows(sqlmock.NewRows([]string{"avg", "count", "1", "2", "3", "4", "5"}).
+			AddRow(4.0, 3, 0, 0, 1, 1, 1))
+
+	r := &repository{db: db}
+	got, err := r.GetReviewStatistic(context.Background(), 4)
+	if err != nil {
+		t.Fatalf("GetReviewStatistic() error = %v", err)
+	}
+	want := model.Statistic{Count: 3, Average: 4, Distribution: [5]int64{0, 0, 1, 1, 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetReviewStatistic() got = %+v, want %+v", got, want)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("GetReviewStatistic() unmet expectation: %v", err)
+	}
+}
+
+func Test_repository_CountReviewsWithComment(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE comment = ? AND user_id <> ? AND status <> ?")).
+		WithArgs("buy cheap followers", int64(7), "rejected").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	r := &repository{db: db}
+	got, err := r.CountReviewsWithComment(context.Background(), "buy cheap followers", 7)
+	if err != nil {
+		t.Fatalf("CountReviewsWithComment() error = %v", err)
+	}
+	if got != 2 {
+		t.Errorf("CountReviewsWithComment() got = %v, want %v", got, 2)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("CountReviewsWithComment() unmet expectation: %v", err)
+	}
+}
+
+func Test_repository_CountUserReviewsSince(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	since := time.Date(2023, 12, 14, 9, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE user_id = ? AND created_at >= ?")).
+		WithArgs(int64(7), since).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(4))
+
+	r := &repository{db: db}
+	got, err := r.CountUserReviewsSince(context.Background(), 7, since)
+	if err != nil {
+		t.Fatalf("CountUserReviewsSince() error = %v", err)
+	}
+	if got != 4 {
+		t.Errorf("CountUserReviewsSince() got = %v, want %v", got, 4)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("CountUserReviewsSince() unmet expectation: %v", err)
+	}
+}
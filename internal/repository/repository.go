@@ -3,10 +3,25 @@ package repository
 import (
 	"context"
 	"database/sql"
-	"fmt"
+	"errors"
 	"github.com/alam/govtech/internal/adapter"
 	"github.com/alam/govtech/internal/model"
-	"strings"
+	"github.com/go-sql-driver/mysql"
+)
+
+// MySQL error numbers handled by the repository.
+const (
+	// errFulltextIndexMissing is returned when a MATCH clause has no FULLTEXT
+	// index covering its columns (ER_FT_MATCHING_KEY_NOT_FOUND).
+	errFulltextIndexMissing = 1191
+	// errDuplicateEntry is returned when a unique key is violated
+	// (ER_DUP_ENTRY). The only unique key of products is the sku, the one of
+	// product reviews is the reviewer and product and the one of categories
+	// is the name.
+	errDuplicateEntry = 1062
+	// errRowReferenced is returned when a row referenced by a foreign key is
+	// deleted (ER_ROW_IS_REFERENCED_2).
+	errRowReferenced = 1451
 )
 
 type repository struct {
@@ -38,13 +53,17 @@ func (r *repository) GetProduct(ctx context.Context, id int64) (model.Product, e
 		    p.weight,
 		    p.price,
 		    p.rating,
-		    p.created_at
+		    p.rating_score,
+		    p.created_at,
+		    p.version,
+		    p.updated_at,
+		    COALESCE(p.vendor_id, 0)
 		FROM products p
 		JOIN categories c ON p.category_id = c.id
-		WHERE p.id = ?
+		WHERE p.id = ? AND p.deleted_at IS NULL
 `
 	var res model.Product
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := r.conn(ctx).QueryRowContext(ctx, query, id).Scan(
 		&res.ID,
 		&res.SKU,
 		&res.Title,
@@ -55,7 +74,11 @@ func (r *repository) GetProduct(ctx context.Context, id int64) (model.Product, e
 		&res.Weight,
 		&res.Price,
 		&res.Rating,
+		&res.RatingScore,
 		&res.CreatedAt,
+		&res.Version,
+		&res.UpdatedAt,
+		&res.VendorID,
 	)
 	if err != nil {
 		return model.Product{}, err
@@ -77,13 +100,17 @@ func (r *repository) GetProductBySKU(ctx context.Context, sku string) (model.Pro
 		    p.weight,
 		    p.price,
 		    p.rating,
-		    p.created_at
+		    p.rating_score,
+		    p.created_at,
+		    p.version,
+		    p.updated_at,
+		    COALESCE(p.vendor_id, 0)
 		FROM products p
 		JOIN categories c ON p.category_id = c.id
-		WHERE p.sku = ?
+		WHERE p.sku = ? AND p.deleted_at IS NULL
 `
 	var res model.Product
-	err := r.db.QueryRowContext(ctx, query, sku).Scan(
+	err := r.conn(ctx).QueryRowContext(ctx, query, sku).Scan(
 		&res.ID,
 		&res.SKU,
 		&res.Title,
@@ -94,7 +121,11 @@ func (r *repository) GetProductBySKU(ctx context.Context, sku string) (model.Pro
 		&res.Weight,
 		&res.Price,
 		&res.Rating,
+		&res.RatingScore,
 		&res.CreatedAt,
+		&res.Version,
+		&res.UpdatedAt,
+		&res.VendorID,
 	)
 	if err != nil {
 		return model.Product{}, err
@@ -104,6 +135,17 @@ func (r *repository) GetProductBySKU(ctx context.Context, sku string) (model.Pro
 }
 
 func (r *repository) GetProductList(ctx context.Context, filter model.GetProductListFilter) ([]model.Product, error) {
+	q := newProductListQuery(filter)
+	res, err := r.getProductList(ctx, q)
+	if isFulltextIndexMissing(err) {
+		return r.getProductList(ctx, q.fallback())
+	}
+
+	return res, err
+}
+
+func (r *repository) getProductList(ctx context.Context, q productListQuery) ([]model.Product, error) {
+	relevance, args := q.relevance()
 	query := `
 		SELECT 
 		    p.id,
@@ -116,37 +158,42 @@ func (r *repository) GetProductList(ctx context.Context, filter model.GetProduct
 		    p.weight,
 		    p.price,
 		    p.rating,
-		    p.created_at
+		    p.rating_score,
+		    p.created_at,
+		    ` + relevance + ` AS relevance
 		FROM products p
 		JOIN categories c ON p.category_id = c.id
 `
 
-	var args []interface{}
+	where, whereArgs := q.where()
+	query += where
+	args = append(args, whereArgs...)
 
-	var filterQuery []string
-	if filter.Search != "" {
-		filterQuery = append(filterQuery, fmt.Sprintf("(p.title LIKE '%%%s%%' or p.sku LIKE '%%%s%%')", filter.Search, filter.Search))
-	}
-	if filter.CategoryID > 0 {
-		filterQuery = append(filterQuery, "p.category_id = ?")
-		args = append(args, filter.CategoryID)
+	keyset, keysetArgs, err := q.keyset()
+	if err != nil {
+		return nil, err
 	}
-	if len(filterQuery) > 0 {
-		query += " WHERE " + strings.Join(filterQuery, " AND ")
+	if keyset != "" {
+		query += " AND " + keyset
 	}
+	args = append(args, keysetArgs...)
 
-	if filter.SortColumn != "" {
-		query += fmt.Sprintf(" ORDER BY p.%s %s", filter.SortColumn, filter.SortType)
+	order, orderArgs, err := q.order()
+	if err != nil {
+		return nil, err
 	}
+	query += order
+	args = append(args, orderArgs...)
 
 	query += " LIMIT ? OFFSET ?"
-	args = append(args, filter.Limit, filter.Offset)
+	args = append(args, q.filter.Limit, q.filter.Offset)
 
 	var res []model.Product
-	rows, err := r.db.QueryContext(ctx, query, args...)
+	rows, err := r.conn(ctx).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 	for rows.Next() {
 		var data model.Product
 		err := rows.Scan(
@@ -160,7 +207,9 @@ func (r *repository) GetProductList(ctx context.Context, filter model.GetProduct
 			&data.Weight,
 			&data.Price,
 			&data.Rating,
+			&data.RatingScore,
 			&data.CreatedAt,
+			&data.Relevance,
 		)
 		if err != nil {
 			return nil, err
@@ -168,61 +217,471 @@ func (r *repository) GetProductList(ctx context.Context, filter model.GetProduct
 
 		res = append(res, data)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
 	return res, nil
 }
 
-func (r *repository) InsertProduct(ctx context.Context, product model.Product) error {
+func (r *repository) CountProductList(ctx context.Context, filter model.GetProductListFilter) (int64, error) {
+	q := newProductListQuery(filter)
+	res, err := r.countProductList(ctx, q)
+	if isFulltextIndexMissing(err) {
+		return r.countProductList(ctx, q.fallback())
+	}
+
+	return res, err
+}
+
+func (r *repository) countProductList(ctx context.Context, q productListQuery) (int64, error) {
 	query := `
-		INSERT INTO products(sku, title, description, category_id, image_url, weight, price, rating)
-		VALUES(?, ?, ?, ?, ?, ?, ?, ?)
-		
+		SELECT 
+		    COUNT(p.id)
+		FROM products p
+		JOIN categories c ON p.category_id = c.id
 `
-	_, err := r.db.ExecContext(ctx, query,
-		product.SKU,
-		product.Title,
-		product.Description,
-		product.Category.ID,
-		product.ImageURL,
-		product.Weight,
-		product.Price,
-		product.Rating,
-	)
+	where, args := q.where()
+	query += where
+
+	var res int64
+	err := r.conn(ctx).QueryRowContext(ctx, query, args...).Scan(&res)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	return res, nil
+}
+
+func (r *repository) GetProductFacets(ctx context.Context, filter model.GetProductListFilter) (model.ProductFacets, error) {
+	q := newProductListQuery(filter)
+	res, err := r.getProductFacets(ctx, q)
+	if isFulltextIndexMissing(err) {
+		return r.getProductFacets(ctx, q.fallback())
+	}
+
+	return res, err
 }
 
-func (r *repository) UpdateProduct(ctx context.Context, id int64, product model.Product) error {
+func (r *repository) getProductFacets(ctx context.Context, q productListQuery) (model.ProductFacets, error) {
+	var res model.ProductFacets
+
+	categories, err := r.getCategoryFacets(ctx, q.withoutCategories())
+	if err != nil {
+		return model.ProductFacets{}, err
+	}
+	res.Categories = categories
+
+	priceExpr, priceArgs := bucket("p.price", productPriceBuckets)
+	priceCounts, err := r.getBucketCounts(ctx, q.withoutPriceRange(), priceExpr, priceArgs)
+	if err != nil {
+		return model.ProductFacets{}, err
+	}
+	for i, lower := range productPriceBuckets {
+		facet := model.PriceFacet{Min: lower, Count: priceCounts[i]}
+		if i+1 < len(productPriceBuckets) {
+			facet.Max = productPriceBuckets[i+1]
+		}
+		res.Prices = append(res.Prices, facet)
+	}
+
+	ratingExpr, ratingArgs := bucket("p.rating", productRatingBuckets)
+	ratingCounts, err := r.getBucketCounts(ctx, q.withoutMinRating(), ratingExpr, ratingArgs)
+	if err != nil {
+		return model.ProductFacets{}, err
+	}
+	for i, lower := range productRatingBuckets {
+		facet := model.RatingFacet{Min: lower, Max: maxRating, Count: ratingCounts[i]}
+		if i+1 < len(productRatingBuckets) {
+			facet.Max = productRatingBuckets[i+1]
+		}
+		res.Ratings = append(res.Ratings, facet)
+	}
+
+	return res, nil
+}
+
+func (r *repository) getCategoryFacets(ctx context.Context, q productListQuery) ([]model.CategoryFacet, error) {
 	query := `
+		SELECT 
+		    c.id,
+		    c.name,
+		    COUNT(p.id)
+		FROM products p
+		JOIN categories c ON p.category_id = c.id
+`
+	where, args := q.where()
+	query += where
+	query += " GROUP BY c.id, c.name ORDER BY c.name"
+
+	var res []model.CategoryFacet
+	rows, err := r.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var data model.CategoryFacet
+		err := rows.Scan(
+			&data.Category.ID,
+			&data.Category.Name,
+			&data.Count,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, data)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// getBucketCounts counts the products matching q per value of a bucket
+// expression.
+func (r *repository) getBucketCounts(ctx context.Context, q productListQuery, expr string, exprArgs []interface{}) (map[int]int64, error) {
+	query := `
+		SELECT 
+		    ` + expr + ` AS bucket,
+		    COUNT(p.id)
+		FROM products p
+		JOIN categories c ON p.category_id = c.id
+`
+	where, args := q.where()
+	query += where
+	query += " GROUP BY bucket"
+	args = append(exprArgs, args...)
+
+	res := make(map[int]int64)
+	rows, err := r.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var index int
+		var count int64
+		if err := rows.Scan(&index, &count); err != nil {
+			return nil, err
+		}
+
+		res[index] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *repository) InsertProduct(ctx context.Context, product model.Product, actor model.User) (int64, error) {
+	var id int64
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		query := `
+		INSERT INTO products(sku, title, description, category_id, image_url, weight, price, rating, vendor_id)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, 0))
+		
+`
+		res, err := tx.ExecContext(ctx, query,
+			product.SKU,
+			product.Title,
+			product.Description,
+			product.Category.ID,
+			product.ImageURL,
+			product.Weight,
+			product.Price,
+			product.Rating,
+			product.VendorID,
+		)
+		if isDuplicateEntry(err) {
+			return model.ErrDuplicateSKU
+		}
+		if err != nil {
+			return err
+		}
+
+		id, err = res.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		if err := r.insertPrice(ctx, tx, id); err != nil {
+			return err
+		}
+
+		return r.recordHistory(ctx, tx, id, model.HistoryActionCreate, actor, nil)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// UpdateProduct writes every mutable field of a product if it is still at
+// product.Version, and increments the version. sql.ErrNoRows is returned when
+// the product does not exist and model.ErrVersionConflict when it has been
+// updated since that version.
+func (r *repository) UpdateProduct(ctx context.Context, id int64, product model.Product, actor model.User) error {
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		before, err := r.lockProductSnapshot(ctx, tx, id, false)
+		if err != nil {
+			return err
+		}
+		if before.Version != product.Version {
+			return model.ErrVersionConflict
+		}
+
+		query := `
 		UPDATE 
 		    products 
 		SET 
 		    sku = ?,
 		    title = ?,
 		    description = ?,
-		    category_id = ?
+		    category_id = ?,
+		    image_url = ?,
+		    weight = ?,
+		    price = ?,
+		    version = version + 1
+		WHERE id = ? AND version = ? AND deleted_at IS NULL
+`
+		_, err = tx.ExecContext(ctx, query,
+			product.SKU,
+			product.Title,
+			product.Description,
+			product.Category.ID,
+			product.ImageURL,
+			product.Weight,
+			product.Price,
+			id,
+			product.Version,
+		)
+		if isDuplicateEntry(err) {
+			return model.ErrDuplicateSKU
+		}
+		if err != nil {
+			return err
+		}
+
+		if product.Price != before.Price {
+			if err := r.changePrice(ctx, tx, id); err != nil {
+				return err
+			}
+		}
+
+		return r.recordHistory(ctx, tx, id, model.HistoryActionUpdate, actor, &before)
+	})
+}
+
+// DeleteProduct soft deletes a product, its reviews are kept. sql.ErrNoRows is
+// returned when there is no product to delete.
+func (r *repository) DeleteProduct(ctx context.Context, id int64, actor model.User) error {
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		before, err := r.lockProductSnapshot(ctx, tx, id, false)
+		if err != nil {
+			return err
+		}
+
+		query := `
+		UPDATE 
+		    products 
+		SET 
+		    deleted_at = NOW()
+		WHERE id = ? AND deleted_at IS NULL
+`
+		if _, err := tx.ExecContext(ctx, query, id); err != nil {
+			return err
+		}
+
+		return r.insertHistory(ctx, tx, id, model.HistoryActionDelete, actor, &before, nil)
+	})
+}
+
+// RestoreProduct undoes DeleteProduct. sql.ErrNoRows is returned when there is
+// no deleted product to restore.
+func (r *repository) RestoreProduct(ctx context.Context, id int64, actor model.User) error {
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := r.lockProductSnapshot(ctx, tx, id, true); err != nil {
+			return err
+		}
+
+		query := `
+		UPDATE 
+		    products 
+		SET 
+		    deleted_at = NULL
+		WHERE id = ? AND deleted_at IS NOT NULL
+`
+		if _, err := tx.ExecContext(ctx, query, id); err != nil {
+			return err
+		}
+
+		return r.recordHistory(ctx, tx, id, model.HistoryActionRestore, actor, nil)
+	})
+}
+
+// MoveCategoryProducts moves every product of a category, deleted ones
+// included, to another category. Each moved product gets a new version and a
+// history entry.
+func (r *repository) MoveCategoryProducts(ctx context.Context, fromCategoryID, toCategoryID int64, actor model.User) error {
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		query := `
+		SELECT 
+		    id,
+		    deleted_at IS NOT NULL
+		FROM products 
+		WHERE category_id = ?
+		ORDER BY id
+		FOR UPDATE
+`
+		rows, err := tx.QueryContext(ctx, query, fromCategoryID)
+		if err != nil {
+			return err
+		}
+		deleted := map[int64]bool{}
+		var ids []int64
+		for rows.Next() {
+			var id int64
+			var isDeleted bool
+			if err := rows.Scan(&id, &isDeleted); err != nil {
+				rows.Close()
+				return err
+			}
+			ids = append(ids, id)
+			deleted[id] = isDeleted
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			before, err := r.lockProductSnapshot(ctx, tx, id, deleted[id])
+			if err != nil {
+				return err
+			}
+
+			query := `
+		UPDATE 
+		    products 
+		SET 
+		    category_id = ?,
+		    version = version + 1
 		WHERE id = ?
 `
-	_, err := r.db.ExecContext(ctx, query, product.SKU, product.Title, product.Description, product.Category.ID, id)
+			if _, err := tx.ExecContext(ctx, query, toCategoryID, id); err != nil {
+				return err
+			}
+
+			after, err := r.lockProductSnapshot(ctx, tx, id, deleted[id])
+			if err != nil {
+				return err
+			}
+			if err := r.insertHistory(ctx, tx, id, model.HistoryActionUpdate, actor, &before, &after); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// AddProductRating applies delta to the rating aggregate of a product in a
+// single statement, so concurrent reviews never overwrite each other. The
+// score uses the prior of the product category, or prior when it has none.
+func (r *repository) AddProductRating(ctx context.Context, id int64, delta model.RatingDelta, prior model.RatingPrior, actor model.User) error {
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		before, err := r.lockProductSnapshot(ctx, tx, id, false)
+		if err != nil {
+			return err
+		}
+
+		prior, err := r.getCategoryRatingPrior(ctx, tx, before.CategoryID, prior)
+		if err != nil {
+			return err
+		}
+
+		// MySQL assigns from left to right, so rating and rating_score are
+		// computed from the updated sum and count.
+		query := `
+		UPDATE 
+		    products 
+		SET 
+		    rating_sum = rating_sum + ?,
+		    rating_count = rating_count + ?,
+		    rating = IF(rating_count = 0, 0, rating_sum / rating_count),
+		    rating_score = (? * ? + rating_sum) / (? + rating_count)
+		WHERE id = ?
+`
+		_, err = tx.ExecContext(ctx, query,
+			delta.Sum,
+			delta.Count,
+			prior.Weight,
+			prior.Mean,
+			prior.Weight,
+			id,
+		)
+		if err != nil {
+			return err
+		}
+
+		return r.recordHistory(ctx, tx, id, model.HistoryActionRating, actor, &before)
+	})
+}
+
+// getCategoryRatingPrior returns the rating prior of a category, or fallback
+// when the category has none.
+func (r *repository) getCategoryRatingPrior(ctx context.Context, tx *sql.Tx, categoryID int64, fallback model.RatingPrior) (model.RatingPrior, error) {
+	query := `
+		SELECT 
+		    COALESCE(rating_prior_mean, ?),
+		    COALESCE(rating_prior_weight, ?)
+		FROM categories 
+		WHERE id = ?
+`
+	var res model.RatingPrior
+	err := tx.QueryRowContext(ctx, query, fallback.Mean, fallback.Weight, categoryID).Scan(
+		&res.Mean,
+		&res.Weight,
+	)
 	if err != nil {
-		return err
+		return model.RatingPrior{}, err
 	}
 
-	return nil
+	return res, nil
 }
 
-func (r *repository) UpdateProductRating(ctx context.Context, id int64, rating float64) error {
+// RecomputeRatingScores scores every product again, for when the priors have
+// changed. Categories without a prior use prior.
+func (r *repository) RecomputeRatingScores(ctx context.Context, prior model.RatingPrior) error {
 	query := `
 		UPDATE 
-		    products 
+		    products p
+		JOIN categories c ON p.category_id = c.id
 		SET 
-		    rating = ?
-		WHERE id = ?
+		    p.rating_score = (COALESCE(c.rating_prior_weight, ?) * COALESCE(c.rating_prior_mean, ?) + p.rating_sum) / (COALESCE(c.rating_prior_weight, ?) + p.rating_count)
+`
+	_, err := r.conn(ctx).ExecContext(ctx, query, prior.Weight, prior.Mean, prior.Weight)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *repository) InsertReview(ctx context.Context, review model.ProductReview) error {
+	query := `
+		INSERT INTO product_reviews(user_id, product_id, rating, comment, status, flag_reason)
+		VALUES(?, ?, ?, ?, ?, ?)
 `
-	_, err := r.db.ExecContext(ctx, query, rating, id)
+	_, err := r.conn(ctx).ExecContext(ctx, query, review.UserID, review.ProductID, review.Rating, review.Comment, review.Status, review.FlagReason)
+	if isDuplicateEntry(err) {
+		return model.ErrDuplicateReview
+	}
 	if err != nil {
 		return err
 	}
@@ -230,32 +689,73 @@ func (r *repository) UpdateProductRating(ctx context.Context, id int64, rating f
 	return nil
 }
 
-func (r *repository) GetCategory(ctx context.Context, id int64) (model.Category, error) {
+// GetReview returns a review of a product and locks it until the end of the
+// transaction of ctx.
+func (r *repository) GetReview(ctx context.Context, productID, id int64) (model.ProductReview, error) {
+	return r.getReview(ctx, "pr.product_id = ? AND pr.id = ?", productID, id)
+}
+
+// GetReviewByID returns a review of any product and locks it until the end of
+// the transaction of ctx.
+func (r *repository) GetReviewByID(ctx context.Context, id int64) (model.ProductReview, error) {
+	return r.getReview(ctx, "pr.id = ?", id)
+}
+
+// GetUserReview returns the review of a product by a user and locks it until
+// the end of the transaction of ctx.
+func (r *repository) GetUserReview(ctx context.Context, productID, userID int64) (model.ProductReview, error) {
+	return r.getReview(ctx, "pr.product_id = ? AND pr.user_id = ?", productID, userID)
+}
+
+func (r *repository) getReview(ctx context.Context, where string, args ...interface{}) (model.ProductReview, error) {
 	query := `
 		SELECT 
-		    id,
-		    name
-		FROM categories 
-		WHERE id = ?
+		    pr.id,
+		    pr.user_id,
+		    pr.product_id,
+		    pr.rating,
+		    pr.comment,
+		    pr.status,
+		    pr.flag_reason,
+		    u.subject,
+		    u.name
+		FROM product_reviews pr
+		JOIN users u ON u.id = pr.user_id
+		WHERE ` + where + `
+		FOR UPDATE
 `
-	var res model.Category
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	var res model.ProductReview
+	err := r.conn(ctx).QueryRowContext(ctx, query, args...).Scan(
 		&res.ID,
-		&res.Name,
+		&res.UserID,
+		&res.ProductID,
+		&res.Rating,
+		&res.Comment,
+		&res.Status,
+		&res.FlagReason,
+		&res.User.Subject,
+		&res.User.Name,
 	)
 	if err != nil {
-		return model.Category{}, err
+		return model.ProductReview{}, err
 	}
+	res.User.ID = res.UserID
 
 	return res, nil
 }
 
-func (r *repository) InsertReview(ctx context.Context, review model.ProductReview) error {
+func (r *repository) UpdateReview(ctx context.Context, review model.ProductReview) error {
 	query := `
-		INSERT INTO product_reviews(user_id, product_id, rating, comment)
-		VALUES(?, ?, ?, ?)
+		UPDATE 
+		    product_reviews 
+		SET 
+		    rating = ?,
+		    comment = ?,
+		    status = ?,
+		    flag_reason = ?
+		WHERE id = ?
 `
-	_, err := r.db.ExecContext(ctx, query, review.UserID, review.ProductID, review.Rating, review.Comment)
+	_, err := r.conn(ctx).ExecContext(ctx, query, review.Rating, review.Comment, review.Status, review.FlagReason, review.ID)
 	if err != nil {
 		return err
 	}
@@ -263,19 +763,43 @@ func (r *repository) InsertReview(ctx context.Context, review model.ProductRevie
 	return nil
 }
 
+func (r *repository) DeleteReview(ctx context.Context, id int64) error {
+	query := `
+		DELETE FROM product_reviews 
+		WHERE id = ?
+`
+	_, err := r.conn(ctx).ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetReviewStatistic returns the statistic of the approved reviews of a
+// product, a product without approved reviews has a zero statistic.
 func (r *repository) GetReviewStatistic(ctx context.Context, productID int64) (model.Statistic, error) {
 	query := `
 		SELECT 
-		    AVG(rating),
-		    COUNT(id)
+		    COALESCE(AVG(rating), 0),
+		    COUNT(id),
+		    COALESCE(SUM(rating = 1), 0),
+		    COALESCE(SUM(rating = 2), 0),
+		    COALESCE(SUM(rating = 3), 0),
+		    COALESCE(SUM(rating = 4), 0),
+		    COALESCE(SUM(rating = 5), 0)
 		FROM product_reviews 
-		WHERE product_id = ?
-		GROUP BY product_id
+		WHERE product_id = ? AND status = ?
 `
 	var res model.Statistic
-	err := r.db.QueryRowContext(ctx, query, productID).Scan(
+	err := r.conn(ctx).QueryRowContext(ctx, query, productID, model.ReviewStatusApproved).Scan(
 		&res.Average,
 		&res.Count,
+		&res.Distribution[0],
+		&res.Distribution[1],
+		&res.Distribution[2],
+		&res.Distribution[3],
+		&res.Distribution[4],
 	)
 	if err != nil {
 		return model.Statistic{}, err
@@ -283,3 +807,18 @@ func (r *repository) GetReviewStatistic(ctx context.Context, productID int64) (m
 
 	return res, nil
 }
+
+func isFulltextIndexMissing(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == errFulltextIndexMissing
+}
+
+func isDuplicateEntry(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == errDuplicateEntry
+}
+
+func isRowReferenced(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == errRowReferenced
+}
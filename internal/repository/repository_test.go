@@ -0,0 +1,751 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alam/govtech/internal/model"
+	"github.com/go-sql-driver/mysql"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+var productColumns = []string{
+	"id", "sku", "title", "description", "category_id", "category_name",
+	"image_url", "weight", "price", "rating", "rating_score", "created_at", "relevance",
+}
+
+func Test_repository_GetProductList(t *testing.T) {
+	createdAt := time.Date(2023, 11, 20, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		filter  model.GetProductListFilter
+		prepare func(mock sqlmock.Sqlmock)
+		want    []model.Product
+		wantErr bool
+	}{
+		{
+			name: "hostile search is bound and matches nothing",
+			filter: model.GetProductListFilter{
+				Search: "' OR 1=1; DROP TABLE products; --",
+				Limit:  10,
+			},
+			prepare: func(mock sqlmock.Sqlmock) {
+				terms := "OR* 1* 1* DROP* TABLE* products*"
+				mock.ExpectQuery(regexp.QuoteMeta("WHERE p.deleted_at IS NULL AND (MATCH(p.title, p.description, p.sku) AGAINST(? IN BOOLEAN MODE) OR MATCH(c.name) AGAINST(? IN BOOLEAN MODE)) ORDER BY p.id ASC LIMIT ? OFFSET ?")).
+					WithArgs(terms, terms, terms, terms, int64(10), int64(0)).
+					WillReturnRows(sqlmock.NewRows(productColumns))
+			},
+			want: nil,
+		},
+		{
+			name: "fulltext search ranked by relevance",
+			filter: model.GetProductListFilter{
+				Search:     "cotton",
+				SortColumn: "relevance",
+				Limit:      10,
+			},
+			prepare: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("MATCH(p.title, p.description, p.sku) AGAINST(? IN BOOLEAN MODE) + MATCH(c.name) AGAINST(? IN BOOLEAN MODE) AS relevance")).
+					WithArgs("cotton*", "cotton*", "cotton*", "cotton*", int64(10), int64(0)).
+					WillReturnRows(sqlmock.NewRows(productColumns).
+						AddRow(1, "IND001", "100% Cotton", "kaos", 1, "Food", "https://foo.bar/foo.jpg", 1, 1000, 4.5, 3.8, createdAt, 1.75))
+			},
+			want: []model.Product{
+				{
+					ID:          1,
+					SKU:         "IND001",
+					Title:       "100% Cotton",
+					Description: "kaos",
+					Category: model.Category{
+						ID:   1,
+						Name: "Food",
+					},
+					ImageURL:    "https://foo.bar/foo.jpg",
+					Weight:      1,
+					Price:       1000,
+					Rating:      4.5,
+					RatingScore: 3.8,
+					CreatedAt:   createdAt,
+					Relevance:   1.75,
+				},
+			},
+		},
+		{
+			name: "fall back to like when fulltext index is missing",
+			filter: model.GetProductListFilter{
+				Search:     "100%",
+				SortColumn: "rating",
+				SortType:   "desc",
+				Limit:      10,
+				Offset:     10,
+			},
+			prepare: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("AGAINST(? IN BOOLEAN MODE)")).
+					WillReturnError(&mysql.MySQLError{Number: errFulltextIndexMissing})
+				pattern := "%100!%%"
+				mock.ExpectQuery(regexp.QuoteMeta("WHERE p.deleted_at IS NULL AND (p.title LIKE ? ESCAPE '!' OR p.description LIKE ? ESCAPE '!' OR p.sku LIKE ? ESCAPE '!' OR c.name LIKE ? ESCAPE '!') ORDER BY p.rating DESC, p.id DESC LIMIT ? OFFSET ?")).
+					WithArgs(pattern, pattern, pattern, pattern, pattern, pattern, pattern, pattern, int64(10), int64(10)).
+					WillReturnRows(sqlmock.NewRows(productColumns).
+						AddRow(1, "IND001", "100% Cotton", "kaos", 1, "Food", "https://foo.bar/foo.jpg", 1, 1000, 4.5, 3.8, createdAt, 1))
+			},
+			want: []model.Product{
+				{
+					ID:          1,
+					SKU:         "IND001",
+					Title:       "100% Cotton",
+					Description: "kaos",
+					Category: model.Category{
+						ID:   1,
+						Name: "Food",
+					},
+					ImageURL:    "https://foo.bar/foo.jpg",
+					Weight:      1,
+					Price:       1000,
+					Rating:      4.5,
+					RatingScore: 3.8,
+					CreatedAt:   createdAt,
+					Relevance:   1,
+				},
+			},
+		},
+		{
+			name: "other database error is returned",
+			filter: model.GetProductListFilter{
+				Search: "keju",
+				Limit:  10,
+			},
+			prepare: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("AGAINST(? IN BOOLEAN MODE)")).
+					WillReturnError(&mysql.MySQLError{Number: 1146})
+			},
+			wantErr: true,
+		},
+		{
+			name: "page after cursor",
+			filter: model.GetProductListFilter{
+				CategoryIDs: []int64{2},
+				SortColumn:  "created_at",
+				SortType:    "desc",
+				After:       &model.ProductCursor{Value: createdAt, ID: 7},
+				Limit:       10,
+			},
+			prepare: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("WHERE p.deleted_at IS NULL AND p.category_id IN (?) AND (p.created_at, p.id) < (?, ?) ORDER BY p.created_at DESC, p.id DESC LIMIT ? OFFSET ?")).
+					WithArgs(int64(2), createdAt, int64(7), int64(10), int64(0)).
+					WillReturnRows(sqlmock.NewRows(productColumns))
+			},
+			want: nil,
+		},
+		{
+			name: "sort column outside whitelist",
+			filter: model.GetProductListFilter{
+				SortColumn: "(SELECT password FROM users)",
+				Limit:      10,
+			},
+			prepare: func(mock sqlmock.Sqlmock) {},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to open sqlmock: %v", err)
+			}
+			defer db.Close()
+			tt.prepare(mock)
+
+			r := &repository{db: db}
+			got, err := r.GetProductList(context.Background(), tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetProductList() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetProductList() got = %v, want %v", got, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("GetProductList() unmet expectation: %v", err)
+			}
+		})
+	}
+}
+
+func Test_repository_GetProductFacets(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	filter := model.GetProductListFilter{
+		CategoryIDs: []int64{1},
+		MinPrice:    20000,
+		Limit:       10,
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE p.deleted_at IS NULL AND p.price >= ? GROUP BY c.id, c.name")).
+		WithArgs(int64(20000)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "count"}).
+			AddRow(1, "Food", 4).
+			AddRow(2, "Pet", 1))
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE p.deleted_at IS NULL AND p.category_id IN (?) GROUP BY bucket")).
+		WithArgs(int64(10000), int64(50000), int64(100000), int64(500000), int64(1000000), int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"bucket", "count"}).
+			AddRow(1, 2).
+			AddRow(2, 2))
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE p.deleted_at IS NULL AND p.category_id IN (?) AND p.price >= ? GROUP BY bucket")).
+		WithArgs(float64(1), float64(2), float64(3), float64(4), int64(1), int64(20000)).
+		WillReturnRows(sqlmock.NewRows([]string{"bucket", "count"}).
+			AddRow(4, 4))
+
+	r := &repository{db: db}
+	got, err := r.GetProductFacets(context.Background(), filter)
+	if err != nil {
+		t.Fatalf("GetProductFacets() error = %v", err)
+	}
+
+	want := model.ProductFacets{
+		Categories: []model.CategoryFacet{
+			{Category: model.Category{ID: 1, Name: "Food"}, Count: 4},
+			{Category: model.Category{ID: 2, Name: "Pet"}, Count: 1},
+		},
+		Prices: []model.PriceFacet{
+			{Min: 0, Max: 10000, Count: 0},
+			{Min: 10000, Max: 50000, Count: 2},
+			{Min: 50000, Max: 100000, Count: 2},
+			{Min: 100000, Max: 500000, Count: 0},
+			{Min: 500000, Max: 1000000, Count: 0},
+			{Min: 1000000, Max: 0, Count: 0},
+		},
+		Ratings: []model.RatingFacet{
+			{Min: 0, Max: 1, Count: 0},
+			{Min: 1, Max: 2, Count: 0},
+			{Min: 2, Max: 3, Count: 0},
+			{Min: 3, Max: 4, Count: 0},
+			{Min: 4, Max: 5, Count: 4},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetProductFacets() got = %v, want %v", got, want)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("GetProductFacets() unmet expectation: %v", err)
+	}
+}
+
+func Test_repository_CountProductList(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT \n\t\t    COUNT(p.id)\n\t\tFROM products p\n\t\tJOIN categories c ON p.category_id = c.id\n WHERE p.deleted_at IS NULL AND p.category_id IN (?) AND p.rating >= ?")).
+		WithArgs(int64(2), float64(4)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(12))
+
+	r := &repository{db: db}
+	got, err := r.CountProductList(context.Background(), model.GetProductListFilter{
+		CategoryIDs: []int64{2},
+		MinRating:   4,
+		SortColumn:  "rating",
+		Limit:       10,
+		Offset:      20,
+	})
+	if err != nil {
+		t.Fatalf("CountProductList() error = %v", err)
+	}
+	if got != 12 {
+		t.Errorf("CountProductList() got = %v, want %v", got, 12)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("CountProductList() unmet expectation: %v", err)
+	}
+}
+
+func Test_repository_GetProductBySKU_deleted(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE p.sku = ? AND p.deleted_at IS NULL")).
+		WithArgs("IND001").
+		WillReturnRows(sqlmock.NewRows(productColumns[:11]))
+
+	r := &repository{db: db}
+	if _, err := r.GetProductBySKU(context.Background(), "IND001"); err != sql.ErrNoRows {
+		t.Errorf("GetProductBySKU() error = %v, want %v", err, sql.ErrNoRows)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("GetProductBySKU() unmet expectation: %v", err)
+	}
+}
+
+var snapshotColumns = []string{
+	"sku", "title", "description", "category_id", "image_url", "weight", "price", "rating", "version",
+}
+
+const (
+	lockActiveProduct  = "WHERE id = ? AND deleted_at IS NULL\n\t\tFOR UPDATE"
+	lockDeletedProduct = "WHERE id = ? AND deleted_at IS NOT NULL\n\t\tFOR UPDATE"
+)
+
+func Test_repository_InsertProduct(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO products")).
+		WillReturnResult(sqlmock.NewResult(9, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO product_prices(product_id, price, effective_from)\n\t\tSELECT id, price, created_at FROM products WHERE id = ?")).
+		WithArgs(int64(9)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(lockActiveProduct)).
+		WithArgs(int64(9)).
+		WillReturnRows(sqlmock.NewRows(snapshotColumns).
+			AddRow("IND009", "title", "description", 1, "https://foo.bar/foo.jpg", 2, 15000, 0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO product_history")).
+		WithArgs(int64(9), "create", "", "", nil,
+			[]byte(`{"sku":"IND009","title":"title","description":"description","categoryId":1,"imageUrl":"https://foo.bar/foo.jpg","weight":2,"price":15000,"rating":0,"version":1}`)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	r := &repository{db: db}
+	got, err := r.InsertProduct(context.Background(), model.Product{SKU: "IND009", Price: 15000}, model.User{})
+	if err != nil {
+		t.Fatalf("InsertProduct() error = %v", err)
+	}
+	if got != 9 {
+		t.Errorf("InsertProduct() got = %v, want %v", got, 9)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("InsertProduct() unmet expectation: %v", err)
+	}
+}
+
+func Test_repository_InsertProduct_duplicateSKU(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	// The sku of a deleted product is still taken by the unique key.
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO products")).
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'IND001' for key 'products.sku'"})
+	mock.ExpectRollback()
+
+	r := &repository{db: db}
+	_, err = r.InsertProduct(context.Background(), model.Product{SKU: "IND001"}, model.User{})
+	if err != model.ErrDuplicateSKU {
+		t.Errorf("InsertProduct() error = %v, want %v", err, model.ErrDuplicateSKU)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("InsertProduct() unmet expectation: %v", err)
+	}
+}
+
+func Test_repository_UpdateProduct(t *testing.T) {
+	ctx := context.Background()
+	actor := model.User{Subject: "u1", Name: "Budi"}
+	product := model.Product{
+		ID:          5,
+		SKU:         "IND005",
+		Title:       "title",
+		Description: "description",
+		Category:    model.Category{ID: 3},
+		ImageURL:    "https://foo.bar/foo.jpg",
+		Weight:      2,
+		Price:       15000,
+		Version:     4,
+	}
+
+	tests := []struct {
+		name    string
+		prepare func(mock sqlmock.Sqlmock)
+		wantErr error
+	}{
+		{
+			name: "updated with history",
+			prepare: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(lockActiveProduct)).
+					WithArgs(int64(5)).
+					WillReturnRows(sqlmock.NewRows(snapshotColumns).
+						AddRow("IND005", "title", "description", 3, "https://foo.bar/foo.jpg", 2, 10000, 4.5, 4))
+				mock.ExpectExec(regexp.QuoteMeta("price = ?,\n\t\t    version = version + 1\n\t\tWHERE id = ? AND version = ? AND deleted_at IS NULL")).
+					WithArgs("IND005", "title", "description", int64(3), "https://foo.bar/foo.jpg", int32(2), int64(15000), int64(5), int64(4)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta("SET \n\t\t    pp.effective_to = p.updated_at\n\t\tWHERE pp.product_id = ? AND pp.effective_to IS NULL")).
+					WithArgs(int64(5)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO product_prices(product_id, price, effective_from)\n\t\tSELECT id, price, updated_at FROM products WHERE id = ?")).
+					WithArgs(int64(5)).
+					WillReturnResult(sqlmock.NewResult(2, 1))
+				mock.ExpectQuery(regexp.QuoteMeta(lockActiveProduct)).
+					WithArgs(int64(5)).
+					WillReturnRows(sqlmock.NewRows(snapshotColumns).
+						AddRow("IND005", "title", "description", 3, "https://foo.bar/foo.jpg", 2, 15000, 4.5, 5))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO product_history")).
+					WithArgs(int64(5), "update", "u1", "Budi",
+						[]byte(`{"sku":"IND005","title":"title","description":"description","categoryId":3,"imageUrl":"https://foo.bar/foo.jpg","weight":2,"price":10000,"rating":4.5,"version":4}`),
+						[]byte(`{"sku":"IND005","title":"title","description":"description","categoryId":3,"imageUrl":"https://foo.bar/foo.jpg","weight":2,"price":15000,"rating":4.5,"version":5}`)).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "unchanged price keeps the price timeline",
+			prepare: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(lockActiveProduct)).
+					WithArgs(int64(5)).
+					WillReturnRows(sqlmock.NewRows(snapshotColumns).
+						AddRow("IND005", "old title", "description", 3, "https://foo.bar/foo.jpg", 2, 15000, 4.5, 4))
+				mock.ExpectExec(regexp.QuoteMeta("WHERE id = ? AND version = ? AND deleted_at IS NULL")).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(regexp.QuoteMeta(lockActiveProduct)).
+					WithArgs(int64(5)).
+					WillReturnRows(sqlmock.NewRows(snapshotColumns).
+						AddRow("IND005", "title", "description", 3, "https://foo.bar/foo.jpg", 2, 15000, 4.5, 5))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO product_history")).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "product not found",
+			prepare: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(lockActiveProduct)).
+					WithArgs(int64(5)).
+					WillReturnRows(sqlmock.NewRows(snapshotColumns))
+				mock.ExpectRollback()
+			},
+			wantErr: sql.ErrNoRows,
+		},
+		{
+			name: "stale version",
+			prepare: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(lockActiveProduct)).
+					WithArgs(int64(5)).
+					WillReturnRows(sqlmock.NewRows(snapshotColumns).
+						AddRow("IND005", "title", "description", 3, "https://foo.bar/foo.jpg", 2, 10000, 4.5, 6))
+				mock.ExpectRollback()
+			},
+			wantErr: model.ErrVersionConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to open sqlmock: %v", err)
+			}
+			defer db.Close()
+			tt.prepare(mock)
+
+			r := &repository{db: db}
+			if err := r.UpdateProduct(ctx, 5, product, actor); err != tt.wantErr {
+				t.Errorf("UpdateProduct() error = %v, want %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("UpdateProduct() unmet expectation: %v", err)
+			}
+		})
+	}
+}
+
+func Test_repository_DeleteProduct(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(mock sqlmock.Sqlmock)
+		wantErr error
+	}{
+		{
+			name: "soft deleted with history",
+			prepare: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(lockActiveProduct)).
+					WithArgs(int64(3)).
+					WillReturnRows(sqlmock.NewRows(snapshotColumns).
+						AddRow("IND003", "title", "description", 1, "https://foo.bar/foo.jpg", 2, 10000, 4, 2))
+				mock.ExpectExec(regexp.QuoteMeta("deleted_at = NOW()\n\t\tWHERE id = ? AND deleted_at IS NULL")).
+					WithArgs(int64(3)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO product_history")).
+					WithArgs(int64(3), "delete", "", "", sqlmock.AnyArg(), nil).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "missing or already deleted",
+			prepare: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(lockActiveProduct)).
+					WithArgs(int64(3)).
+					WillReturnRows(sqlmock.NewRows(snapshotColumns))
+				mock.ExpectRollback()
+			},
+			wantErr: sql.ErrNoRows,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to open sqlmock: %v", err)
+			}
+			defer db.Close()
+			tt.prepare(mock)
+
+			r := &repository{db: db}
+			if err := r.DeleteProduct(context.Background(), 3, model.User{}); err != tt.wantErr {
+				t.Errorf("DeleteProduct() error = %v, want %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("DeleteProduct() unmet expectation: %v", err)
+			}
+		})
+	}
+}
+
+func Test_repository_RestoreProduct(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(mock sqlmock.Sqlmock)
+		wantErr error
+	}{
+		{
+			name: "restored with history",
+			prepare: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(lockDeletedProduct)).
+					WithArgs(int64(3)).
+					WillReturnRows(sqlmock.NewRows(snapshotColumns).
+						AddRow("IND003", "title", "description", 1, "https://foo.bar/foo.jpg", 2, 10000, 4, 2))
+				mock.ExpectExec(regexp.QuoteMeta("deleted_at = NULL\n\t\tWHERE id = ? AND deleted_at IS NOT NULL")).
+					WithArgs(int64(3)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(regexp.QuoteMeta(lockActiveProduct)).
+					WithArgs(int64(3)).
+					WillReturnRows(sqlmock.NewRows(snapshotColumns).
+						AddRow("IND003", "title", "description", 1, "https://foo.bar/foo.jpg", 2, 10000, 4, 2))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO product_history")).
+					WithArgs(int64(3), "restore", "", "", nil, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "missing or not deleted",
+			prepare: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(lockDeletedProduct)).
+					WithArgs(int64(3)).
+					WillReturnRows(sqlmock.NewRows(snapshotColumns))
+				mock.ExpectRollback()
+			},
+			wantErr: sql.ErrNoRows,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to open sqlmock: %v", err)
+			}
+			defer db.Close()
+			tt.prepare(mock)
+
+			r := &repository{db: db}
+			if err := r.RestoreProduct(context.Background(), 3, model.User{}); err != tt.wantErr {
+				t.Errorf("RestoreProduct() error = %v, want %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("RestoreProduct() unmet expectation: %v", err)
+			}
+		})
+	}
+}
+
+func Test_repository_AddProductRating(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(lockActiveProduct)).
+		WithArgs(int64(4)).
+		WillReturnRows(sqlmock.NewRows(snapshotColumns).
+			AddRow("IND004", "title", "description", 1, "https://foo.bar/foo.jpg", 2, 10000, 3, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("COALESCE(rating_prior_mean, ?),\n\t\t    COALESCE(rating_prior_weight, ?)\n\t\tFROM categories \n\t\tWHERE id = ?")).
+		WithArgs(3.0, 10.0, int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"mean", "weight"}).AddRow(4.0, 20.0))
+	mock.ExpectExec(regexp.QuoteMeta("rating_sum = rating_sum + ?,\n\t\t    rating_count = rating_count + ?,\n\t\t    rating = IF(rating_count = 0, 0, rating_sum / rating_count),\n\t\t    rating_score = (? * ? + rating_sum) / (? + rating_count)\n\t\tWHERE id = ?")).
+		WithArgs(int64(5), int64(1), 20.0, 4.0, 20.0, int64(4)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(lockActiveProduct)).
+		WithArgs(int64(4)).
+		WillReturnRows(sqlmock.NewRows(snapshotColumns).
+			AddRow("IND004", "title", "description", 1, "https://foo.bar/foo.jpg", 2, 10000, 4, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO product_history")).
+		WithArgs(int64(4), "rating", "", "", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	r := &repository{db: db}
+	if err := r.AddProductRating(context.Background(), 4, model.RatingDelta{Sum: 5, Count: 1}, model.RatingPrior{Mean: 3, Weight: 10}, model.User{}); err != nil {
+		t.Errorf("AddProductRating() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("AddProductRating() unmet expectation: %v", err)
+	}
+}
+
+func Test_repository_GetUserReview(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE pr.product_id = ? AND pr.user_id = ?\n\t\tFOR UPDATE")).
+		WithArgs(int64(4), int64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "product_id", "rating", "comment", "status", "flag_reason", "subject", "name"}).
+			AddRow(3, 7, 4, 2, "comment", "approved", "", "u1", "Budi"))
+
+	r := &repository{db: db}
+	got, err := r.GetUserReview(context.Background(), 4, 7)
+	if err != nil {
+		t.Fatalf("GetUserReview() error = %v", err)
+	}
+	want := model.ProductReview{
+		ID:        3,
+		UserID:    7,
+		ProductID: 4,
+		Rating:    2,
+		Comment:   "comment",
+		Status:    "approved",
+		User:      model.User{ID: 7, Subject: "u1", Name: "Budi"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetUserReview() got = %+v, want %+v", got, want)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("GetUserReview() unmet expectation: %v", err)
+	}
+}
+
+func Test_repository_GetReview(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE pr.product_id = ? AND pr.id = ?\n\t\tFOR UPDATE")).
+		WithArgs(int64(4), int64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "product_id", "rating", "comment", "status", "flag_reason", "subject", "name"}).
+			AddRow(3, 7, 4, 2, "comment", "approved", "", "u1", "Budi"))
+
+	r := &repository{db: db}
+	got, err := r.GetReview(context.Background(), 4, 3)
+	if err != nil {
+		t.Fatalf("GetReview() error = %v", err)
+	}
+	want := model.ProductReview{
+		ID:        3,
+		UserID:    7,
+		ProductID: 4,
+		Rating:    2,
+		Comment:   "comment",
+		Status:    "approved",
+		User:      model.User{ID: 7, Subject: "u1", Name: "Budi"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetReview() got = %+v, want %+v", got, want)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("GetReview() unmet expectation: %v", err)
+	}
+}
+
+func Test_repository_DeleteReview(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM product_reviews")).
+		WithArgs(int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	r := &repository{db: db}
+	if err := r.DeleteReview(context.Background(), 3); err != nil {
+		t.Errorf("DeleteReview() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("DeleteReview() unmet expectation: %v", err)
+	}
+}
+
+func Test_repository_InsertReview_duplicate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO product_reviews")).
+		WithArgs(int64(7), int64(4), int32(5), "comment", "approved", "").
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry '7-4' for key 'product_reviews.uq_product_reviews_user_product'"})
+
+	r := &repository{db: db}
+	err = r.InsertReview(context.Background(), model.ProductReview{UserID: 7, ProductID: 4, Rating: 5, Comment: "comment", Status: "approved"})
+	if err != model.ErrDuplicateReview {
+		t.Errorf("InsertReview() error = %v, want %v", err, model.ErrDuplicateReview)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("InsertReview() unmet expectation: %v", err)
+	}
+}
+
+func Test_repository_RecomputeRatingScores(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(regexp.QuoteMeta("p.rating_score = (COALESCE(c.rating_prior_weight, ?) * COALESCE(c.rating_prior_mean, ?) + p.rating_sum) / (COALESCE(c.rating_prior_weight, ?) + p.rating_count)")).
+		WithArgs(10.0, 3.0, 10.0).
+		WillReturnResult(sqlmock.NewResult(0, 12))
+
+	r := &repository{db: db}
+	if err := r.RecomputeRatingScores(context.Background(), model.RatingPrior{Mean: 3, Weight: 10}); err != nil {
+		t.Errorf("RecomputeRatingScores() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("RecomputeRatingScores() unmet expectation: %v", err)
+	}
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"github.com/alam/govtech/internal/model"
+)
+
+// UpsertReviewReply saves the reply to a review, replacing the previous one.
+func (r *repository) UpsertReviewReply(ctx context.Context, reply model.ReviewReply) error {
+	query := `
+		INSERT INTO review_replies(review_id, vendor_id, comment)
+		VALUES(?, ?, ?)
+		ON DUPLICATE KEY UPDATE 
+		    vendor_id = VALUES(vendor_id),
+		    comment = VALUES(comment)
+`
+	_, err := r.conn(ctx).ExecContext(ctx, query, reply.ReviewID, reply.VendorID, reply.Comment)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *repository) DeleteReviewReply(ctx context.Context, reviewID int64) error {
+	query := `
+		DELETE FROM review_replies 
+		WHERE review_id = ?
+`
+	_, err := r.conn(ctx).ExecContext(ctx, query, reviewID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alam/govtech/internal/model"
+	"regexp"
+	"testing"
+)
+
+func Test_repository_UpsertReviewReply(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO review_replies(review_id, vendor_id, comment)\n\t\tVALUES(?, ?, ?)\n\t\tON DUPLICATE KEY UPDATE")).
+		WithArgs(int64(3), int64(9), "terima kasih").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	r := &repository{db: db}
+	err = r.UpsertReviewReply(context.Background(), model.ReviewReply{ReviewID: 3, VendorID: 9, Comment: "terima kasih"})
+	if err != nil {
+		t.Errorf("UpsertReviewReply() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("UpsertReviewReply() unmet expectation: %v", err)
+	}
+}
+
+func Test_repository_DeleteReviewReply(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM review_replies")).
+		WithArgs(int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	r := &repository{db: db}
+	if err := r.DeleteReviewReply(context.Background(), 3); err != nil {
+		t.Errorf("DeleteReviewReply() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("DeleteReviewReply() unmet expectation: %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"github.com/alam/govtech/internal/model"
+)
+
+// GetReviewVote returns the vote of a user on a review and locks it until the
+// end of the transaction of ctx.
+func (r *repository) GetReviewVote(ctx context.Context, reviewID, userID int64) (model.ReviewVote, error) {
+	query := `
+		SELECT 
+		    review_id,
+		    user_id,
+		    helpful
+		FROM review_votes 
+		WHERE review_id = ? AND user_id = ?
+		FOR UPDATE
+`
+	var res model.ReviewVote
+	err := r.conn(ctx).QueryRowContext(ctx, query, reviewID, userID).Scan(
+		&res.ReviewID,
+		&res.UserID,
+		&res.Helpful,
+	)
+	if err != nil {
+		return model.ReviewVote{}, err
+	}
+
+	return res, nil
+}
+
+func (r *repository) InsertReviewVote(ctx context.Context, vote model.ReviewVote) error {
+	query := `
+		INSERT INTO review_votes(review_id, user_id, helpful)
+		VALUES(?, ?, ?)
+`
+	_, err := r.conn(ctx).ExecContext(ctx, query, vote.ReviewID, vote.UserID, vote.Helpful)
+	if isDuplicateEntry(err) {
+		return model.ErrDuplicateVote
+	}
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *repository) UpdateReviewVote(ctx context.Context, vote model.ReviewVote) error {
+	query := `
+		UPDATE 
+		    review_votes 
+		SET 
+		    helpful = ?
+		WHERE review_id = ? AND user_id = ?
+`
+	_, err := r.conn(ctx).ExecContext(ctx, query, vote.Helpful, vote.ReviewID, vote.UserID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AddReviewVotes applies delta to the vote counts of a review in a single
+// statement and scores it again. The score is the lower bound of the 95%
+// Wilson interval of the share of helpful votes, so a review with a few
+// helpful votes ranks below one with as good a share of many votes.
+func (r *repository) AddReviewVotes(ctx context.Context, reviewID int64, delta model.VoteDelta) error {
+	// MySQL assigns from left to right, so the score is computed from the
+	// updated counts. Votes do not change the review itself, updated_at is
+	// kept as is.
+	query := `
+		UPDATE 
+		    product_reviews 
+		SET 
+		    helpful_count = helpful_count + ?,
+		    unhelpful_count = unhelpful_count + ?,
+		    helpful_score = IF(helpful_count + unhelpful_count = 0, 0,
+		        ((helpful_count + 1.9208) / (helpful_count + unhelpful_count)
+		            - 1.96 * SQRT(helpful_count * unhelpful_count / (helpful_count + unhelpful_count) + 0.9604) / (helpful_count + unhelpful_count))
+		        / (1 + 3.8416 / (helpful_count + unhelpful_count))),
+		    updated_at = updated_at
+		WHERE id = ?
+`
+	_, err := r.conn(ctx).ExecContext(ctx, query, delta.Helpful, delta.Unhelpful, reviewID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alam/govtech/internal/model"
+	"github.com/go-sql-driver/mysql"
+	"regexp"
+	"testing"
+)
+
+func Test_repository_GetReviewVote(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(mock sqlmock.Sqlmock)
+		want    model.ReviewVote
+		wantErr error
+	}{
+		{
+			name: "vote found",
+			prepare: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("WHERE review_id = ? AND user_id = ?\n\t\tFOR UPDATE")).
+					WithArgs(int64(3), int64(7)).
+					WillReturnRows(sqlmock.NewRows([]string{"review_id", "user_id", "helpful"}).AddRow(3, 7, true))
+			},
+			want:    model.ReviewVote{ReviewID: 3, UserID: 7, Helpful: true},
+			wantErr: nil,
+		},
+		{
+			name: "no vote",
+			prepare: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("FROM review_votes")).
+					WithArgs(int64(3), int64(7)).
+					WillReturnRows(sqlmock.NewRows([]string{"review_id", "user_id", "helpful"}))
+			},
+			want:    model.ReviewVote{},
+			wantErr: sql.ErrNoRows,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to open sqlmock: %v", err)
+			}
+			defer db.Close()
+			tt.prepare(mock)
+
+			r := &repository{db: db}
+			got, err := r.GetReviewVote(context.Background(), 3, 7)
+			if err != tt.wantErr {
+				t.Errorf("GetReviewVote() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("GetReviewVote() got = %+v, want %+v", got, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("GetReviewVote() unmet expectation: %v", err)
+			}
+		})
+	}
+}
+
+func Test_repository_InsertReviewVote_duplicate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO review_votes")).
+		WithArgs(int64(3), int64(7), true).
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry '3-7' for key 'review_votes.PRIMARY'"})
+
+	r := &repository{db: db}
+	err = r.InsertReviewVote(context.Background(), model.ReviewVote{ReviewID: 3, UserID: 7, Helpful: true})
+	if err != model.ErrDuplicateVote {
+		t.Errorf("InsertReviewVote() error = %v, want %v", err, model.ErrDuplicateVote)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("InsertReviewVote() unmet expectation: %v", err)
+	}
+}
+
+func Test_repository_AddReviewVotes(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(regexp.QuoteMeta("helpful_count = helpful_count + ?,\n\t\t    unhelpful_count = unhelpful_count + ?,")).
+		WithArgs(int64(1), int64(-1), int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	r := &repository{db: db}
+	if err := r.AddReviewVotes(context.Background(), 3, model.VoteDelta{Helpful: 1, Unhelpful: -1}); err != nil {
+		t.Errorf("AddReviewVotes() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("AddReviewVotes() unmet expectation: %v", err)
+	}
+}
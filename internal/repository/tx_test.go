@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alam/govtech/internal/model"
+	"regexp"
+	"testing"
+)
+
+func Test_repository_WithinTx(t *testing.T) {
+	errAny := errors.New("any")
+
+	tests := []struct {
+		name    string
+		prepare func(mock sqlmock.Sqlmock)
+		fnErr   error
+		wantErr error
+	}{
+		{
+			name: "repositories join the transaction",
+			prepare: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO product_reviews")).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectQuery(regexp.QuoteMeta(lockActiveProduct)).
+					WithArgs(int64(4)).
+					WillReturnRows(sqlmock.NewRows(snapshotColumns).
+						AddRow("IND004", "title", "description", 1, "https://foo.bar/foo.jpg", 2, 10000, 3, 1))
+				mock.ExpectQuery(regexp.QuoteMeta("FROM categories")).
+					WillReturnRows(sqlmock.NewRows([]string{"mean", "weight"}).AddRow(3.0, 10.0))
+				mock.ExpectExec(regexp.QuoteMeta("rating_sum = rating_sum + ?")).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(regexp.QuoteMeta(lockActiveProduct)).
+					WithArgs(int64(4)).
+					WillReturnRows(sqlmock.NewRows(snapshotColumns).
+						AddRow("IND004", "title", "description", 1, "https://foo.bar/foo.jpg", 2, 10000, 4, 1))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO product_history")).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			fnErr:   nil,
+			wantErr: nil,
+		},
+		{
+			name: "rolled back when fn fails",
+			prepare: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO product_reviews")).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectQuery(regexp.QuoteMeta(lockActiveProduct)).
+					WithArgs(int64(4)).
+					WillReturnRows(sqlmock.NewRows(snapshotColumns).
+						AddRow("IND004", "title", "description", 1, "https://foo.bar/foo.jpg", 2, 10000, 3, 1))
+				mock.ExpectQuery(regexp.QuoteMeta("FROM categories")).
+					WillReturnRows(sqlmock.NewRows([]string{"mean", "weight"}).AddRow(3.0, 10.0))
+				mock.ExpectExec(regexp.QuoteMeta("rating_sum = rating_sum + ?")).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(regexp.QuoteMeta(lockActiveProduct)).
+					WithArgs(int64(4)).
+					WillReturnRows(sqlmock.NewRows(snapshotColumns).
+						AddRow("IND004", "title", "description", 1, "https://foo.bar/foo.jpg", 2, 10000, 4, 1))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO product_history")).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectRollback()
+			},
+			fnErr:   errAny,
+			wantErr: errAny,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to open sqlmock: %v", err)
+			}
+			defer db.Close()
+			tt.prepare(mock)
+
+			r := &repository{db: db}
+			err = r.WithinTx(context.Background(), func(ctx context.Context) error {
+				err := r.InsertReview(ctx, model.ProductReview{ProductID: 4, Rating: 5})
+				if err != nil {
+					return err
+				}
+				if err := r.AddProductRating(ctx, 4, model.RatingDelta{Sum: 5, Count: 1}, model.RatingPrior{Mean: 3, Weight: 10}, model.User{}); err != nil {
+					return err
+				}
+				return tt.fnErr
+			})
+			if err != tt.wantErr {
+				t.Errorf("WithinTx() error = %v, want %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("WithinTx() unmet expectation: %v", err)
+			}
+		})
+	}
+}
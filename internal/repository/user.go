@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/alam/govtech/internal/adapter"
+	"github.com/alam/govtech/internal/model"
+)
+
+func NewUserRepository(db *sql.DB) adapter.UserRepository {
+	return &repository{db: db}
+}
+
+// UpsertUser returns the id of the user with the subject of user, creating the
+// user on first sight. The stored name follows the latest non empty one.
+func (r *repository) UpsertUser(ctx context.Context, user model.User) (int64, error) {
+	// LAST_INSERT_ID(id) makes the id of an existing row the insert id.
+	query := `
+		INSERT INTO users(subject, name)
+		VALUES(?, ?)
+		ON DUPLICATE KEY UPDATE name = IF(VALUES(name) = '', name, VALUES(name)), id = LAST_INSERT_ID(id)
+`
+	res, err := r.conn(ctx).ExecContext(ctx, query, user.Subject, user.Name)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.LastInsertId()
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alam/govtech/internal/model"
+	"regexp"
+	"testing"
+)
+
+func Test_repository_UpsertUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(regexp.QuoteMeta("ON DUPLICATE KEY UPDATE name = IF(VALUES(name) = '', name, VALUES(name)), id = LAST_INSERT_ID(id)")).
+		WithArgs("u1", "Budi").
+		WillReturnResult(sqlmock.NewResult(7, 2))
+
+	r := &repository{db: db}
+	got, err := r.UpsertUser(context.Background(), model.User{Subject: "u1", Name: "Budi"})
+	if err != nil {
+		t.Fatalf("UpsertUser() error = %v", err)
+	}
+	if got != 7 {
+		t.Errorf("UpsertUser() got = %v, want %v", got, 7)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("UpsertUser() unmet expectation: %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package screener
+
+import (
+	"context"
+	"github.com/alam/govtech/internal/adapter"
+	"github.com/alam/govtech/internal/model"
+	"time"
+)
+
+// Settings of the screener returned by NewDefault.
+const (
+	// defaultDuplicateMinLength keeps short comments like "enak" from being
+	// flagged, many reviewers write them.
+	defaultDuplicateMinLength = 20
+	defaultBurstWindow        = time.Hour
+	defaultBurstLimit         = 5
+)
+
+// NewDefault returns the built-in screener. It flags profanity from
+// DefaultWords, comments copied from other reviews and users writing more
+// than 5 reviews within an hour.
+func NewDefault(reviewRepo adapter.ProductReviewRepository) adapter.ReviewScreener {
+	return Chain(
+		NewWordList(DefaultWords),
+		NewDuplicate(reviewRepo, defaultDuplicateMinLength),
+		NewBurst(reviewRepo, defaultBurstWindow, defaultBurstLimit),
+	)
+}
+
+type chain []adapter.ReviewScreener
+
+// Chain returns a screener running screeners in order. A review is flagged
+// with the reason of the first screener flagging it, the next ones are not
+// run.
+func Chain(screeners ...adapter.ReviewScreener) adapter.ReviewScreener {
+	return chain(screeners)
+}
+
+func (c chain) Screen(ctx context.Context, review model.ProductReview) (string, error) {
+	for _, screener := range c {
+		reason, err := screener.Screen(ctx, review)
+		if err != nil || reason != "" {
+			return reason, err
+		}
+	}
+	return "", nil
+}
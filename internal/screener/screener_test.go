@@ -0,0 +1,182 @@
+package screener
+
+import (
+	"context"
+	"errors"
+	"github.com/alam/govtech/internal/model"
+	"github.com/alam/govtech/mocks"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func Test_wordList_Screen(t *testing.T) {
+	s := NewWordList([]string{"Shit", "bangsat"})
+
+	tests := []struct {
+		name    string
+		comment string
+		flagged bool
+	}{
+		{
+			name:    "clean comment",
+			comment: "taste good!!!",
+			flagged: false,
+		},
+		{
+			name:    "blocked word in any case",
+			comment: "This is SHIT, do not buy",
+			flagged: true,
+		},
+		{
+			name:    "blocked word next to punctuation",
+			comment: "kurirnya bangsat!!",
+			flagged: true,
+		},
+		{
+			name:    "blocked word spelled with symbols",
+			comment: "what a pile of $h1t",
+			flagged: true,
+		},
+		{
+			name:    "blocked word inside another word",
+			comment: "shitake mushrooms are fresh",
+			flagged: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, err := s.Screen(context.Background(), model.ProductReview{Comment: tt.comment})
+			if err != nil {
+				t.Fatalf("Screen() error = %v", err)
+			}
+			if (reason != "") != tt.flagged {
+				t.Errorf("Screen() reason = %q, want flagged %v", reason, tt.flagged)
+			}
+		})
+	}
+}
+
+func Test_duplicate_Screen(t *testing.T) {
+	tests := []struct {
+		name    string
+		review  model.ProductReview
+		prepare func(reviewRepo *mocks.ProductReviewRepository)
+		flagged bool
+		wantErr bool
+	}{
+		{
+			name:    "short comment",
+			review:  model.ProductReview{Comment: "enak"},
+			prepare: func(reviewRepo *mocks.ProductReviewRepository) {},
+			flagged: false,
+		},
+		{
+			name:   "unique comment",
+			review: model.ProductReview{ID: 3, UserID: 7, Comment: "the chair arrived with a broken wheel"},
+			prepare: func(reviewRepo *mocks.ProductReviewRepository) {
+				reviewRepo.On("CountReviewsWithComment", mock.Anything, "the chair arrived with a broken wheel", int64(7)).
+					Return(int64(0), nil)
+			},
+			flagged: false,
+		},
+		{
+			name:   "copied comment",
+			review: model.ProductReview{UserID: 8, Comment: "best shop, visit my store for promo"},
+			prepare: func(reviewRepo *mocks.ProductReviewRepository) {
+				reviewRepo.On("CountReviewsWithComment", mock.Anything, "best shop, visit my store for promo", int64(8)).
+					Return(int64(4), nil)
+			},
+			flagged: true,
+		},
+		{
+			name:   "error when count reviews",
+			review: model.ProductReview{Comment: "best shop, visit my store for promo"},
+			prepare: func(reviewRepo *mocks.ProductReviewRepository) {
+				reviewRepo.On("CountReviewsWithComment", mock.Anything, mock.Anything, mock.Anything).
+					Return(int64(0), errors.New("any"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reviewRepo := new(mocks.ProductReviewRepository)
+			tt.prepare(reviewRepo)
+
+			reason, err := NewDuplicate(reviewRepo, 20).Screen(context.Background(), tt.review)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Screen() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (reason != "") != tt.flagged {
+				t.Errorf("Screen() reason = %q, want flagged %v", reason, tt.flagged)
+			}
+			reviewRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_burst_Screen(t *testing.T) {
+	now := time.Date(2023, 12, 14, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		review  model.ProductReview
+		count   int64
+		flagged bool
+	}{
+		{
+			name:    "below the limit",
+			review:  model.ProductReview{UserID: 7},
+			count:   4,
+			flagged: false,
+		},
+		{
+			name:    "at the limit",
+			review:  model.ProductReview{UserID: 7},
+			count:   5,
+			flagged: true,
+		},
+		{
+			name:    "edit at the limit",
+			review:  model.ProductReview{ID: 3, UserID: 7},
+			count:   5,
+			flagged: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reviewRepo := new(mocks.ProductReviewRepository)
+			reviewRepo.On("CountUserReviewsSince", mock.Anything, int64(7), now.Add(-time.Hour)).
+				Return(tt.count, nil).Maybe()
+
+			s := NewBurst(reviewRepo, time.Hour, 5).(*burst)
+			s.now = func() time.Time { return now }
+
+			reason, err := s.Screen(context.Background(), tt.review)
+			if err != nil {
+				t.Fatalf("Screen() error = %v", err)
+			}
+			if (reason != "") != tt.flagged {
+				t.Errorf("Screen() reason = %q, want flagged %v", reason, tt.flagged)
+			}
+		})
+	}
+}
+
+func Test_chain_Screen(t *testing.T) {
+	first := new(mocks.ReviewScreener)
+	first.On("Screen", mock.Anything, mock.Anything).Return("", nil)
+	second := new(mocks.ReviewScreener)
+	second.On("Screen", mock.Anything, mock.Anything).Return("flagged by second", nil)
+	third := new(mocks.ReviewScreener)
+
+	reason, err := Chain(first, second, third).Screen(context.Background(), model.ProductReview{})
+	if err != nil {
+		t.Fatalf("Screen() error = %v", err)
+	}
+	if reason != "flagged by second" {
+		t.Errorf("Screen() reason = %q, want %q", reason, "flagged by second")
+	}
+	third.AssertNotCalled(t, "Screen", mock.Anything, mock.Anything)
+}
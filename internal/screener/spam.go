@@ -0,0 +1,75 @@
+package screener
+
+import (
+	"context"
+	"fmt"
+	"github.com/alam/govtech/internal/adapter"
+	"github.com/alam/govtech/internal/model"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type duplicate struct {
+	reviewRepo adapter.ProductReviewRepository
+	minLength  int
+}
+
+// NewDuplicate returns a screener flagging comments already used by a review of
+// another user, rejected reviews aside. Comments shorter than minLength
+// characters are not checked.
+func NewDuplicate(reviewRepo adapter.ProductReviewRepository, minLength int) adapter.ReviewScreener {
+	return &duplicate{
+		reviewRepo: reviewRepo,
+		minLength:  minLength,
+	}
+}
+
+func (s *duplicate) Screen(ctx context.Context, review model.ProductReview) (string, error) {
+	if utf8.RuneCountInString(strings.TrimSpace(review.Comment)) < s.minLength {
+		return "", nil
+	}
+
+	count, err := s.reviewRepo.CountReviewsWithComment(ctx, review.Comment, review.UserID)
+	if err != nil {
+		return "", err
+	}
+	if count > 0 {
+		return fmt.Sprintf("comment is the same as %d other reviews", count), nil
+	}
+	return "", nil
+}
+
+type burst struct {
+	reviewRepo adapter.ProductReviewRepository
+	window     time.Duration
+	limit      int64
+	now        func() time.Time
+}
+
+// NewBurst returns a screener flagging the reviews of a user who has already
+// written limit reviews within window. Edits of existing reviews are not new
+// reviews, so they are never flagged.
+func NewBurst(reviewRepo adapter.ProductReviewRepository, window time.Duration, limit int64) adapter.ReviewScreener {
+	return &burst{
+		reviewRepo: reviewRepo,
+		window:     window,
+		limit:      limit,
+		now:        time.Now,
+	}
+}
+
+func (s *burst) Screen(ctx context.Context, review model.ProductReview) (string, error) {
+	if review.ID != 0 {
+		return "", nil
+	}
+
+	count, err := s.reviewRepo.CountUserReviewsSince(ctx, review.UserID, s.now().Add(-s.window))
+	if err != nil {
+		return "", err
+	}
+	if count >= s.limit {
+		return fmt.Sprintf("user wrote %d reviews within %v", count, s.window), nil
+	}
+	return "", nil
+}
@@ -0,0 +1,71 @@
+package screener
+
+import (
+	"context"
+	"fmt"
+	"github.com/alam/govtech/internal/adapter"
+	"github.com/alam/govtech/internal/model"
+	"strings"
+	"unicode"
+)
+
+// DefaultWords are the profanities flagged by the default screener, in
+// English and Indonesian.
+var DefaultWords = []string{
+	"asshole",
+	"bastard",
+	"bitch",
+	"cunt",
+	"fuck",
+	"shit",
+	"bajingan",
+	"bangsat",
+	"goblok",
+	"kontol",
+	"memek",
+	"tolol",
+}
+
+// leetReplacer undoes the character swaps commonly used to get profanity past
+// word filters.
+var leetReplacer = strings.NewReplacer(
+	"0", "o",
+	"1", "i",
+	"3", "e",
+	"4", "a",
+	"5", "s",
+	"7", "t",
+	"@", "a",
+	"$", "s",
+)
+
+type wordList struct {
+	words map[string]bool
+}
+
+// NewWordList returns a screener flagging comments containing one of words.
+// Words are matched whole and case insensitively.
+func NewWordList(words []string) adapter.ReviewScreener {
+	s := &wordList{
+		words: make(map[string]bool, len(words)),
+	}
+	for _, word := range words {
+		s.words[strings.ToLower(word)] = true
+	}
+	return s
+}
+
+func (s *wordList) Screen(ctx context.Context, review model.ProductReview) (string, error) {
+	comment := strings.ToLower(review.Comment)
+	for _, text := range []string{comment, leetReplacer.Replace(comment)} {
+		tokens := strings.FieldsFunc(text, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		for _, token := range tokens {
+			if s.words[token] {
+				return fmt.Sprintf("comment contains the blocked word %q", token), nil
+			}
+		}
+	}
+	return "", nil
+}
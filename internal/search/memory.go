@@ -0,0 +1,299 @@
+package search
+
+import (
+	"context"
+	"github.com/alam/govtech/internal/adapter"
+	"github.com/alam/govtech/internal/model"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Field weights, a match in the title or sku counts more than a match in the
+// description or category name.
+const (
+	titleWeight       = 3
+	skuWeight         = 3
+	categoryWeight    = 2
+	descriptionWeight = 1
+)
+
+// Score multiplier of each kind of term match.
+const (
+	exactMatch  = 1.0
+	stemMatch   = 0.9
+	prefixMatch = 0.6
+	typoMatch   = 0.4
+)
+
+// minPrefixLength is the shortest query token expanded into prefix matches.
+const minPrefixLength = 2
+
+type memorySearcher struct {
+	mu sync.RWMutex
+	// postings maps an indexed term to the weight of that term per product.
+	postings map[string]map[int64]float64
+	// terms keeps the terms indexed for each product so they can be removed
+	// when the product is indexed again.
+	terms map[int64][]string
+	// vocabulary is the sorted list of terms, used for prefix lookups.
+	vocabulary []string
+}
+
+// NewMemorySearcher returns a ProductSearcher backed by an in-process inverted
+// index. The index is not persisted and has to be filled on startup.
+func NewMemorySearcher() adapter.ProductSearcher {
+	return &memorySearcher{
+		postings: make(map[string]map[int64]float64),
+		terms:    make(map[int64][]string),
+	}
+}
+
+func (s *memorySearcher) IndexProduct(ctx context.Context, product model.Product) error {
+	weights := make(map[string]float64)
+	addTerms(weights, product.Title, titleWeight)
+	addTerms(weights, product.SKU, skuWeight)
+	addTerms(weights, product.Category.Name, categoryWeight)
+	addTerms(weights, product.Description, descriptionWeight)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.remove(product.ID)
+
+	terms := make([]string, 0, len(weights))
+	for term, weight := range weights {
+		docs, ok := s.postings[term]
+		if !ok {
+			docs = make(map[int64]float64)
+			s.postings[term] = docs
+			s.insertVocabulary(term)
+		}
+		docs[product.ID] = weight
+		terms = append(terms, term)
+	}
+	s.terms[product.ID] = terms
+
+	return nil
+}
+
+func (s *memorySearcher) RemoveProduct(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.remove(id)
+
+	return nil
+}
+
+func (s *memorySearcher) Search(ctx context.Context, query string) ([]model.SearchHit, error) {
+	// The index only keeps letters and digits, so it can neither match a
+	// query without any nor the literal % and _ the repository search
+	// supports.
+	tokens := tokenize(query)
+	if len(tokens) == 0 || strings.ContainsAny(query, "%_") {
+		return nil, model.ErrUnsupportedQuery
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	scores := make(map[int64]float64)
+	for _, token := range tokens {
+		// A product only gets the best match of every query token, so a
+		// token matching both exactly and by prefix is not counted twice.
+		best := make(map[int64]float64)
+		for term, factor := range s.match(token) {
+			for id, weight := range s.postings[term] {
+				if score := factor * weight; score > best[id] {
+					best[id] = score
+				}
+			}
+		}
+		for id, score := range best {
+			scores[id] += score
+		}
+	}
+
+	hits := make([]model.SearchHit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, model.SearchHit{
+			ProductID: id,
+			Score:     score,
+		})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ProductID < hits[j].ProductID
+	})
+
+	return hits, nil
+}
+
+// match returns the indexed terms matching a query token with their score
+// multiplier.
+func (s *memorySearcher) match(token string) map[string]float64 {
+	res := make(map[string]float64)
+	add := func(term string, factor float64) {
+		if factor > res[term] {
+			res[term] = factor
+		}
+	}
+
+	if _, ok := s.postings[token]; ok {
+		add(token, exactMatch)
+	}
+	if stemmed := stem(token); stemmed != token {
+		if _, ok := s.postings[stemmed]; ok {
+			add(stemmed, stemMatch)
+		}
+	}
+
+	if len([]rune(token)) >= minPrefixLength {
+		i := sort.SearchStrings(s.vocabulary, token)
+		for ; i < len(s.vocabulary) && strings.HasPrefix(s.vocabulary[i], token); i++ {
+			add(s.vocabulary[i], prefixMatch)
+		}
+	}
+
+	if maxDistance := typoTolerance(token); maxDistance > 0 {
+		for _, term := range s.vocabulary {
+			if withinDistance(token, term, maxDistance) {
+				add(term, typoMatch)
+			}
+		}
+	}
+
+	return res
+}
+
+// remove deletes every posting of a product. The caller must hold the lock.
+func (s *memorySearcher) remove(id int64) {
+	for _, term := range s.terms[id] {
+		docs := s.postings[term]
+		delete(docs, id)
+		if len(docs) == 0 {
+			delete(s.postings, term)
+			s.removeVocabulary(term)
+		}
+	}
+	delete(s.terms, id)
+}
+
+func (s *memorySearcher) insertVocabulary(term string) {
+	i := sort.SearchStrings(s.vocabulary, term)
+	s.vocabulary = append(s.vocabulary, "")
+	copy(s.vocabulary[i+1:], s.vocabulary[i:])
+	s.vocabulary[i] = term
+}
+
+func (s *memorySearcher) removeVocabulary(term string) {
+	i := sort.SearchStrings(s.vocabulary, term)
+	if i < len(s.vocabulary) && s.vocabulary[i] == term {
+		s.vocabulary = append(s.vocabulary[:i], s.vocabulary[i+1:]...)
+	}
+}
+
+// addTerms tokenizes text and records both every token and its stem, keeping
+// the highest weight when a term appears in several fields.
+func addTerms(weights map[string]float64, text string, weight float64) {
+	for _, token := range tokenize(text) {
+		for _, term := range []string{token, stem(token)} {
+			if weight > weights[term] {
+				weights[term] = weight
+			}
+		}
+	}
+}
+
+// tokenize splits text into lower cased words of letters and digits.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// stemSuffixes are stripped by stem, longest first. The list is deliberately
+// short: it only folds common plural and verb forms together.
+var stemSuffixes = []string{"ies", "ing", "es", "ed", "ly", "s"}
+
+// stem reduces a word to a crude stem so "chairs" and "chair" or "printing"
+// and "print" match each other. Words are never reduced below three letters.
+func stem(word string) string {
+	for _, suffix := range stemSuffixes {
+		if !strings.HasSuffix(word, suffix) {
+			continue
+		}
+		base := strings.TrimSuffix(word, suffix)
+		if len([]rune(base)) < 3 {
+			continue
+		}
+		if suffix == "ies" {
+			return base + "y"
+		}
+		return base
+	}
+	return word
+}
+
+// typoTolerance returns the edit distance allowed for a query token. Short
+// tokens must match exactly, otherwise almost everything would match. Tokens
+// with digits are codes such as a SKU, where one changed character is another
+// product rather than a typo.
+func typoTolerance(token string) int {
+	if strings.IndexFunc(token, unicode.IsDigit) >= 0 {
+		return 0
+	}
+
+	switch n := len([]rune(token)); {
+	case n >= 8:
+		return 2
+	case n >= 4:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// withinDistance reports whether the Levenshtein distance between a and b is
+// at most maxDistance.
+func withinDistance(a, b string, maxDistance int) bool {
+	ra, rb := []rune(a), []rune(b)
+	if abs(len(ra)-len(rb)) > maxDistance {
+		return false
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > maxDistance {
+			return false
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)] <= maxDistance
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
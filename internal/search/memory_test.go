@@ -0,0 +1,217 @@
+package search
+
+import (
+	"context"
+	"github.com/alam/govtech/internal/model"
+	"reflect"
+	"testing"
+)
+
+func newTestSearcher(t *testing.T) *memorySearcher {
+	s := NewMemorySearcher().(*memorySearcher)
+	products := []model.Product{
+		{
+			ID:          1,
+			SKU:         "IND001",
+			Title:       "Office Chair",
+			Description: "Ergonomic chair with lumbar support",
+			Category:    model.Category{ID: 3, Name: "Furniture"},
+		},
+		{
+			ID:          2,
+			SKU:         "IND002",
+			Title:       "Standing Desk",
+			Description: "Desk for office chairs and monitors",
+			Category:    model.Category{ID: 3, Name: "Furniture"},
+		},
+		{
+			ID:          3,
+			SKU:         "IND003",
+			Title:       "Pototo Keju",
+			Description: "Makanan ringan rasa keju",
+			Category:    model.Category{ID: 1, Name: "Food"},
+		},
+	}
+	for _, product := range products {
+		if err := s.IndexProduct(context.Background(), product); err != nil {
+			t.Fatalf("IndexProduct() error = %v", err)
+		}
+	}
+	return s
+}
+
+func hitIDs(hits []model.SearchHit) []int64 {
+	ids := make([]int64, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ProductID
+	}
+	return ids
+}
+
+func Test_memorySearcher_Search(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    []int64
+		wantErr error
+	}{
+		{
+			name:    "query without words",
+			query:   " !! ",
+			want:    []int64{},
+			wantErr: model.ErrUnsupportedQuery,
+		},
+		{
+			name:    "literal wildcard",
+			query:   "50%",
+			want:    []int64{},
+			wantErr: model.ErrUnsupportedQuery,
+		},
+		{
+			name:  "exact match ranks title above description",
+			query: "chair",
+			want:  []int64{1, 2},
+		},
+		{
+			name:  "stemmed plural",
+			query: "desks",
+			want:  []int64{2},
+		},
+		{
+			name:  "prefix",
+			query: "pot",
+			want:  []int64{3},
+		},
+		{
+			name:  "typo",
+			query: "furnitur",
+			want:  []int64{1, 2},
+		},
+		{
+			name:  "two typos in a long word",
+			query: "ergonmic",
+			want:  []int64{1},
+		},
+		{
+			name:  "short tokens need an exact or prefix match",
+			query: "kej",
+			want:  []int64{3},
+		},
+		{
+			name:  "sku",
+			query: "ind003",
+			want:  []int64{3},
+		},
+		{
+			name:  "case and punctuation are ignored",
+			query: "KEJU!!!",
+			want:  []int64{3},
+		},
+		{
+			name:  "no match",
+			query: "laptop",
+			want:  []int64{},
+		},
+	}
+	s := newTestSearcher(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Search(context.Background(), tt.query)
+			if err != tt.wantErr {
+				t.Errorf("Search() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if ids := hitIDs(got); !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("Search() = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func Test_memorySearcher_IndexProduct_reindex(t *testing.T) {
+	s := newTestSearcher(t)
+
+	err := s.IndexProduct(context.Background(), model.Product{
+		ID:       3,
+		SKU:      "IND003",
+		Title:    "Kerupuk Udang",
+		Category: model.Category{ID: 1, Name: "Food"},
+	})
+	if err != nil {
+		t.Fatalf("IndexProduct() error = %v", err)
+	}
+
+	got, _ := s.Search(context.Background(), "keju")
+	if ids := hitIDs(got); len(ids) != 0 {
+		t.Errorf("Search() old term = %v, want no hit", ids)
+	}
+	got, _ = s.Search(context.Background(), "udang")
+	if ids := hitIDs(got); !reflect.DeepEqual(ids, []int64{3}) {
+		t.Errorf("Search() new term = %v, want %v", ids, []int64{3})
+	}
+	for _, term := range s.vocabulary {
+		if term == "keju" {
+			t.Errorf("vocabulary still contains removed term %q", term)
+		}
+	}
+}
+
+func Test_memorySearcher_RemoveProduct(t *testing.T) {
+	s := newTestSearcher(t)
+
+	if err := s.RemoveProduct(context.Background(), 1); err != nil {
+		t.Fatalf("RemoveProduct() error = %v", err)
+	}
+
+	got, _ := s.Search(context.Background(), "chair")
+	if ids := hitIDs(got); !reflect.DeepEqual(ids, []int64{2}) {
+		t.Errorf("Search() = %v, want %v", ids, []int64{2})
+	}
+	got, _ = s.Search(context.Background(), "ergonomic")
+	if ids := hitIDs(got); len(ids) != 0 {
+		t.Errorf("Search() term of removed product = %v, want no hit", ids)
+	}
+}
+
+func Test_stem(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{word: "chairs", want: "chair"},
+		{word: "batteries", want: "battery"},
+		{word: "printing", want: "print"},
+		{word: "boxes", want: "box"},
+		{word: "bus", want: "bus"},
+		{word: "keju", want: "keju"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			if got := stem(tt.word); got != tt.want {
+				t.Errorf("stem() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_withinDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		max  int
+		want bool
+	}{
+		{a: "chair", b: "chair", max: 0, want: true},
+		{a: "chiar", b: "chair", max: 1, want: false},
+		{a: "chiar", b: "chair", max: 2, want: true},
+		{a: "chai", b: "chair", max: 1, want: true},
+		{a: "desk", b: "chair", max: 2, want: false},
+		{a: "kursi", b: "kursì", max: 1, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+"_"+tt.b, func(t *testing.T) {
+			if got := withinDistance(tt.a, tt.b, tt.max); got != tt.want {
+				t.Errorf("withinDistance() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
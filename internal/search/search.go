@@ -0,0 +1,32 @@
+package search
+
+import (
+	"context"
+	"github.com/alam/govtech/internal/adapter"
+	"github.com/alam/govtech/internal/model"
+)
+
+const indexBatchSize = 500
+
+// IndexAll indexes every product stored in productRepo into searcher.
+func IndexAll(ctx context.Context, productRepo adapter.ProductRepository, searcher adapter.ProductSearcher) error {
+	for offset := int64(0); ; offset += indexBatchSize {
+		products, err := productRepo.GetProductList(ctx, model.GetProductListFilter{
+			Limit:  indexBatchSize,
+			Offset: offset,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, product := range products {
+			if err := searcher.IndexProduct(ctx, product); err != nil {
+				return err
+			}
+		}
+
+		if len(products) < indexBatchSize {
+			return nil
+		}
+	}
+}
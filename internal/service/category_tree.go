@@ -0,0 +1,78 @@
+package service
+
+import (
+	"github.com/alam/govtech/internal/api"
+	"github.com/alam/govtech/internal/model"
+)
+
+// categoryTree is the hierarchy of every category. Categories are few, so the
+// tree is read whole instead of walking it with a query per level.
+type categoryTree struct {
+	byID     map[int64]model.Category
+	children map[int64][]int64
+	roots    []int64
+}
+
+func newCategoryTree(categories []model.Category) categoryTree {
+	tree := categoryTree{
+		byID:     make(map[int64]model.Category, len(categories)),
+		children: map[int64][]int64{},
+	}
+	for _, category := range categories {
+		tree.byID[category.ID] = category
+	}
+	for _, category := range categories {
+		if _, ok := tree.byID[category.ParentID]; ok {
+			tree.children[category.ParentID] = append(tree.children[category.ParentID], category.ID)
+		} else {
+			tree.roots = append(tree.roots, category.ID)
+		}
+	}
+	return tree
+}
+
+// path returns the categories from the root down to the category id, empty
+// when it does not exist.
+func (t categoryTree) path(id int64) []model.Category {
+	var res []model.Category
+	for category, ok := t.byID[id]; ok && len(res) < len(t.byID); category, ok = t.byID[category.ParentID] {
+		res = append([]model.Category{category}, res...)
+	}
+	return res
+}
+
+// subtree returns the category id followed by all of its subcategories, at any
+// depth. An unknown id is returned alone.
+func (t categoryTree) subtree(id int64) []int64 {
+	res := []int64{id}
+	// The length check only stops a cycle, which re-parenting prevents.
+	for i := 0; i < len(res) && len(res) <= len(t.byID); i++ {
+		res = append(res, t.children[res[i]]...)
+	}
+	return res
+}
+
+func (t categoryTree) toAPI(ids []int64) []api.CategoryTree {
+	res := make([]api.CategoryTree, 0, len(ids))
+	for _, id := range ids {
+		category := t.byID[id]
+		res = append(res, api.CategoryTree{
+			ID:       category.ID,
+			Name:     category.Name,
+			Children: t.toAPI(t.children[id]),
+		})
+	}
+	return res
+}
+
+// apiPath returns the breadcrumb of the category id.
+func (t categoryTree) apiPath(id int64) []api.Category {
+	var res []api.Category
+	for _, category := range t.path(id) {
+		res = append(res, api.Category{
+			ID:   category.ID,
+			Name: category.Name,
+		})
+	}
+	return res
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"errors"
+	"github.com/alam/govtech/internal/api"
+	"github.com/alam/govtech/internal/model"
+	"github.com/alam/govtech/internal/util/cursorhelper"
+	"time"
+)
+
+// productCursor is the content of a product list cursor token. The sort and
+// search it was issued for are kept so a cursor cannot be replayed against a
+// different ordering.
+type productCursor struct {
+	Search     string      `json:"q,omitempty"`
+	SortColumn string      `json:"s,omitempty"`
+	SortType   string      `json:"t,omitempty"`
+	Value      interface{} `json:"v,omitempty"`
+	ID         int64       `json:"i"`
+}
+
+func (s *service) encodeProductCursor(filter api.GetProductListFilter, product model.Product, position int) (string, error) {
+	cursor := productCursor{
+		Search:     filter.Search,
+		SortColumn: filter.SortColumn,
+		SortType:   filter.SortType,
+		ID:         product.ID,
+	}
+
+	switch filter.SortColumn {
+	case "created_at":
+		cursor.Value = product.CreatedAt.Format(time.RFC3339Nano)
+	case "rating":
+		cursor.Value = float64(product.Rating)
+	case "score":
+		cursor.Value = product.RatingScore
+	case "relevance":
+		// Searcher results are ordered by their position in the hit list,
+		// database results by the relevance computed by MySQL.
+		if position > 0 {
+			cursor.Value = position
+		} else {
+			cursor.Value = product.Relevance
+		}
+	}
+
+	return cursorhelper.Encode(s.cursorKey, cursor)
+}
+
+func (s *service) decodeProductCursor(filter api.GetProductListFilter) (*model.ProductCursor, error) {
+	var cursor productCursor
+	if err := cursorhelper.Decode(s.cursorKey, filter.Cursor, &cursor); err != nil {
+		return nil, err
+	}
+	if cursor.Search != filter.Search || cursor.SortColumn != filter.SortColumn || cursor.SortType != filter.SortType {
+		return nil, errors.New("cursor was issued for another search or sort")
+	}
+
+	res := &model.ProductCursor{ID: cursor.ID}
+	switch filter.SortColumn {
+	case "created_at":
+		str, ok := cursor.Value.(string)
+		if !ok {
+			return nil, errors.New("invalid cursor value")
+		}
+		createdAt, err := time.Parse(time.RFC3339Nano, str)
+		if err != nil {
+			return nil, errors.New("invalid cursor value")
+		}
+		res.Value = createdAt
+	case "rating", "score", "relevance":
+		value, ok := cursor.Value.(float64)
+		if !ok {
+			return nil, errors.New("invalid cursor value")
+		}
+		res.Value = value
+	}
+
+	return res, nil
+}
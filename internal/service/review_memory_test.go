@@ -0,0 +1,458 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"github.com/alam/govtech/internal/adapter"
+	"github.com/alam/govtech/internal/api"
+	"github.com/alam/govtech/internal/model"
+	"github.com/alam/govtech/internal/util/authhelper"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// memoryStore keeps products and reviews in memory. Every method is atomic,
+// like a single SQL statement.
+type memoryStore struct {
+	mu       sync.Mutex
+	products map[int64]*memoryProduct
+	reviews  []model.ProductReview
+	users    map[string]int64
+
+	lastReviewID int64
+}
+
+type memoryProduct struct {
+	product     model.Product
+	ratingSum   int64
+	ratingCount int64
+}
+
+type memoryProductRepo struct {
+	adapter.ProductRepository
+	store *memoryStore
+}
+
+func (r memoryProductRepo) GetProduct(ctx context.Context, id int64) (model.Product, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	p, ok := r.store.products[id]
+	if !ok {
+		return model.Product{}, sql.ErrNoRows
+	}
+	return p.product, nil
+}
+
+func (r memoryProductRepo) AddProductRating(ctx context.Context, id int64, delta model.RatingDelta, prior model.RatingPrior, actor model.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	p, ok := r.store.products[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	p.ratingSum += delta.Sum
+	p.ratingCount += delta.Count
+	p.product.Rating = 0
+	if p.ratingCount > 0 {
+		p.product.Rating = float32(float64(p.ratingSum) / float64(p.ratingCount))
+	}
+	p.product.RatingScore = (prior.Weight*prior.Mean + float64(p.ratingSum)) / (prior.Weight + float64(p.ratingCount))
+	return nil
+}
+
+type memoryReviewRepo struct {
+	adapter.ProductReviewRepository
+	store *memoryStore
+}
+
+func (r memoryReviewRepo) GetUserReview(ctx context.Context, productID, userID int64) (model.ProductReview, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, review := range r.store.reviews {
+		if review.ProductID == productID && review.UserID == userID {
+			return review, nil
+		}
+	}
+	return model.ProductReview{}, sql.ErrNoRows
+}
+
+func (r memoryReviewRepo) InsertReview(ctx context.Context, review model.ProductReview) error {
+	// Let other reviews run between the insert and the rating update.
+	runtime.Gosched()
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.lastReviewID++
+	review.ID = r.store.lastReviewID
+	r.store.reviews = append(r.store.reviews, review)
+	return nil
+}
+
+func (r memoryReviewRepo) GetReview(ctx context.Context, productID, id int64) (model.ProductReview, error) {
+	review, err := r.GetReviewByID(ctx, id)
+	if err != nil || review.ProductID != productID {
+		return model.ProductReview{}, sql.ErrNoRows
+	}
+	return review, nil
+}
+
+func (r memoryReviewRepo) GetReviewByID(ctx context.Context, id int64) (model.ProductReview, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, review := range r.store.reviews {
+		if review.ID == id {
+			for subject, userID := range r.store.users {
+				if userID == review.UserID {
+					review.User = model.User{ID: userID, Subject: subject}
+				}
+			}
+			return review, nil
+		}
+	}
+	return model.ProductReview{}, sql.ErrNoRows
+}
+
+func (r memoryReviewRepo) UpdateReview(ctx context.Context, review model.ProductReview) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for i := range r.store.reviews {
+		if r.store.reviews[i].ID == review.ID {
+			r.store.reviews[i].Rating = review.Rating
+			r.store.reviews[i].Comment = review.Comment
+			r.store.reviews[i].Status = review.Status
+			r.store.reviews[i].FlagReason = review.FlagReason
+		}
+	}
+	return nil
+}
+
+func (r memoryReviewRepo) DeleteReview(ctx context.Context, id int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for i := range r.store.reviews {
+		if r.store.reviews[i].ID == id {
+			r.store.reviews = append(r.store.reviews[:i], r.store.reviews[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+type memoryUserRepo struct {
+	store *memoryStore
+}
+
+func (r memoryUserRepo) UpsertUser(ctx context.Context, user model.User) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	id, ok := r.store.users[user.Subject]
+	if !ok {
+		id = int64(len(r.store.users) + 1)
+		r.store.users[user.Subject] = id
+	}
+	return id, nil
+}
+
+// memoryScreener holds the reviews mentioning spam for moderation.
+type memoryScreener struct{}
+
+func (memoryScreener) Screen(ctx context.Context, review model.ProductReview) (string, error) {
+	if strings.Contains(review.Comment, "spam") {
+		return "spam", nil
+	}
+	return "", nil
+}
+
+// memoryTxManager runs the unit of work without isolation, the rating must stay
+// exact without relying on it.
+type memoryTxManager struct{}
+
+func (memoryTxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func Test_service_ReviewProduct_concurrent(t *testing.T) {
+	const reviews = 500
+
+	store := &memoryStore{
+		products: map[int64]*memoryProduct{
+			4: {product: model.Product{ID: 4, SKU: "IND004"}},
+		},
+		users: map[string]int64{},
+	}
+	s := &service{
+		productRepo: memoryProductRepo{store: store},
+		reviewRepo:  memoryReviewRepo{store: store},
+		userRepo:    memoryUserRepo{store: store},
+		txManager:   memoryTxManager{},
+		screener:    memoryScreener{},
+		ratingPrior: model.RatingPrior{Mean: 3, Weight: 10},
+	}
+
+	var wantSum int64
+	var wg sync.WaitGroup
+	errs := make(chan error, reviews)
+	for i := 0; i < reviews; i++ {
+		rating := int32(i%5 + 1)
+		wantSum += int64(rating)
+		ctx := authhelper.WithActor(context.Background(), authhelper.Actor{ID: "u" + strconv.Itoa(i)})
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.ReviewProduct(ctx, 4, api.ReviewProductRequest{
+				Rating:  rating,
+				Comment: "comment",
+			})
+			if err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("ReviewProduct() error = %v", err)
+	}
+
+	p := store.products[4]
+	if len(store.reviews) != reviews {
+		t.Errorf("ReviewProduct() stored %v reviews, want %v", len(store.reviews), reviews)
+	}
+	if p.ratingCount != reviews || p.ratingSum != wantSum {
+		t.Errorf("ReviewProduct() rating sum, count = %v, %v, want %v, %v", p.ratingSum, p.ratingCount, wantSum, reviews)
+	}
+	if want := float32(float64(wantSum) / reviews); p.product.Rating != want {
+		t.Errorf("ReviewProduct() rating = %v, want %v", p.product.Rating, want)
+	}
+	if want := (10*3 + float64(wantSum)) / (10 + reviews); p.product.RatingScore != want {
+		t.Errorf("ReviewProduct() rating score = %v, want %v", p.product.RatingScore, want)
+	}
+}
+
+func Test_service_DeleteReview_lastReview(t *testing.T) {
+	store := &memoryStore{
+		products: map[int64]*memoryProduct{
+			4: {product: model.Product{ID: 4, SKU: "IND004"}},
+		},
+		users: map[string]int64{},
+	}
+	s := &service{
+		productRepo: memoryProductRepo{store: store},
+		reviewRepo:  memoryReviewRepo{store: store},
+		userRepo:    memoryUserRepo{store: store},
+		txManager:   memoryTxManager{},
+		screener:    memoryScreener{},
+		ratingPrior: model.RatingPrior{Mean: 3, Weight: 10},
+	}
+	budi := authhelper.WithActor(context.Background(), authhelper.Actor{ID: "u1"})
+	siti := authhelper.WithActor(context.Background(), authhelper.Actor{ID: "u2"})
+
+	steps := []struct {
+		name       string
+		run        func() (api.MutationResponse, error)
+		wantRating float32
+		wantScore  float64
+	}{
+		{
+			name: "budi reviews",
+			run: func() (api.MutationResponse, error) {
+				return s.ReviewProduct(budi, 4, api.ReviewProductRequest{Rating: 5, Comment: "good"})
+			},
+			wantRating: 5,
+			wantScore:  (10*3 + 5) / 11.0,
+		},
+		{
+			name: "siti reviews",
+			run: func() (api.MutationResponse, error) {
+				return s.ReviewProduct(siti, 4, api.ReviewProductRequest{Rating: 2, Comment: "bad"})
+			},
+			wantRating: 3.5,
+			wantScore:  (10*3 + 7) / 12.0,
+		},
+		{
+			name: "budi edits",
+			run: func() (api.MutationResponse, error) {
+				return s.UpdateReview(budi, 4, 1, api.ReviewProductRequest{Rating: 4, Comment: "fine"})
+			},
+			wantRating: 3,
+			wantScore:  (10*3 + 6) / 12.0,
+		},
+		{
+			name: "siti deletes",
+			run: func() (api.MutationResponse, error) {
+				return s.DeleteReview(siti, 4, 2)
+			},
+			wantRating: 4,
+			wantScore:  (10*3 + 4) / 11.0,
+		},
+		{
+			name: "budi deletes the last review",
+			run: func() (api.MutationResponse, error) {
+				return s.DeleteReview(budi, 4, 1)
+			},
+			wantRating: 0,
+			wantScore:  3,
+		},
+	}
+	for _, step := range steps {
+		if _, err := step.run(); err != nil {
+			t.Fatalf("%v: error = %v", step.name, err)
+		}
+		p := store.products[4]
+		if p.product.Rating != step.wantRating {
+			t.Errorf("%v: rating = %v, want %v", step.name, p.product.Rating, step.wantRating)
+		}
+		if p.product.RatingScore != step.wantScore {
+			t.Errorf("%v: rating score = %v, want %v", step.name, p.product.RatingScore, step.wantScore)
+		}
+	}
+
+	p := store.products[4]
+	if len(store.reviews) != 0 || p.ratingSum != 0 || p.ratingCount != 0 {
+		t.Errorf("DeleteReview() left %v reviews, rating sum, count = %v, %v", len(store.reviews), p.ratingSum, p.ratingCount)
+	}
+}
+
+func Test_service_ModerateReview_rating(t *testing.T) {
+	store := &memoryStore{
+		products: map[int64]*memoryProduct{
+			4: {product: model.Product{ID: 4, SKU: "IND004"}},
+		},
+		users: map[string]int64{},
+	}
+	s := &service{
+		productRepo: memoryProductRepo{store: store},
+		reviewRepo:  memoryReviewRepo{store: store},
+		userRepo:    memoryUserRepo{store: store},
+		txManager:   memoryTxManager{},
+		screener:    memoryScreener{},
+		ratingPrior: model.RatingPrior{Mean: 3, Weight: 10},
+	}
+	budi := authhelper.WithActor(context.Background(), authhelper.Actor{ID: "u1"})
+	siti := authhelper.WithActor(context.Background(), authhelper.Actor{ID: "u2"})
+	admin := authhelper.WithActor(context.Background(), authhelper.Actor{ID: "a1", Roles: []string{authhelper.RoleAdmin}})
+
+	steps := []struct {
+		name       string
+		run        func() (api.MutationResponse, error)
+		wantStatus []string
+		wantRating float32
+	}{
+		{
+			name: "budi reviews",
+			run: func() (api.MutationResponse, error) {
+				return s.ReviewProduct(budi, 4, api.ReviewProductRequest{Rating: 4, Comment: "good"})
+			},
+			wantStatus: []string{model.ReviewStatusApproved},
+			wantRating: 4,
+		},
+		{
+			name: "siti spams",
+			run: func() (api.MutationResponse, error) {
+				return s.ReviewProduct(siti, 4, api.ReviewProductRequest{Rating: 1, Comment: "spam spam"})
+			},
+			wantStatus: []string{model.ReviewStatusApproved, model.ReviewStatusPending},
+			wantRating: 4,
+		},
+		{
+			name: "siti edits the pending review",
+			run: func() (api.MutationResponse, error) {
+				return s.UpdateReview(siti, 4, 2, api.ReviewProductRequest{Rating: 1, Comment: "bad"})
+			},
+			wantStatus: []string{model.ReviewStatusApproved, model.ReviewStatusPending},
+			wantRating: 4,
+		},
+		{
+			name: "admin approves",
+			run: func() (api.MutationResponse, error) {
+				return s.ModerateReview(admin, 2, api.ModerateReviewRequest{Status: model.ReviewStatusApproved})
+			},
+			wantStatus: []string{model.ReviewStatusApproved, model.ReviewStatusApproved},
+			wantRating: 2.5,
+		},
+		{
+			name: "budi edits into spam",
+			run: func() (api.MutationResponse, error) {
+				return s.UpdateReview(budi, 4, 1, api.ReviewProductRequest{Rating: 5, Comment: "spam"})
+			},
+			wantStatus: []string{model.ReviewStatusPending, model.ReviewStatusApproved},
+			wantRating: 1,
+		},
+		{
+			name: "admin rejects",
+			run: func() (api.MutationResponse, error) {
+				return s.ModerateReview(admin, 1, api.ModerateReviewRequest{Status: model.ReviewStatusRejected})
+			},
+			wantStatus: []string{model.ReviewStatusRejected, model.ReviewStatusApproved},
+			wantRating: 1,
+		},
+		{
+			name: "budi edits the rejected review",
+			run: func() (api.MutationResponse, error) {
+				return s.UpdateReview(budi, 4, 1, api.ReviewProductRequest{Rating: 5, Comment: "great"})
+			},
+			wantStatus: []string{model.ReviewStatusPending, model.ReviewStatusApproved},
+			wantRating: 1,
+		},
+		{
+			name: "admin rejects again",
+			run: func() (api.MutationResponse, error) {
+				return s.ModerateReview(admin, 1, api.ModerateReviewRequest{Status: model.ReviewStatusRejected})
+			},
+			wantStatus: []string{model.ReviewStatusRejected, model.ReviewStatusApproved},
+			wantRating: 1,
+		},
+		{
+			name: "budi reviews again",
+			run: func() (api.MutationResponse, error) {
+				return s.ReviewProduct(budi, 4, api.ReviewProductRequest{Rating: 5, Comment: "really great"})
+			},
+			wantStatus: []string{model.ReviewStatusPending, model.ReviewStatusApproved},
+			wantRating: 1,
+		},
+		{
+			name: "admin rejects the resubmission",
+			run: func() (api.MutationResponse, error) {
+				return s.ModerateReview(admin, 1, api.ModerateReviewRequest{Status: model.ReviewStatusRejected})
+			},
+			wantStatus: []string{model.ReviewStatusRejected, model.ReviewStatusApproved},
+			wantRating: 1,
+		},
+		{
+			name: "budi deletes the rejected review",
+			run: func() (api.MutationResponse, error) {
+				return s.DeleteReview(budi, 4, 1)
+			},
+			wantStatus: []string{model.ReviewStatusApproved},
+			wantRating: 1,
+		},
+	}
+	for _, step := range steps {
+		if _, err := step.run(); err != nil {
+			t.Fatalf("%v: error = %v", step.name, err)
+		}
+		var status []string
+		for _, review := range store.reviews {
+			status = append(status, review.Status)
+		}
+		if !reflect.DeepEqual(status, step.wantStatus) {
+			t.Errorf("%v: review status = %v, want %v", step.name, status, step.wantStatus)
+		}
+		if got := store.products[4].product.Rating; got != step.wantRating {
+			t.Errorf("%v: rating = %v, want %v", step.name, got, step.wantRating)
+		}
+	}
+}
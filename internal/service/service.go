@@ -3,38 +3,100 @@ package service
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"github.com/alam/govtech/internal/adapter"
 	"github.com/alam/govtech/internal/api"
 	"github.com/alam/govtech/internal/model"
+	"github.com/alam/govtech/internal/util/authhelper"
 	"github.com/alam/govtech/internal/util/errorhelper"
-	"math/rand"
+	"log"
 	"net/http"
+	"strings"
 	"time"
 )
 
 type Service interface {
 	CreateProduct(ctx context.Context, req api.Product) (api.MutationResponse, error)
 	UpdateProduct(ctx context.Context, id int64, req api.Product) (api.MutationResponse, error)
-	GetProduct(ctx context.Context, id int64) (api.Product, error)
-	GetProductList(ctx context.Context, filter api.GetProductListFilter) ([]api.Product, error)
+	PatchProduct(ctx context.Context, id int64, patch api.ProductPatch) (api.MutationResponse, error)
+	GetProduct(ctx context.Context, id int64, filter api.GetProductFilter) (api.Product, error)
+	GetProductList(ctx context.Context, filter api.GetProductListFilter) (api.ProductListResponse, error)
 	ReviewProduct(ctx context.Context, productID int64, req api.ReviewProductRequest) (api.MutationResponse, error)
+	UpdateReview(ctx context.Context, productID, reviewID int64, req api.ReviewProductRequest) (api.MutationResponse, error)
+	DeleteReview(ctx context.Context, productID, reviewID int64) (api.MutationResponse, error)
+	VoteReview(ctx context.Context, productID, reviewID int64, req api.VoteReviewRequest) (api.MutationResponse, error)
+	ReplyReview(ctx context.Context, productID, reviewID int64, req api.ReplyReviewRequest) (api.MutationResponse, error)
+	DeleteReviewReply(ctx context.Context, productID, reviewID int64) (api.MutationResponse, error)
+	GetPendingReviews(ctx context.Context, filter api.GetPendingReviewListFilter) (api.PendingReviewListResponse, error)
+	ModerateReview(ctx context.Context, reviewID int64, req api.ModerateReviewRequest) (api.MutationResponse, error)
+	DeleteProduct(ctx context.Context, id int64) (api.MutationResponse, error)
+	RestoreProduct(ctx context.Context, id int64) (api.MutationResponse, error)
+	GetProductHistory(ctx context.Context, productID int64, filter api.PageFilter) (api.ProductHistoryResponse, error)
+	GetProductPrices(ctx context.Context, productID int64, filter api.PageFilter) (api.ProductPriceResponse, error)
+	GetProductReviews(ctx context.Context, productID int64, filter api.GetReviewListFilter) (api.ProductReviewListResponse, error)
+	GetCategoryList(ctx context.Context) (api.CategoryListResponse, error)
+	GetCategoryTree(ctx context.Context) (api.CategoryTreeResponse, error)
+	GetCategory(ctx context.Context, id int64) (api.Category, error)
+	GetCategoryBreadcrumbs(ctx context.Context, id int64) (api.CategoryListResponse, error)
+	CreateCategory(ctx context.Context, req api.Category) (api.MutationResponse, error)
+	UpdateCategory(ctx context.Context, id int64, req api.Category) (api.MutationResponse, error)
+	DeleteCategory(ctx context.Context, id int64, req api.DeleteCategoryRequest) (api.MutationResponse, error)
 }
 
+// maxSearchHits caps the number of searcher results hydrated from the
+// repository for a single product list request. The other filters only apply
+// to the kept results, so the response is marked as truncated when some are
+// dropped.
+const maxSearchHits = 1000
+
+// reindexBatchSize is the number of products read at once when the products
+// of a category are indexed again.
+const reindexBatchSize = 500
+
 type service struct {
 	productRepo  adapter.ProductRepository
 	categoryRepo adapter.CategoryRepository
 	reviewRepo   adapter.ProductReviewRepository
+	historyRepo  adapter.ProductHistoryRepository
+	priceRepo    adapter.ProductPriceRepository
+	userRepo     adapter.UserRepository
+	txManager    adapter.TxManager
+	searcher     adapter.ProductSearcher
+	screener     adapter.ReviewScreener
+	cursorKey    []byte
+	ratingPrior  model.RatingPrior
 }
 
+// NewService creates the product service. The searcher is optional, when it is
+// nil text queries are handled by the product repository. The screener decides
+// which reviews are held for moderation. cursorKey signs the product list
+// cursors. ratingPrior scores the products of categories without
+// a prior of their own.
 func NewService(
 	productRepo adapter.ProductRepository,
 	categoryRepo adapter.CategoryRepository,
 	reviewRepo adapter.ProductReviewRepository,
+	historyRepo adapter.ProductHistoryRepository,
+	priceRepo adapter.ProductPriceRepository,
+	userRepo adapter.UserRepository,
+	txManager adapter.TxManager,
+	searcher adapter.ProductSearcher,
+	screener adapter.ReviewScreener,
+	cursorKey []byte,
+	ratingPrior model.RatingPrior,
 ) Service {
 	return &service{
 		productRepo:  productRepo,
 		categoryRepo: categoryRepo,
 		reviewRepo:   reviewRepo,
+		historyRepo:  historyRepo,
+		priceRepo:    priceRepo,
+		userRepo:     userRepo,
+		txManager:    txManager,
+		searcher:     searcher,
+		screener:     screener,
+		cursorKey:    cursorKey,
+		ratingPrior:  ratingPrior,
 	}
 }
 
@@ -43,7 +105,7 @@ func (s *service) CreateProduct(ctx context.Context, req api.Product) (api.Mutat
 		return api.MutationResponse{}, errorhelper.WrapWithCode(err, "invalid request payload", http.StatusBadRequest)
 	}
 
-	_, err := s.categoryRepo.GetCategory(ctx, req.Category.ID)
+	category, err := s.categoryRepo.GetCategory(ctx, req.Category.ID)
 	if err != nil && err != sql.ErrNoRows {
 		return api.MutationResponse{}, errorhelper.WrapWithCode(err, "error when get category", http.StatusInternalServerError)
 	}
@@ -51,15 +113,7 @@ func (s *service) CreateProduct(ctx context.Context, req api.Product) (api.Mutat
 		return api.MutationResponse{}, errorhelper.NewWithCode("category not found", http.StatusBadRequest)
 	}
 
-	_, err = s.productRepo.GetProductBySKU(ctx, req.SKU)
-	if err != nil && err != sql.ErrNoRows {
-		return api.MutationResponse{}, errorhelper.WrapWithCode(err, "error when get product by sku", http.StatusInternalServerError)
-	}
-	if err == nil {
-		return api.MutationResponse{}, errorhelper.NewWithCode("sku already exist", http.StatusBadRequest)
-	}
-
-	err = s.productRepo.InsertProduct(ctx, model.Product{
+	product := model.Product{
 		SKU:         req.SKU,
 		Title:       req.Title,
 		Description: req.Description,
@@ -71,11 +125,47 @@ func (s *service) CreateProduct(ctx context.Context, req api.Product) (api.Mutat
 		Price:     req.Price,
 		Rating:    0,
 		CreatedAt: time.Now(),
+	}
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		_, err := s.productRepo.GetProductBySKU(ctx, req.SKU)
+		if err != nil && err != sql.ErrNoRows {
+			return errorhelper.WrapWithCode(err, "error when get product by sku", http.StatusInternalServerError)
+		}
+		if err == nil {
+			return errorhelper.NewWithCode("sku already exist", http.StatusBadRequest)
+		}
+
+		// The user listing the product is its vendor.
+		actor := authhelper.ActorFromContext(ctx)
+		if !actor.IsAnonymous() {
+			product.VendorID, err = s.userRepo.UpsertUser(ctx, model.User{
+				Subject: actor.ID,
+				Name:    actor.Name,
+			})
+			if err != nil {
+				return errorhelper.WrapWithCode(err, "error when save user", http.StatusInternalServerError)
+			}
+		}
+
+		product.ID, err = s.productRepo.InsertProduct(ctx, product, historyActor(ctx))
+		if err == model.ErrDuplicateSKU {
+			// The sku is not visible to GetProductBySKU when it belongs to
+			// a deleted product or a product inserted concurrently.
+			return errorhelper.NewWithCode("sku already exist", http.StatusBadRequest)
+		}
+		if err != nil {
+			return errorhelper.WrapWithCode(err, "error when insert product", http.StatusInternalServerError)
+		}
+
+		return nil
 	})
 	if err != nil {
-		return api.MutationResponse{}, errorhelper.WrapWithCode(err, "error when insert product", http.StatusInternalServerError)
+		return api.MutationResponse{}, txError(err)
 	}
 
+	product.Category = category
+	s.indexProduct(ctx, product)
+
 	return api.MutationResponse{
 		Success: true,
 	}, nil
@@ -90,7 +180,11 @@ func (s *service) UpdateProduct(ctx context.Context, id int64, req api.Product)
 		return api.MutationResponse{}, errorhelper.WrapWithCode(err, "invalid request payload", http.StatusBadRequest)
 	}
 
-	_, err := s.categoryRepo.GetCategory(ctx, req.Category.ID)
+	if req.Version == api.VersionMissing {
+		return api.MutationResponse{}, errorhelper.NewWithCode("If-Match header is required", http.StatusPreconditionRequired)
+	}
+
+	category, err := s.categoryRepo.GetCategory(ctx, req.Category.ID)
 	if err != nil && err != sql.ErrNoRows {
 		return api.MutationResponse{}, errorhelper.WrapWithCode(err, "error when get category", http.StatusInternalServerError)
 	}
@@ -98,36 +192,189 @@ func (s *service) UpdateProduct(ctx context.Context, id int64, req api.Product)
 		return api.MutationResponse{}, errorhelper.NewWithCode("category not found", http.StatusBadRequest)
 	}
 
-	err = s.productRepo.UpdateProduct(ctx, id, model.Product{
-		SKU:         req.SKU,
-		Title:       req.Title,
-		Description: req.Description,
-		Category: model.Category{
-			ID: req.Category.ID,
-		},
-	})
+	product, err := s.productRepo.GetProduct(ctx, id)
+	if err != nil && err != sql.ErrNoRows {
+		return api.MutationResponse{}, errorhelper.WrapWithCode(err, "error when get product", http.StatusInternalServerError)
+	}
+	if err == sql.ErrNoRows {
+		return api.MutationResponse{}, errorhelper.NewWithCode("product not found", http.StatusNotFound)
+	}
+	if req.Version != api.VersionAny && product.Version != req.Version {
+		return api.MutationResponse{}, errorhelper.NewWithCode("product has been modified", http.StatusPreconditionFailed)
+	}
+
+	if req.SKU != product.SKU {
+		_, err := s.productRepo.GetProductBySKU(ctx, req.SKU)
+		if err != nil && err != sql.ErrNoRows {
+			return api.MutationResponse{}, errorhelper.WrapWithCode(err, "error when get product by sku", http.StatusInternalServerError)
+		}
+		if err == nil {
+			return api.MutationResponse{}, errorhelper.NewWithCode("sku already exist", http.StatusBadRequest)
+		}
+	}
+
+	product.SKU = req.SKU
+	product.Title = req.Title
+	product.Description = req.Description
+	product.Category = category
+	product.ImageURL = req.ImageURL
+	product.Weight = req.Weight
+	product.Price = req.Price
+
+	return s.saveProduct(ctx, product)
+}
+
+func (s *service) PatchProduct(ctx context.Context, id int64, patch api.ProductPatch) (api.MutationResponse, error) {
+	if id <= 0 {
+		return api.MutationResponse{}, errorhelper.NewWithCode("invalid id", http.StatusBadRequest)
+	}
+
+	if err := patch.Validate(); err != nil {
+		return api.MutationResponse{}, errorhelper.WrapWithCode(err, "invalid request payload", http.StatusBadRequest)
+	}
+
+	if patch.Version == api.VersionMissing {
+		return api.MutationResponse{}, errorhelper.NewWithCode("If-Match header is required", http.StatusPreconditionRequired)
+	}
+
+	product, err := s.productRepo.GetProduct(ctx, id)
+	if err != nil && err != sql.ErrNoRows {
+		return api.MutationResponse{}, errorhelper.WrapWithCode(err, "error when get product", http.StatusInternalServerError)
+	}
+	if err == sql.ErrNoRows {
+		return api.MutationResponse{}, errorhelper.NewWithCode("product not found", http.StatusNotFound)
+	}
+	if patch.Version != api.VersionAny && product.Version != patch.Version {
+		return api.MutationResponse{}, errorhelper.NewWithCode("product has been modified", http.StatusPreconditionFailed)
+	}
+
+	if patch.Category != nil && patch.Category.ID != product.Category.ID {
+		category, err := s.categoryRepo.GetCategory(ctx, patch.Category.ID)
+		if err != nil && err != sql.ErrNoRows {
+			return api.MutationResponse{}, errorhelper.WrapWithCode(err, "error when get category", http.StatusInternalServerError)
+		}
+		if err == sql.ErrNoRows {
+			return api.MutationResponse{}, errorhelper.NewWithCode("category not found", http.StatusBadRequest)
+		}
+		product.Category = category
+	}
+
+	if patch.SKU != nil && *patch.SKU != product.SKU {
+		_, err := s.productRepo.GetProductBySKU(ctx, *patch.SKU)
+		if err != nil && err != sql.ErrNoRows {
+			return api.MutationResponse{}, errorhelper.WrapWithCode(err, "error when get product by sku", http.StatusInternalServerError)
+		}
+		if err == nil {
+			return api.MutationResponse{}, errorhelper.NewWithCode("sku already exist", http.StatusBadRequest)
+		}
+		product.SKU = *patch.SKU
+	}
+	if patch.Title != nil {
+		product.Title = *patch.Title
+	}
+	if patch.Description != nil {
+		product.Description = *patch.Description
+	}
+	if patch.ImageURL != nil {
+		product.ImageURL = *patch.ImageURL
+	}
+	if patch.Weight != nil {
+		product.Weight = *patch.Weight
+	}
+	if patch.Price != nil {
+		product.Price = *patch.Price
+	}
+
+	return s.saveProduct(ctx, product)
+}
+
+// saveProduct writes every mutable field of an existing product if it is still
+// at product.Version, and keeps the searcher in sync.
+func (s *service) saveProduct(ctx context.Context, product model.Product) (api.MutationResponse, error) {
+	err := s.productRepo.UpdateProduct(ctx, product.ID, product, historyActor(ctx))
+	if err == model.ErrDuplicateSKU {
+		return api.MutationResponse{}, errorhelper.NewWithCode("sku already exist", http.StatusBadRequest)
+	}
+	if err == model.ErrVersionConflict {
+		return api.MutationResponse{}, errorhelper.NewWithCode("product has been modified", http.StatusPreconditionFailed)
+	}
 	if err != nil && err != sql.ErrNoRows {
 		return api.MutationResponse{}, errorhelper.WrapWithCode(err, "error when update product", http.StatusInternalServerError)
 	}
+	// The product can still be deleted between reading and updating it.
+	if err == sql.ErrNoRows {
+		return api.MutationResponse{}, errorhelper.NewWithCode("product not found", http.StatusNotFound)
+	}
+
+	s.indexProduct(ctx, product)
 
 	return api.MutationResponse{
 		Success: true,
 	}, nil
 }
 
-func (s *service) GetProduct(ctx context.Context, id int64) (api.Product, error) {
+func (s *service) GetProduct(ctx context.Context, id int64, filter api.GetProductFilter) (api.Product, error) {
 	if id <= 0 {
 		return api.Product{}, errorhelper.NewWithCode("invalid id", http.StatusBadRequest)
 	}
 
-	product, err := s.productRepo.GetProduct(ctx, id)
-	if err != nil && err != sql.ErrNoRows {
-		return api.Product{}, errorhelper.WrapWithCode(err, "error when get product", http.StatusInternalServerError)
+	asOf, err := filter.AsOfTime()
+	if err != nil {
+		return api.Product{}, errorhelper.WrapWithCode(err, "invalid request", http.StatusBadRequest)
 	}
-	if err == sql.ErrNoRows {
-		return api.Product{}, errorhelper.NewWithCode("product not found", http.StatusNotFound)
+
+	// The product and its review statistic are read from the same snapshot,
+	// so the distribution always adds up to the stored rating.
+	var product model.Product
+	var stat model.Statistic
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		product, err = s.productRepo.GetProduct(ctx, id)
+		if err != nil && err != sql.ErrNoRows {
+			return errorhelper.WrapWithCode(err, "error when get product", http.StatusInternalServerError)
+		}
+		if err == sql.ErrNoRows {
+			return errorhelper.NewWithCode("product not found", http.StatusNotFound)
+		}
+
+		stat, err = s.reviewRepo.GetReviewStatistic(ctx, id)
+		if err != nil {
+			return errorhelper.WrapWithCode(err, "error when get statistic review", http.StatusInternalServerError)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return api.Product{}, txError(err)
+	}
+
+	tree, err := s.getCategoryTree(ctx)
+	if err != nil {
+		return api.Product{}, err
+	}
+
+	res := toAPIProduct(product)
+	res.Category.Path = tree.apiPath(product.Category.ID)
+	res.ReviewCount = &stat.Count
+	res.RatingDistribution = make(map[int32]int64, len(stat.Distribution))
+	for i, count := range stat.Distribution {
+		res.RatingDistribution[int32(i+1)] = count
+	}
+	if !asOf.IsZero() {
+		price, err := s.priceRepo.GetProductPriceAt(ctx, id, asOf)
+		if err != nil && err != sql.ErrNoRows {
+			return api.Product{}, errorhelper.WrapWithCode(err, "error when get product price", http.StatusInternalServerError)
+		}
+		if err == sql.ErrNoRows {
+			return api.Product{}, errorhelper.NewWithCode("product has no price at as_of", http.StatusNotFound)
+		}
+		res.Price = price.Price
+		res.AsOf = &asOf
 	}
 
+	return res, nil
+}
+
+func toAPIProduct(product model.Product) api.Product {
 	return api.Product{
 		ID:          product.ID,
 		SKU:         product.SKU,
@@ -137,34 +384,157 @@ func (s *service) GetProduct(ctx context.Context, id int64) (api.Product, error)
 			ID:   product.Category.ID,
 			Name: product.Category.Name,
 		},
-		ImageURL: product.ImageURL,
-		Weight:   product.Weight,
-		Price:    product.Price,
-		Rating:   product.Rating,
-	}, nil
+		ImageURL:    product.ImageURL,
+		Weight:      product.Weight,
+		Price:       product.Price,
+		Rating:      product.Rating,
+		RatingScore: product.RatingScore,
+		Version:     product.Version,
+		UpdatedAt:   product.UpdatedAt,
+	}
 }
 
-func (s *service) GetProductList(ctx context.Context, filter api.GetProductListFilter) ([]api.Product, error) {
+func (s *service) GetProductList(ctx context.Context, filter api.GetProductListFilter) (api.ProductListResponse, error) {
 	if err := filter.Validate(); err != nil {
-		return nil, errorhelper.WrapWithCode(err, "invalid request", http.StatusBadRequest)
+		return api.ProductListResponse{}, errorhelper.WrapWithCode(err, "invalid request", http.StatusBadRequest)
 	}
 
-	products, err := s.productRepo.GetProductList(ctx, model.GetProductListFilter{
-		Search:     filter.Search,
-		CategoryID: filter.CategoryID,
-		SortColumn: filter.SortColumn,
-		SortType:   filter.SortType,
-		Limit:      filter.Size,
-		Offset:     (filter.Page - 1) * filter.Size,
-	})
+	productFilter := model.GetProductListFilter{
+		Search:      filter.Search,
+		CategoryIDs: filter.CategoryIDs,
+		MinPrice:    filter.MinPrice,
+		MaxPrice:    filter.MaxPrice,
+		MinWeight:   filter.MinWeight,
+		MaxWeight:   filter.MaxWeight,
+		MinRating:   filter.MinRating,
+		SortColumn:  filter.SortColumn,
+		SortType:    filter.SortType,
+		Limit:       filter.Size + 1,
+		Offset:      (filter.Page - 1) * filter.Size,
+	}
+
+	var tree categoryTree
+	if filter.IncludeSubcategories && len(filter.CategoryIDs) > 0 {
+		var err error
+		tree, err = s.getCategoryTree(ctx)
+		if err != nil {
+			return api.ProductListResponse{}, err
+		}
+		productFilter.CategoryIDs = nil
+		for _, id := range filter.CategoryIDs {
+			productFilter.CategoryIDs = append(productFilter.CategoryIDs, tree.subtree(id)...)
+		}
+	}
+
+	if filter.Cursor != "" {
+		after, err := s.decodeProductCursor(filter)
+		if err != nil {
+			return api.ProductListResponse{}, errorhelper.WrapWithCode(err, "invalid cursor", http.StatusBadRequest)
+		}
+		productFilter.After = after
+		productFilter.Offset = 0
+	}
+
+	var hits []model.SearchHit
+	var searched bool
+	if filter.Search != "" && s.searcher != nil {
+		var err error
+		hits, err = s.searcher.Search(ctx, filter.Search)
+		if err != nil && err != model.ErrUnsupportedQuery {
+			return api.ProductListResponse{}, errorhelper.WrapWithCode(err, "error when search product", http.StatusInternalServerError)
+		}
+		// Queries the searcher can not answer are left to the repository.
+		searched = err == nil
+	}
+
+	var scores map[int64]float64
+	var positions map[int64]int
+	var truncated bool
+	if searched {
+		if len(hits) == 0 {
+			res := api.ProductListResponse{
+				Items:      []api.Product{},
+				Pagination: newPagination(filter.Page, filter.Size, 0, filter.URL),
+			}
+			if filter.Cursor != "" {
+				res.Pagination = api.Pagination{Size: filter.Size}
+			}
+			return res, nil
+		}
+		if len(hits) > maxSearchHits {
+			hits = hits[:maxSearchHits]
+			truncated = true
+		}
+
+		scores = make(map[int64]float64, len(hits))
+		positions = make(map[int64]int, len(hits))
+		for i, hit := range hits {
+			productFilter.IDs = append(productFilter.IDs, hit.ProductID)
+			scores[hit.ProductID] = hit.Score
+			positions[hit.ProductID] = i + 1
+		}
+		productFilter.Search = ""
+	}
+
+	products, err := s.productRepo.GetProductList(ctx, productFilter)
+	if err != nil {
+		return api.ProductListResponse{}, errorhelper.WrapWithCode(err, "error when get product list", http.StatusInternalServerError)
+	}
+	if scores != nil {
+		for i := range products {
+			products[i].Relevance = scores[products[i].ID]
+		}
+	}
+
+	// One more product than the page size is fetched to know whether a next
+	// page exists.
+	var nextCursor string
+	if int64(len(products)) > filter.Size {
+		products = products[:filter.Size]
+		last := products[len(products)-1]
+		nextCursor, err = s.encodeProductCursor(filter, last, positions[last.ID])
+		if err != nil {
+			return api.ProductListResponse{}, errorhelper.WrapWithCode(err, "error when encode cursor", http.StatusInternalServerError)
+		}
+	}
+
+	total, err := s.productRepo.CountProductList(ctx, productFilter)
+	if err != nil {
+		return api.ProductListResponse{}, errorhelper.WrapWithCode(err, "error when count product list", http.StatusInternalServerError)
+	}
+
+	facets, err := s.productRepo.GetProductFacets(ctx, productFilter)
 	if err != nil {
-		return nil, errorhelper.WrapWithCode(err, "error when get product list", http.StatusInternalServerError)
+		return api.ProductListResponse{}, errorhelper.WrapWithCode(err, "error when get product facets", http.StatusInternalServerError)
+	}
+
+	if len(products) > 0 && tree.byID == nil {
+		tree, err = s.getCategoryTree(ctx)
+		if err != nil {
+			return api.ProductListResponse{}, err
+		}
 	}
 
-	res := make([]api.Product, len(products))
+	res := api.ProductListResponse{
+		Items:      make([]api.Product, len(products)),
+		Pagination: newPagination(filter.Page, filter.Size, total, filter.URL),
+		Truncated:  truncated,
+	}
+	if filter.Cursor != "" {
+		// Cursor pages have no number, they can only move forward.
+		res.Pagination = api.Pagination{
+			Size:       filter.Size,
+			TotalItems: total,
+			TotalPages: res.Pagination.TotalPages,
+		}
+		if nextCursor != "" {
+			res.Pagination.Next = filter.CursorURL(nextCursor)
+		}
+	}
+	res.Pagination.NextCursor = nextCursor
 
 	for i, v := range products {
-		res[i] = api.Product{
+		res.Items[i] = api.Product{
 			ID:          v.ID,
 			SKU:         v.SKU,
 			Title:       v.Title,
@@ -172,14 +542,43 @@ func (s *service) GetProductList(ctx context.Context, filter api.GetProductListF
 			Category: api.Category{
 				ID:   v.Category.ID,
 				Name: v.Category.Name,
+				Path: tree.apiPath(v.Category.ID),
 			},
-			ImageURL: v.ImageURL,
-			Weight:   v.Weight,
-			Price:    v.Price,
-			Rating:   v.Rating,
+			ImageURL:    v.ImageURL,
+			Weight:      v.Weight,
+			Price:       v.Price,
+			Rating:      v.Rating,
+			RatingScore: v.RatingScore,
+			Relevance:   v.Relevance,
 		}
 	}
 
+	for _, v := range facets.Categories {
+		res.Facets.Categories = append(res.Facets.Categories, api.CategoryFacet{
+			ID:    v.Category.ID,
+			Name:  v.Category.Name,
+			Count: v.Count,
+		})
+	}
+	for _, v := range facets.Prices {
+		facet := api.PriceFacet{
+			Min:   v.Min,
+			Count: v.Count,
+		}
+		if v.Max > 0 {
+			upper := v.Max
+			facet.Max = &upper
+		}
+		res.Facets.Prices = append(res.Facets.Prices, facet)
+	}
+	for _, v := range facets.Ratings {
+		res.Facets.Ratings = append(res.Facets.Ratings, api.RatingFacet{
+			Min:   v.Min,
+			Max:   v.Max,
+			Count: v.Count,
+		})
+	}
+
 	return res, nil
 }
 
@@ -192,38 +591,1163 @@ func (s *service) ReviewProduct(ctx context.Context, productID int64, req api.Re
 		return api.MutationResponse{}, errorhelper.WrapWithCode(err, "invalid request", http.StatusBadRequest)
 	}
 
-	_, err := s.productRepo.GetProduct(ctx, productID)
-	if err != nil && err != sql.ErrNoRows {
-		return api.MutationResponse{}, errorhelper.WrapWithCode(err, "error when get product", http.StatusInternalServerError)
+	actor := authhelper.ActorFromContext(ctx)
+	if actor.IsAnonymous() {
+		return api.MutationResponse{}, errorhelper.NewWithCode("reviewer is not authenticated", http.StatusUnauthorized)
 	}
-	if err == sql.ErrNoRows {
-		return api.MutationResponse{}, errorhelper.NewWithCode("product not found", http.StatusNotFound)
+
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		_, err := s.productRepo.GetProduct(ctx, productID)
+		if err != nil && err != sql.ErrNoRows {
+			return errorhelper.WrapWithCode(err, "error when get product", http.StatusInternalServerError)
+		}
+		if err == sql.ErrNoRows {
+			return errorhelper.NewWithCode("product not found", http.StatusNotFound)
+		}
+
+		userID, err := s.userRepo.UpsertUser(ctx, model.User{
+			Subject: actor.ID,
+			Name:    actor.Name,
+		})
+		if err != nil {
+			return errorhelper.WrapWithCode(err, "error when save user", http.StatusInternalServerError)
+		}
+
+		review := model.ProductReview{
+			UserID:    userID,
+			ProductID: productID,
+			Rating:    req.Rating,
+			Comment:   req.Comment,
+		}
+
+		// A user has one review per product, reviewing again replaces it.
+		prev, err := s.reviewRepo.GetUserReview(ctx, productID, userID)
+		if err != nil && err != sql.ErrNoRows {
+			return errorhelper.WrapWithCode(err, "error when get user review", http.StatusInternalServerError)
+		}
+		if err == sql.ErrNoRows {
+			prev = model.ProductReview{}
+		}
+		review.ID = prev.ID
+
+		err = s.screenReview(ctx, prev, &review)
+		if err != nil {
+			return err
+		}
+
+		if review.ID == 0 {
+			err = s.reviewRepo.InsertReview(ctx, review)
+			if err == model.ErrDuplicateReview {
+				// Inserted by a concurrent request of the same user.
+				return errorhelper.NewWithCode("review is already being submitted", http.StatusConflict)
+			}
+			if err != nil {
+				return errorhelper.WrapWithCode(err, "error when insert review", http.StatusInternalServerError)
+			}
+		} else {
+			err = s.reviewRepo.UpdateReview(ctx, review)
+			if err != nil {
+				return errorhelper.WrapWithCode(err, "error when update review", http.StatusInternalServerError)
+			}
+		}
+
+		return s.addProductRating(ctx, productID, ratingChange(prev, review))
+	})
+	if err != nil {
+		return api.MutationResponse{}, txError(err)
 	}
 
-	stat, err := s.reviewRepo.GetReviewStatistic(ctx, productID)
-	if err != nil && err != sql.ErrNoRows {
-		return api.MutationResponse{}, errorhelper.WrapWithCode(err, "error when get statistic review", http.StatusInternalServerError)
+	return api.MutationResponse{
+		Success: true,
+	}, nil
+
+}
+
+func (s *service) UpdateReview(ctx context.Context, productID, reviewID int64, req api.ReviewProductRequest) (api.MutationResponse, error) {
+	if productID <= 0 || reviewID <= 0 {
+		return api.MutationResponse{}, errorhelper.NewWithCode("invalid id", http.StatusBadRequest)
 	}
 
-	err = s.reviewRepo.InsertReview(ctx, model.ProductReview{
-		UserID:    int64(rand.Int() % 777777),
-		ProductID: productID,
-		Rating:    req.Rating,
-		Comment:   req.Comment,
+	if err := req.Validate(); err != nil {
+		return api.MutationResponse{}, errorhelper.WrapWithCode(err, "invalid request", http.StatusBadRequest)
+	}
+
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		review, err := s.getOwnReview(ctx, productID, reviewID)
+		if err != nil {
+			return err
+		}
+
+		prev := review
+		review.Rating = req.Rating
+		review.Comment = req.Comment
+		err = s.screenReview(ctx, prev, &review)
+		if err != nil {
+			return err
+		}
+
+		err = s.reviewRepo.UpdateReview(ctx, review)
+		if err != nil {
+			return errorhelper.WrapWithCode(err, "error when update review", http.StatusInternalServerError)
+		}
+
+		return s.addProductRating(ctx, productID, ratingChange(prev, review))
+	})
+	if err != nil {
+		return api.MutationResponse{}, txError(err)
+	}
+
+	return api.MutationResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *service) DeleteReview(ctx context.Context, productID, reviewID int64) (api.MutationResponse, error) {
+	if productID <= 0 || reviewID <= 0 {
+		return api.MutationResponse{}, errorhelper.NewWithCode("invalid id", http.StatusBadRequest)
+	}
+
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		review, err := s.getOwnReview(ctx, productID, reviewID)
+		if err != nil {
+			return err
+		}
+
+		err = s.reviewRepo.DeleteReview(ctx, review.ID)
+		if err != nil {
+			return errorhelper.WrapWithCode(err, "error when delete review", http.StatusInternalServerError)
+		}
+
+		return s.addProductRating(ctx, productID, ratingChange(review, model.ProductReview{}))
 	})
 	if err != nil {
-		return api.MutationResponse{}, errorhelper.WrapWithCode(err, "error when insert review", http.StatusInternalServerError)
+		return api.MutationResponse{}, txError(err)
+	}
+
+	return api.MutationResponse{
+		Success: true,
+	}, nil
+}
+
+// VoteReview records whether the actor of ctx found a published review
+// helpful. A user has one vote per review, voting again replaces it.
+func (s *service) VoteReview(ctx context.Context, productID, reviewID int64, req api.VoteReviewRequest) (api.MutationResponse, error) {
+	if productID <= 0 || reviewID <= 0 {
+		return api.MutationResponse{}, errorhelper.NewWithCode("invalid id", http.StatusBadRequest)
+	}
+
+	if err := req.Validate(); err != nil {
+		return api.MutationResponse{}, errorhelper.WrapWithCode(err, "invalid request", http.StatusBadRequest)
+	}
+
+	actor := authhelper.ActorFromContext(ctx)
+	if actor.IsAnonymous() {
+		return api.MutationResponse{}, errorhelper.NewWithCode("voter is not authenticated", http.StatusUnauthorized)
 	}
 
-	rating := ((float64(stat.Count) * stat.Average) + float64(req.Rating)) / float64(stat.Count+1)
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		_, err := s.productRepo.GetProduct(ctx, productID)
+		if err != nil && err != sql.ErrNoRows {
+			return errorhelper.WrapWithCode(err, "error when get product", http.StatusInternalServerError)
+		}
+		if err == sql.ErrNoRows {
+			return errorhelper.NewWithCode("product not found", http.StatusNotFound)
+		}
+
+		review, err := s.reviewRepo.GetReview(ctx, productID, reviewID)
+		if err != nil && err != sql.ErrNoRows {
+			return errorhelper.WrapWithCode(err, "error when get review", http.StatusInternalServerError)
+		}
+		if err == sql.ErrNoRows || review.Status != model.ReviewStatusApproved {
+			return errorhelper.NewWithCode("review not found", http.StatusNotFound)
+		}
+		if review.User.Subject == actor.ID {
+			return errorhelper.NewWithCode("reviewer can not vote on their own review", http.StatusForbidden)
+		}
+
+		userID, err := s.userRepo.UpsertUser(ctx, model.User{
+			Subject: actor.ID,
+			Name:    actor.Name,
+		})
+		if err != nil {
+			return errorhelper.WrapWithCode(err, "error when save user", http.StatusInternalServerError)
+		}
+
+		vote := model.ReviewVote{
+			ReviewID: reviewID,
+			UserID:   userID,
+			Helpful:  *req.Helpful,
+		}
+
+		prev, err := s.reviewRepo.GetReviewVote(ctx, reviewID, userID)
+		if err != nil && err != sql.ErrNoRows {
+			return errorhelper.WrapWithCode(err, "error when get review vote", http.StatusInternalServerError)
+		}
+		if err == nil && prev.Helpful == vote.Helpful {
+			return nil
+		}
+		if err == sql.ErrNoRows {
+			err = s.reviewRepo.InsertReviewVote(ctx, vote)
+			if err == model.ErrDuplicateVote {
+				// Inserted by a concurrent request of the same user.
+				return errorhelper.NewWithCode("vote is already being submitted", http.StatusConflict)
+			}
+			if err != nil {
+				return errorhelper.WrapWithCode(err, "error when insert review vote", http.StatusInternalServerError)
+			}
+		} else {
+			err = s.reviewRepo.UpdateReviewVote(ctx, vote)
+			if err != nil {
+				return errorhelper.WrapWithCode(err, "error when update review vote", http.StatusInternalServerError)
+			}
+		}
+
+		err = s.reviewRepo.AddReviewVotes(ctx, reviewID, voteChange(prev, vote))
+		if err != nil {
+			return errorhelper.WrapWithCode(err, "error when update review votes", http.StatusInternalServerError)
+		}
 
-	err = s.productRepo.UpdateProductRating(ctx, productID, rating)
+		return nil
+	})
 	if err != nil {
-		return api.MutationResponse{}, errorhelper.WrapWithCode(err, "error when update product rating", http.StatusInternalServerError)
+		return api.MutationResponse{}, txError(err)
 	}
 
 	return api.MutationResponse{
 		Success: true,
 	}, nil
+}
+
+// ReplyReview saves the public reply of the vendor of a product to one of its
+// published reviews. A review has one reply, replying again edits it.
+func (s *service) ReplyReview(ctx context.Context, productID, reviewID int64, req api.ReplyReviewRequest) (api.MutationResponse, error) {
+	if productID <= 0 || reviewID <= 0 {
+		return api.MutationResponse{}, errorhelper.NewWithCode("invalid id", http.StatusBadRequest)
+	}
+
+	if err := req.Validate(); err != nil {
+		return api.MutationResponse{}, errorhelper.WrapWithCode(err, "invalid request", http.StatusBadRequest)
+	}
+
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		vendorID, err := s.checkReviewVendor(ctx, productID, reviewID)
+		if err != nil {
+			return err
+		}
 
+		err = s.reviewRepo.UpsertReviewReply(ctx, model.ReviewReply{
+			ReviewID: reviewID,
+			VendorID: vendorID,
+			Comment:  req.Comment,
+		})
+		if err != nil {
+			return errorhelper.WrapWithCode(err, "error when save review reply", http.StatusInternalServerError)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return api.MutationResponse{}, txError(err)
+	}
+
+	return api.MutationResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *service) DeleteReviewReply(ctx context.Context, productID, reviewID int64) (api.MutationResponse, error) {
+	if productID <= 0 || reviewID <= 0 {
+		return api.MutationResponse{}, errorhelper.NewWithCode("invalid id", http.StatusBadRequest)
+	}
+
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		_, err := s.checkReviewVendor(ctx, productID, reviewID)
+		if err != nil {
+			return err
+		}
+
+		err = s.reviewRepo.DeleteReviewReply(ctx, reviewID)
+		if err != nil {
+			return errorhelper.WrapWithCode(err, "error when delete review reply", http.StatusInternalServerError)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return api.MutationResponse{}, txError(err)
+	}
+
+	return api.MutationResponse{
+		Success: true,
+	}, nil
+}
+
+// checkReviewVendor returns the user id of the actor of ctx when they are the
+// vendor of the product of a published review.
+func (s *service) checkReviewVendor(ctx context.Context, productID, reviewID int64) (int64, error) {
+	actor := authhelper.ActorFromContext(ctx)
+	if actor.IsAnonymous() {
+		return 0, errorhelper.NewWithCode("vendor is not authenticated", http.StatusUnauthorized)
+	}
+
+	product, err := s.productRepo.GetProduct(ctx, productID)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, errorhelper.WrapWithCode(err, "error when get product", http.StatusInternalServerError)
+	}
+	if err == sql.ErrNoRows {
+		return 0, errorhelper.NewWithCode("product not found", http.StatusNotFound)
+	}
+
+	review, err := s.reviewRepo.GetReview(ctx, productID, reviewID)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, errorhelper.WrapWithCode(err, "error when get review", http.StatusInternalServerError)
+	}
+	if err == sql.ErrNoRows || review.Status != model.ReviewStatusApproved {
+		return 0, errorhelper.NewWithCode("review not found", http.StatusNotFound)
+	}
+
+	userID, err := s.userRepo.UpsertUser(ctx, model.User{
+		Subject: actor.ID,
+		Name:    actor.Name,
+	})
+	if err != nil {
+		return 0, errorhelper.WrapWithCode(err, "error when save user", http.StatusInternalServerError)
+	}
+	if product.VendorID == 0 || product.VendorID != userID {
+		return 0, errorhelper.NewWithCode("only the vendor of the product can reply", http.StatusForbidden)
+	}
+
+	return userID, nil
+}
+
+// voteChange returns the change to the vote counts of a review when a vote
+// goes from before to after. A zero before stands for no vote.
+func voteChange(before, after model.ReviewVote) model.VoteDelta {
+	var delta model.VoteDelta
+	if before.UserID != 0 {
+		if before.Helpful {
+			delta.Helpful--
+		} else {
+			delta.Unhelpful--
+		}
+	}
+	if after.Helpful {
+		delta.Helpful++
+	} else {
+		delta.Unhelpful++
+	}
+	return delta
+}
+
+// getOwnReview returns a review of a product written by the actor of ctx and
+// locks it until the end of the transaction of ctx.
+func (s *service) getOwnReview(ctx context.Context, productID, reviewID int64) (model.ProductReview, error) {
+	actor := authhelper.ActorFromContext(ctx)
+	if actor.IsAnonymous() {
+		return model.ProductReview{}, errorhelper.NewWithCode("reviewer is not authenticated", http.StatusUnauthorized)
+	}
+
+	_, err := s.productRepo.GetProduct(ctx, productID)
+	if err != nil && err != sql.ErrNoRows {
+		return model.ProductReview{}, errorhelper.WrapWithCode(err, "error when get product", http.StatusInternalServerError)
+	}
+	if err == sql.ErrNoRows {
+		return model.ProductReview{}, errorhelper.NewWithCode("product not found", http.StatusNotFound)
+	}
+
+	review, err := s.reviewRepo.GetReview(ctx, productID, reviewID)
+	if err != nil && err != sql.ErrNoRows {
+		return model.ProductReview{}, errorhelper.WrapWithCode(err, "error when get review", http.StatusInternalServerError)
+	}
+	if err == sql.ErrNoRows {
+		return model.ProductReview{}, errorhelper.NewWithCode("review not found", http.StatusNotFound)
+	}
+	if review.User.Subject != actor.ID {
+		return model.ProductReview{}, errorhelper.NewWithCode("review belongs to another user", http.StatusForbidden)
+	}
+
+	return review, nil
+}
+
+// GetPendingReviews lists the reviews held for moderation, oldest first.
+func (s *service) GetPendingReviews(ctx context.Context, filter api.GetPendingReviewListFilter) (api.PendingReviewListResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return api.PendingReviewListResponse{}, err
+	}
+
+	if err := filter.Validate(); err != nil {
+		return api.PendingReviewListResponse{}, errorhelper.WrapWithCode(err, "invalid request", http.StatusBadRequest)
+	}
+
+	reviewFilter := model.GetReviewListFilter{
+		Status: model.ReviewStatusPending,
+		Sort:   "oldest",
+		Limit:  filter.Size,
+		Offset: (filter.Page - 1) * filter.Size,
+	}
+
+	total, err := s.reviewRepo.CountReviewList(ctx, reviewFilter)
+	if err != nil {
+		return api.PendingReviewListResponse{}, errorhelper.WrapWithCode(err, "error when count pending reviews", http.StatusInternalServerError)
+	}
+
+	reviews, err := s.reviewRepo.GetReviewList(ctx, reviewFilter)
+	if err != nil {
+		return api.PendingReviewListResponse{}, errorhelper.WrapWithCode(err, "error when get pending reviews", http.StatusInternalServerError)
+	}
+
+	res := api.PendingReviewListResponse{
+		Items: make([]api.PendingReview, len(reviews)),
+		Pagination: newPagination(filter.Page, filter.Size, total, func(page int64) string {
+			return filter.URL("/admin/reviews/pending", page)
+		}),
+	}
+	for i, v := range reviews {
+		res.Items[i] = api.PendingReview{
+			ProductReview: toAPIReview(v),
+			ProductID:     v.ProductID,
+			FlagReason:    v.FlagReason,
+		}
+	}
+
+	return res, nil
+}
+
+// ModerateReview approves or rejects a pending review. An approved review is
+// published and counted in the rating of its product.
+func (s *service) ModerateReview(ctx context.Context, reviewID int64, req api.ModerateReviewRequest) (api.MutationResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return api.MutationResponse{}, err
+	}
+
+	if reviewID <= 0 {
+		return api.MutationResponse{}, errorhelper.NewWithCode("invalid id", http.StatusBadRequest)
+	}
+
+	if err := req.Validate(); err != nil {
+		return api.MutationResponse{}, errorhelper.WrapWithCode(err, "invalid request", http.StatusBadRequest)
+	}
+
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		review, err := s.reviewRepo.GetReviewByID(ctx, reviewID)
+		if err != nil && err != sql.ErrNoRows {
+			return errorhelper.WrapWithCode(err, "error when get review", http.StatusInternalServerError)
+		}
+		if err == sql.ErrNoRows {
+			return errorhelper.NewWithCode("review not found", http.StatusNotFound)
+		}
+		if review.Status != model.ReviewStatusPending {
+			return errorhelper.NewWithCode("review is already moderated", http.StatusConflict)
+		}
+
+		_, err = s.productRepo.GetProduct(ctx, review.ProductID)
+		if err != nil && err != sql.ErrNoRows {
+			return errorhelper.WrapWithCode(err, "error when get product", http.StatusInternalServerError)
+		}
+		if err == sql.ErrNoRows {
+			return errorhelper.NewWithCode("product not found", http.StatusNotFound)
+		}
+
+		prev := review
+		review.Status = req.Status
+		err = s.reviewRepo.UpdateReview(ctx, review)
+		if err != nil {
+			return errorhelper.WrapWithCode(err, "error when update review", http.StatusInternalServerError)
+		}
+
+		return s.addProductRating(ctx, review.ProductID, ratingChange(prev, review))
+	})
+	if err != nil {
+		return api.MutationResponse{}, txError(err)
+	}
+
+	return api.MutationResponse{
+		Success: true,
+	}, nil
+}
+
+// historyActor returns the user of ctx to record as the author of a product
+// change, the zero user when the request is anonymous.
+func historyActor(ctx context.Context) model.User {
+	actor := authhelper.ActorFromContext(ctx)
+	return model.User{
+		Subject: actor.ID,
+		Name:    actor.Name,
+	}
+}
+
+// requireAdmin returns an error unless the actor of ctx is an admin.
+func requireAdmin(ctx context.Context) error {
+	actor := authhelper.ActorFromContext(ctx)
+	if actor.IsAnonymous() {
+		return errorhelper.NewWithCode("user is not authenticated", http.StatusUnauthorized)
+	}
+	if !actor.HasRole(authhelper.RoleAdmin) {
+		return errorhelper.NewWithCode("user is not an admin", http.StatusForbidden)
+	}
+	return nil
+}
+
+// screenReview sets the status of a new or edited review, prev is the review
+// it replaces, if any. Reviews flagged by the screener are held for
+// moderation. An edit of a pending or rejected review stays held with its
+// previous flag, only an admin can make it count.
+func (s *service) screenReview(ctx context.Context, prev model.ProductReview, review *model.ProductReview) error {
+	reason, err := s.screener.Screen(ctx, *review)
+	if err != nil {
+		return errorhelper.WrapWithCode(err, "error when screen review", http.StatusInternalServerError)
+	}
+
+	review.Status = model.ReviewStatusApproved
+	review.FlagReason = reason
+	if prev.Status == model.ReviewStatusPending || prev.Status == model.ReviewStatusRejected {
+		review.Status = model.ReviewStatusPending
+		if reason == "" {
+			review.FlagReason = prev.FlagReason
+		}
+	}
+	if reason != "" {
+		review.Status = model.ReviewStatusPending
+	}
+	return nil
+}
+
+// ratingChange returns the change to the rating of a product when one of its
+// reviews goes from before to after. A zero review stands for no review, and
+// only approved reviews count.
+func ratingChange(before, after model.ProductReview) model.RatingDelta {
+	var delta model.RatingDelta
+	if before.Status == model.ReviewStatusApproved {
+		delta.Sum -= int64(before.Rating)
+		delta.Count--
+	}
+	if after.Status == model.ReviewStatusApproved {
+		delta.Sum += int64(after.Rating)
+		delta.Count++
+	}
+	return delta
+}
+
+// addProductRating applies a change of the reviews of a product to its rating.
+// The average is computed by the repository from the stored sum and count, a
+// value computed here could be stale by the time it is written.
+func (s *service) addProductRating(ctx context.Context, productID int64, delta model.RatingDelta) error {
+	if delta == (model.RatingDelta{}) {
+		return nil
+	}
+
+	err := s.productRepo.AddProductRating(ctx, productID, delta, s.ratingPrior, historyActor(ctx))
+	if err != nil {
+		return errorhelper.WrapWithCode(err, "error when update product rating", http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+func (s *service) GetProductReviews(ctx context.Context, productID int64, filter api.GetReviewListFilter) (api.ProductReviewListResponse, error) {
+	if productID <= 0 {
+		return api.ProductReviewListResponse{}, errorhelper.NewWithCode("invalid id", http.StatusBadRequest)
+	}
+
+	if err := filter.Validate(); err != nil {
+		return api.ProductReviewListResponse{}, errorhelper.WrapWithCode(err, "invalid request", http.StatusBadRequest)
+	}
+
+	_, err := s.productRepo.GetProduct(ctx, productID)
+	if err != nil && err != sql.ErrNoRows {
+		return api.ProductReviewListResponse{}, errorhelper.WrapWithCode(err, "error when get product", http.StatusInternalServerError)
+	}
+	if err == sql.ErrNoRows {
+		return api.ProductReviewListResponse{}, errorhelper.NewWithCode("product not found", http.StatusNotFound)
+	}
+
+	reviewFilter := model.GetReviewListFilter{
+		ProductID: productID,
+		Rating:    filter.Rating,
+		Status:    model.ReviewStatusApproved,
+		Sort:      filter.Sort,
+		Limit:     filter.Size,
+		Offset:    (filter.Page - 1) * filter.Size,
+	}
+
+	total, err := s.reviewRepo.CountReviewList(ctx, reviewFilter)
+	if err != nil {
+		return api.ProductReviewListResponse{}, errorhelper.WrapWithCode(err, "error when count product reviews", http.StatusInternalServerError)
+	}
+
+	reviews, err := s.reviewRepo.GetReviewList(ctx, reviewFilter)
+	if err != nil {
+		return api.ProductReviewListResponse{}, errorhelper.WrapWithCode(err, "error when get product reviews", http.StatusInternalServerError)
+	}
+
+	path := fmt.Sprintf("/products/%d/reviews", productID)
+	res := api.ProductReviewListResponse{
+		Items: make([]api.ProductReview, len(reviews)),
+		Pagination: newPagination(filter.Page, filter.Size, total, func(page int64) string {
+			return filter.URL(path, page)
+		}),
+	}
+	for i, v := range reviews {
+		res.Items[i] = toAPIReview(v)
+	}
+
+	return res, nil
+}
+
+func toAPIReview(review model.ProductReview) api.ProductReview {
+	return api.ProductReview{
+		ID:      review.ID,
+		Rating:  review.Rating,
+		Comment: review.Comment,
+		Reviewer: api.Actor{
+			ID:   review.User.Subject,
+			Name: review.User.Name,
+		},
+		HelpfulCount:   review.HelpfulCount,
+		UnhelpfulCount: review.UnhelpfulCount,
+		Reply:          toAPIReply(review.Reply),
+		CreatedAt:      review.CreatedAt,
+		UpdatedAt:      review.UpdatedAt,
+	}
+}
+
+func toAPIReply(reply *model.ReviewReply) *api.ReviewReply {
+	if reply == nil {
+		return nil
+	}
+	return &api.ReviewReply{
+		Comment: reply.Comment,
+		Vendor: api.Actor{
+			ID:   reply.Vendor.Subject,
+			Name: reply.Vendor.Name,
+		},
+		CreatedAt: reply.CreatedAt,
+		UpdatedAt: reply.UpdatedAt,
+	}
+}
+
+func (s *service) DeleteProduct(ctx context.Context, id int64) (api.MutationResponse, error) {
+	if id <= 0 {
+		return api.MutationResponse{}, errorhelper.NewWithCode("invalid id", http.StatusBadRequest)
+	}
+
+	err := s.productRepo.DeleteProduct(ctx, id, historyActor(ctx))
+	if err != nil && err != sql.ErrNoRows {
+		return api.MutationResponse{}, errorhelper.WrapWithCode(err, "error when delete product", http.StatusInternalServerError)
+	}
+	if err == sql.ErrNoRows {
+		return api.MutationResponse{}, errorhelper.NewWithCode("product not found", http.StatusNotFound)
+	}
+
+	if s.searcher != nil {
+		if err := s.searcher.RemoveProduct(ctx, id); err != nil {
+			log.Println(errorhelper.Wrap(err, "error when remove product from index"))
+		}
+	}
+
+	return api.MutationResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *service) RestoreProduct(ctx context.Context, id int64) (api.MutationResponse, error) {
+	if id <= 0 {
+		return api.MutationResponse{}, errorhelper.NewWithCode("invalid id", http.StatusBadRequest)
+	}
+
+	err := s.productRepo.RestoreProduct(ctx, id, historyActor(ctx))
+	if err != nil && err != sql.ErrNoRows {
+		return api.MutationResponse{}, errorhelper.WrapWithCode(err, "error when restore product", http.StatusInternalServerError)
+	}
+	if err == sql.ErrNoRows {
+		return api.MutationResponse{}, errorhelper.NewWithCode("deleted product not found", http.StatusNotFound)
+	}
+
+	if s.searcher != nil {
+		product, err := s.productRepo.GetProduct(ctx, id)
+		if err != nil {
+			log.Println(errorhelper.Wrap(err, "error when get restored product"))
+		} else {
+			s.indexProduct(ctx, product)
+		}
+	}
+
+	return api.MutationResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *service) GetProductHistory(ctx context.Context, productID int64, filter api.PageFilter) (api.ProductHistoryResponse, error) {
+	if productID <= 0 {
+		return api.ProductHistoryResponse{}, errorhelper.NewWithCode("invalid id", http.StatusBadRequest)
+	}
+
+	if err := filter.Validate(); err != nil {
+		return api.ProductHistoryResponse{}, errorhelper.WrapWithCode(err, "invalid request", http.StatusBadRequest)
+	}
+
+	// Every product has at least its creation in the history, deleted ones
+	// included.
+	total, err := s.historyRepo.CountProductHistory(ctx, productID)
+	if err != nil {
+		return api.ProductHistoryResponse{}, errorhelper.WrapWithCode(err, "error when count product history", http.StatusInternalServerError)
+	}
+	if total == 0 {
+		return api.ProductHistoryResponse{}, errorhelper.NewWithCode("product not found", http.StatusNotFound)
+	}
+
+	history, err := s.historyRepo.GetProductHistory(ctx, productID, filter.Size, (filter.Page-1)*filter.Size)
+	if err != nil {
+		return api.ProductHistoryResponse{}, errorhelper.WrapWithCode(err, "error when get product history", http.StatusInternalServerError)
+	}
+
+	path := fmt.Sprintf("/products/%d/history", productID)
+	res := api.ProductHistoryResponse{
+		Items: make([]api.ProductHistory, len(history)),
+		Pagination: newPagination(filter.Page, filter.Size, total, func(page int64) string {
+			return filter.URL(path, page)
+		}),
+	}
+	for i, v := range history {
+		res.Items[i] = api.ProductHistory{
+			ID:     v.ID,
+			Action: v.Action,
+			Actor: api.Actor{
+				ID:   v.ActorID,
+				Name: v.ActorName,
+			},
+			Before:    toAPISnapshot(v.Before),
+			After:     toAPISnapshot(v.After),
+			CreatedAt: v.CreatedAt,
+		}
+	}
+
+	return res, nil
+}
+
+func (s *service) GetProductPrices(ctx context.Context, productID int64, filter api.PageFilter) (api.ProductPriceResponse, error) {
+	if productID <= 0 {
+		return api.ProductPriceResponse{}, errorhelper.NewWithCode("invalid id", http.StatusBadRequest)
+	}
+
+	if err := filter.Validate(); err != nil {
+		return api.ProductPriceResponse{}, errorhelper.WrapWithCode(err, "invalid request", http.StatusBadRequest)
+	}
+
+	// Every product has had at least its price at creation, deleted ones
+	// included.
+	total, err := s.priceRepo.CountProductPrices(ctx, productID)
+	if err != nil {
+		return api.ProductPriceResponse{}, errorhelper.WrapWithCode(err, "error when count product prices", http.StatusInternalServerError)
+	}
+	if total == 0 {
+		return api.ProductPriceResponse{}, errorhelper.NewWithCode("product not found", http.StatusNotFound)
+	}
+
+	prices, err := s.priceRepo.GetProductPrices(ctx, productID, filter.Size, (filter.Page-1)*filter.Size)
+	if err != nil {
+		return api.ProductPriceResponse{}, errorhelper.WrapWithCode(err, "error when get product prices", http.StatusInternalServerError)
+	}
+
+	path := fmt.Sprintf("/products/%d/prices", productID)
+	res := api.ProductPriceResponse{
+		Items: make([]api.ProductPrice, len(prices)),
+		Pagination: newPagination(filter.Page, filter.Size, total, func(page int64) string {
+			return filter.URL(path, page)
+		}),
+	}
+	for i, v := range prices {
+		res.Items[i] = api.ProductPrice{
+			Price:         v.Price,
+			EffectiveFrom: v.EffectiveFrom,
+			EffectiveTo:   v.EffectiveTo,
+		}
+	}
+
+	return res, nil
+}
+
+func (s *service) GetCategoryList(ctx context.Context) (api.CategoryListResponse, error) {
+	categories, err := s.categoryRepo.GetCategoryList(ctx)
+	if err != nil {
+		return api.CategoryListResponse{}, errorhelper.WrapWithCode(err, "error when get category list", http.StatusInternalServerError)
+	}
+
+	res := api.CategoryListResponse{
+		Items: make([]api.Category, 0, len(categories)),
+	}
+	for _, category := range categories {
+		res.Items = append(res.Items, toAPICategory(category))
+	}
+
+	return res, nil
+}
+
+func (s *service) GetCategoryTree(ctx context.Context) (api.CategoryTreeResponse, error) {
+	tree, err := s.getCategoryTree(ctx)
+	if err != nil {
+		return api.CategoryTreeResponse{}, err
+	}
+
+	return api.CategoryTreeResponse{
+		Items: tree.toAPI(tree.roots),
+	}, nil
+}
+
+func (s *service) GetCategory(ctx context.Context, id int64) (api.Category, error) {
+	if id <= 0 {
+		return api.Category{}, errorhelper.NewWithCode("invalid id", http.StatusBadRequest)
+	}
+
+	category, err := s.categoryRepo.GetCategory(ctx, id)
+	if err != nil && err != sql.ErrNoRows {
+		return api.Category{}, errorhelper.WrapWithCode(err, "error when get category", http.StatusInternalServerError)
+	}
+	if err == sql.ErrNoRows {
+		return api.Category{}, errorhelper.NewWithCode("category not found", http.StatusNotFound)
+	}
+
+	return toAPICategory(category), nil
+}
+
+// GetCategoryBreadcrumbs returns the categories from the root category down to
+// the category id.
+func (s *service) GetCategoryBreadcrumbs(ctx context.Context, id int64) (api.CategoryListResponse, error) {
+	if id <= 0 {
+		return api.CategoryListResponse{}, errorhelper.NewWithCode("invalid id", http.StatusBadRequest)
+	}
+
+	tree, err := s.getCategoryTree(ctx)
+	if err != nil {
+		return api.CategoryListResponse{}, err
+	}
+
+	path := tree.apiPath(id)
+	if len(path) == 0 {
+		return api.CategoryListResponse{}, errorhelper.NewWithCode("category not found", http.StatusNotFound)
+	}
+
+	return api.CategoryListResponse{
+		Items: path,
+	}, nil
+}
+
+func (s *service) CreateCategory(ctx context.Context, req api.Category) (api.MutationResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return api.MutationResponse{}, err
+	}
+
+	if err := req.Validate(); err != nil {
+		return api.MutationResponse{}, errorhelper.WrapWithCode(err, "invalid request payload", http.StatusBadRequest)
+	}
+
+	if req.ParentID != 0 {
+		if err := s.checkCategoryParent(ctx, 0, req.ParentID); err != nil {
+			return api.MutationResponse{}, err
+		}
+	}
+
+	_, err := s.categoryRepo.InsertCategory(ctx, toModelCategory(0, req))
+	if err == model.ErrDuplicateCategoryName {
+		return api.MutationResponse{}, errorhelper.NewWithCode("category name already exist", http.StatusBadRequest)
+	}
+	if err != nil {
+		return api.MutationResponse{}, errorhelper.WrapWithCode(err, "error when insert category", http.StatusInternalServerError)
+	}
+
+	return api.MutationResponse{
+		Success: true,
+	}, nil
+}
+
+// UpdateCategory replaces the name, the parent and the rating prior of a
+// category. The products of the category are scored again when the prior
+// changes and indexed again when the name changes.
+func (s *service) UpdateCategory(ctx context.Context, id int64, req api.Category) (api.MutationResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return api.MutationResponse{}, err
+	}
+
+	if id <= 0 {
+		return api.MutationResponse{}, errorhelper.NewWithCode("invalid id", http.StatusBadRequest)
+	}
+
+	if err := req.Validate(); err != nil {
+		return api.MutationResponse{}, errorhelper.WrapWithCode(err, "invalid request payload", http.StatusBadRequest)
+	}
+
+	category := toModelCategory(id, req)
+	var renamed bool
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.categoryRepo.LockCategory(ctx, id)
+		if err != nil && err != sql.ErrNoRows {
+			return errorhelper.WrapWithCode(err, "error when get category", http.StatusInternalServerError)
+		}
+		if err == sql.ErrNoRows {
+			return errorhelper.NewWithCode("category not found", http.StatusNotFound)
+		}
+
+		if category.ParentID != 0 {
+			if err := s.checkCategoryParent(ctx, id, category.ParentID); err != nil {
+				return err
+			}
+		}
+
+		err = s.categoryRepo.UpdateCategory(ctx, category)
+		if err == model.ErrDuplicateCategoryName {
+			return errorhelper.NewWithCode("category name already exist", http.StatusBadRequest)
+		}
+		if err != nil {
+			return errorhelper.WrapWithCode(err, "error when update category", http.StatusInternalServerError)
+		}
+
+		if !sameRatingPrior(before.RatingPrior, category.RatingPrior) {
+			if err := s.productRepo.RecomputeRatingScores(ctx, s.ratingPrior); err != nil {
+				return errorhelper.WrapWithCode(err, "error when recompute rating scores", http.StatusInternalServerError)
+			}
+		}
+		renamed = before.Name != category.Name
+
+		return nil
+	})
+	if err != nil {
+		return api.MutationResponse{}, txError(err)
+	}
+
+	if renamed {
+		s.reindexCategory(ctx, id)
+	}
+
+	return api.MutationResponse{
+		Success: true,
+	}, nil
+}
+
+// DeleteCategory deletes a category without subcategories. A category with
+// products is only deleted when they are reassigned to another category.
+func (s *service) DeleteCategory(ctx context.Context, id int64, req api.DeleteCategoryRequest) (api.MutationResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return api.MutationResponse{}, err
+	}
+
+	if id <= 0 {
+		return api.MutationResponse{}, errorhelper.NewWithCode("invalid id", http.StatusBadRequest)
+	}
+
+	if err := req.Validate(id); err != nil {
+		return api.MutationResponse{}, errorhelper.WrapWithCode(err, "invalid request", http.StatusBadRequest)
+	}
+
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		_, err := s.categoryRepo.LockCategory(ctx, id)
+		if err != nil && err != sql.ErrNoRows {
+			return errorhelper.WrapWithCode(err, "error when get category", http.StatusInternalServerError)
+		}
+		if err == sql.ErrNoRows {
+			return errorhelper.NewWithCode("category not found", http.StatusNotFound)
+		}
+
+		categories, err := s.categoryRepo.GetCategoryList(ctx)
+		if err != nil {
+			return errorhelper.WrapWithCode(err, "error when get category list", http.StatusInternalServerError)
+		}
+		for _, category := range categories {
+			if category.ParentID == id {
+				return errorhelper.NewWithCode("category still has subcategories", http.StatusConflict)
+			}
+		}
+
+		if req.ReassignTo != 0 {
+			_, err := s.categoryRepo.LockCategory(ctx, req.ReassignTo)
+			if err != nil && err != sql.ErrNoRows {
+				return errorhelper.WrapWithCode(err, "error when get category", http.StatusInternalServerError)
+			}
+			if err == sql.ErrNoRows {
+				return errorhelper.NewWithCode("reassign category not found", http.StatusBadRequest)
+			}
+
+			if err := s.productRepo.MoveCategoryProducts(ctx, id, req.ReassignTo, historyActor(ctx)); err != nil {
+				return errorhelper.WrapWithCode(err, "error when move category products", http.StatusInternalServerError)
+			}
+			// The moved products are scored with the prior of their new
+			// category.
+			if err := s.productRepo.RecomputeRatingScores(ctx, s.ratingPrior); err != nil {
+				return errorhelper.WrapWithCode(err, "error when recompute rating scores", http.StatusInternalServerError)
+			}
+		}
+
+		err = s.categoryRepo.DeleteCategory(ctx, id)
+		if err == model.ErrCategoryInUse {
+			return errorhelper.NewWithCode("category still has products, reassign them to another category", http.StatusConflict)
+		}
+		if err != nil {
+			return errorhelper.WrapWithCode(err, "error when delete category", http.StatusInternalServerError)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return api.MutationResponse{}, txError(err)
+	}
+
+	if req.ReassignTo != 0 {
+		s.reindexCategory(ctx, req.ReassignTo)
+	}
+
+	return api.MutationResponse{
+		Success: true,
+	}, nil
+}
+
+// checkCategoryParent returns an error unless parentID exists and is not the
+// category id or one of its subcategories. The ancestors are locked, so
+// concurrent moves can not close a cycle together.
+func (s *service) checkCategoryParent(ctx context.Context, id, parentID int64) error {
+	for ancestorID := parentID; ancestorID != 0; {
+		if ancestorID == id {
+			return errorhelper.NewWithCode("category can not be moved under itself or its subcategories", http.StatusBadRequest)
+		}
+
+		ancestor, err := s.categoryRepo.LockCategory(ctx, ancestorID)
+		if err != nil && err != sql.ErrNoRows {
+			return errorhelper.WrapWithCode(err, "error when get category", http.StatusInternalServerError)
+		}
+		if err == sql.ErrNoRows {
+			return errorhelper.NewWithCode("parent category not found", http.StatusBadRequest)
+		}
+		ancestorID = ancestor.ParentID
+	}
+
+	return nil
+}
+
+// getCategoryTree reads the whole category tree.
+func (s *service) getCategoryTree(ctx context.Context) (categoryTree, error) {
+	categories, err := s.categoryRepo.GetCategoryList(ctx)
+	if err != nil {
+		return categoryTree{}, errorhelper.WrapWithCode(err, "error when get category list", http.StatusInternalServerError)
+	}
+	return newCategoryTree(categories), nil
+}
+
+func toAPICategory(category model.Category) api.Category {
+	res := api.Category{
+		ID:       category.ID,
+		Name:     category.Name,
+		ParentID: category.ParentID,
+	}
+	if category.RatingPrior != nil {
+		res.RatingPrior = &api.RatingPrior{
+			Mean:   category.RatingPrior.Mean,
+			Weight: category.RatingPrior.Weight,
+		}
+	}
+	return res
+}
+
+func toModelCategory(id int64, req api.Category) model.Category {
+	res := model.Category{
+		ID:       id,
+		Name:     strings.TrimSpace(req.Name),
+		ParentID: req.ParentID,
+	}
+	if req.RatingPrior != nil {
+		res.RatingPrior = &model.RatingPrior{
+			Mean:   req.RatingPrior.Mean,
+			Weight: req.RatingPrior.Weight,
+		}
+	}
+	return res
+}
+
+func sameRatingPrior(a, b *model.RatingPrior) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func toAPISnapshot(snapshot *model.ProductSnapshot) *api.ProductSnapshot {
+	if snapshot == nil {
+		return nil
+	}
+	return &api.ProductSnapshot{
+		SKU:         snapshot.SKU,
+		Title:       snapshot.Title,
+		Description: snapshot.Description,
+		CategoryID:  snapshot.CategoryID,
+		ImageURL:    snapshot.ImageURL,
+		Weight:      snapshot.Weight,
+		Price:       snapshot.Price,
+		Rating:      snapshot.Rating,
+		Version:     snapshot.Version,
+	}
+}
+
+// txError returns the error of a unit of work. Errors returned by its function
+// already carry a status code, any other error comes from the transaction
+// itself.
+func txError(err error) error {
+	if _, ok := err.(*errorhelper.Error); ok {
+		return err
+	}
+	return errorhelper.WrapWithCode(err, "error when commit transaction", http.StatusInternalServerError)
+}
+
+// indexProduct keeps the searcher in sync with a product that has just been
+// stored. The product is already saved at this point, so a failure is only
+// logged instead of failing the request.
+func (s *service) indexProduct(ctx context.Context, product model.Product) {
+	if s.searcher == nil {
+		return
+	}
+	if err := s.searcher.IndexProduct(ctx, product); err != nil {
+		log.Println(errorhelper.Wrap(err, "error when index product"))
+	}
+}
+
+// reindexCategory indexes the products of a category again after a change
+// the searcher sees, like its name. Failures are only logged, as for
+// indexProduct.
+func (s *service) reindexCategory(ctx context.Context, categoryID int64) {
+	if s.searcher == nil {
+		return
+	}
+	for offset := int64(0); ; offset += reindexBatchSize {
+		products, err := s.productRepo.GetProductList(ctx, model.GetProductListFilter{
+			CategoryIDs: []int64{categoryID},
+			Limit:       reindexBatchSize,
+			Offset:      offset,
+		})
+		if err != nil {
+			log.Println(errorhelper.Wrap(err, "error when get products to index"))
+			return
+		}
+
+		for _, product := range products {
+			s.indexProduct(ctx, product)
+		}
+
+		if len(products) < reindexBatchSize {
+			return
+		}
+	}
+}
+
+// newPagination describes the position of a page in a list of total items.
+// link returns the URL of another page of the same list.
+func newPagination(page, size, total int64, link func(page int64) string) api.Pagination {
+	res := api.Pagination{
+		Page:       page,
+		Size:       size,
+		TotalItems: total,
+		TotalPages: (total + size - 1) / size,
+	}
+	if page < res.TotalPages {
+		res.Next = link(page + 1)
+	}
+	if page > 1 {
+		res.Prev = link(min(page-1, max(res.TotalPages, 1)))
+	}
+	return res
 }
@@ -4,12 +4,16 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"github.com/alam/govtech/internal/api"
 	"github.com/alam/govtech/internal/model"
+	"github.com/alam/govtech/internal/util/authhelper"
+	"github.com/alam/govtech/internal/util/cursorhelper"
 	"github.com/alam/govtech/internal/util/errorhelper"
 	"github.com/alam/govtech/mocks"
 	"github.com/stretchr/testify/mock"
 	"net/http"
+	"net/url"
 	"reflect"
 	"testing"
 	"time"
@@ -19,12 +23,28 @@ var (
 	mockProductRepo  *mocks.ProductRepository
 	mockCategoryRepo *mocks.CategoryRepository
 	mockReviewRepo   *mocks.ProductReviewRepository
+	mockHistoryRepo  *mocks.ProductHistoryRepository
+	mockPriceRepo    *mocks.ProductPriceRepository
+	mockUserRepo     *mocks.UserRepository
+	mockTxManager    *mocks.TxManager
+	mockSearcher     *mocks.ProductSearcher
+	mockScreener     *mocks.ReviewScreener
 )
 
 func initMock() {
 	mockProductRepo = new(mocks.ProductRepository)
 	mockCategoryRepo = new(mocks.CategoryRepository)
 	mockReviewRepo = new(mocks.ProductReviewRepository)
+	mockHistoryRepo = new(mocks.ProductHistoryRepository)
+	mockPriceRepo = new(mocks.ProductPriceRepository)
+	mockUserRepo = new(mocks.UserRepository)
+	mockTxManager = new(mocks.TxManager)
+	mockTxManager.On("WithinTx", mock.Anything, mock.Anything).
+		Return(func(ctx context.Context, fn func(context.Context) error) error {
+			return fn(ctx)
+		}).Maybe()
+	mockSearcher = new(mocks.ProductSearcher)
+	mockScreener = new(mocks.ReviewScreener)
 }
 
 func Test_service_CreateProduct(t *testing.T) {
@@ -176,12 +196,41 @@ func Test_service_CreateProduct(t *testing.T) {
 					Return(model.Category{}, nil)
 				mockProductRepo.On("GetProductBySKU", mock.Anything, "IND001").
 					Return(model.Product{}, sql.ErrNoRows)
-				mockProductRepo.On("InsertProduct", mock.Anything, mock.Anything).
-					Return(errors.New("any"))
+				mockProductRepo.On("InsertProduct", mock.Anything, mock.Anything, mock.Anything).
+					Return(int64(0), errors.New("any"))
 			},
 			want:       api.MutationResponse{},
 			statusCode: http.StatusInternalServerError,
 		},
+		{
+			name: "sku taken by a deleted product",
+			args: args{
+				ctx: context.Background(),
+				req: api.Product{
+					SKU:         "IND001",
+					Title:       "Foo",
+					Description: "Makanan ringan",
+					Category: api.Category{
+						ID:   5,
+						Name: "",
+					},
+					ImageURL: "https://foo.bar/foo.jpg",
+					Weight:   5,
+					Price:    10000,
+					Rating:   4,
+				},
+			},
+			prepare: func() {
+				mockCategoryRepo.On("GetCategory", mock.Anything, int64(5)).
+					Return(model.Category{}, nil)
+				mockProductRepo.On("GetProductBySKU", mock.Anything, "IND001").
+					Return(model.Product{}, sql.ErrNoRows)
+				mockProductRepo.On("InsertProduct", mock.Anything, mock.Anything, mock.Anything).
+					Return(int64(0), model.ErrDuplicateSKU)
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusBadRequest,
+		},
 		{
 			name: "success",
 			args: args{
@@ -205,8 +254,44 @@ func Test_service_CreateProduct(t *testing.T) {
 					Return(model.Category{}, nil)
 				mockProductRepo.On("GetProductBySKU", mock.Anything, "IND001").
 					Return(model.Product{}, sql.ErrNoRows)
-				mockProductRepo.On("InsertProduct", mock.Anything, mock.Anything).
-					Return(nil)
+				mockProductRepo.On("InsertProduct", mock.Anything, mock.Anything, mock.Anything).
+					Return(int64(9), nil)
+				mockSearcher.On("IndexProduct", mock.Anything, mock.MatchedBy(func(product model.Product) bool {
+					return product.ID == 9 && product.SKU == "IND001"
+				})).Return(nil)
+			},
+			want: api.MutationResponse{
+				Success: true,
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name: "success records the vendor",
+			args: args{
+				ctx: authhelper.WithActor(context.Background(), authhelper.Actor{ID: "v1", Name: "Toko Budi"}),
+				req: api.Product{
+					SKU:         "IND001",
+					Title:       "Foo",
+					Description: "Makanan ringan",
+					Category: api.Category{
+						ID: 5,
+					},
+					ImageURL: "https://foo.bar/foo.jpg",
+					Weight:   5,
+					Price:    10000,
+				},
+			},
+			prepare: func() {
+				mockCategoryRepo.On("GetCategory", mock.Anything, int64(5)).
+					Return(model.Category{}, nil)
+				mockProductRepo.On("GetProductBySKU", mock.Anything, "IND001").
+					Return(model.Product{}, sql.ErrNoRows)
+				mockUserRepo.On("UpsertUser", mock.Anything, model.User{Subject: "v1", Name: "Toko Budi"}).
+					Return(int64(9), nil)
+				mockProductRepo.On("InsertProduct", mock.Anything, mock.MatchedBy(func(product model.Product) bool {
+					return product.VendorID == 9
+				}), model.User{Subject: "v1", Name: "Toko Budi"}).Return(int64(9), nil)
+				mockSearcher.On("IndexProduct", mock.Anything, mock.Anything).Return(nil)
 			},
 			want: api.MutationResponse{
 				Success: true,
@@ -221,6 +306,9 @@ func Test_service_CreateProduct(t *testing.T) {
 				productRepo:  mockProductRepo,
 				categoryRepo: mockCategoryRepo,
 				reviewRepo:   mockReviewRepo,
+				userRepo:     mockUserRepo,
+				txManager:    mockTxManager,
+				searcher:     mockSearcher,
 			}
 			if tt.prepare != nil {
 				tt.prepare()
@@ -238,9 +326,43 @@ func Test_service_CreateProduct(t *testing.T) {
 }
 
 func Test_service_GetProduct(t *testing.T) {
+	asOf := time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC)
+	product := model.Product{
+		ID:          1,
+		SKU:         "IND005",
+		Title:       "Test",
+		Description: "test",
+		Category: model.Category{
+			ID:   1,
+			Name: "Makanan",
+		},
+		ImageURL:  "https://foo.bar/image.jpg",
+		Weight:    1,
+		Price:     1000,
+		Rating:    4.5,
+		CreatedAt: time.Time{},
+	}
+
+	stat := model.Statistic{
+		Count:        3,
+		Average:      4,
+		Distribution: [5]int64{0, 0, 1, 1, 1},
+	}
+	reviewCount := int64(3)
+	distribution := map[int32]int64{1: 0, 2: 0, 3: 1, 4: 1, 5: 1}
+	categories := []model.Category{
+		{ID: 1, Name: "Makanan", ParentID: 4},
+		{ID: 4, Name: "Sembako"},
+	}
+	path := []api.Category{
+		{ID: 4, Name: "Sembako"},
+		{ID: 1, Name: "Makanan"},
+	}
+
 	type args struct {
-		ctx context.Context
-		id  int64
+		ctx    context.Context
+		id     int64
+		filter api.GetProductFilter
 	}
 	tests := []struct {
 		name       string
@@ -280,6 +402,20 @@ func Test_service_GetProduct(t *testing.T) {
 			want:       api.Product{},
 			statusCode: http.StatusNotFound,
 		},
+		{
+			name: "error when get statistic review",
+			args: args{
+				id: 5,
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(5)).
+					Return(product, nil)
+				mockReviewRepo.On("GetReviewStatistic", mock.Anything, int64(5)).
+					Return(model.Statistic{}, errors.New("any"))
+			},
+			want:       api.Product{},
+			statusCode: http.StatusInternalServerError,
+		},
 		{
 			name: "success",
 			args: args{
@@ -302,6 +438,93 @@ func Test_service_GetProduct(t *testing.T) {
 						Rating:    4.5,
 						CreatedAt: time.Time{},
 					}, nil)
+				mockReviewRepo.On("GetReviewStatistic", mock.Anything, int64(5)).
+					Return(stat, nil)
+				mockCategoryRepo.On("GetCategoryList", mock.Anything).
+					Return(categories, nil)
+			},
+			want: api.Product{
+				ID:          1,
+				SKU:         "IND005",
+				Title:       "Test",
+				Description: "test",
+				Category: api.Category{
+					ID:   1,
+					Name: "Makanan",
+					Path: path,
+				},
+				ImageURL:           "https://foo.bar/image.jpg",
+				Weight:             1,
+				Price:              1000,
+				Rating:             4.5,
+				ReviewCount:        &reviewCount,
+				RatingDistribution: distribution,
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name: "invalid as of",
+			args: args{
+				id:     5,
+				filter: api.GetProductFilter{AsOf: "2023-12-01"},
+			},
+			prepare:    nil,
+			want:       api.Product{},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "error when get product price",
+			args: args{
+				id:     5,
+				filter: api.GetProductFilter{AsOf: "2023-12-01T00:00:00Z"},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(5)).
+					Return(product, nil)
+				mockReviewRepo.On("GetReviewStatistic", mock.Anything, int64(5)).
+					Return(stat, nil)
+				mockCategoryRepo.On("GetCategoryList", mock.Anything).
+					Return(categories, nil)
+				mockPriceRepo.On("GetProductPriceAt", mock.Anything, int64(5), asOf).
+					Return(model.ProductPrice{}, errors.New("any"))
+			},
+			want:       api.Product{},
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name: "no price at as of",
+			args: args{
+				id:     5,
+				filter: api.GetProductFilter{AsOf: "2023-12-01T00:00:00Z"},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(5)).
+					Return(product, nil)
+				mockReviewRepo.On("GetReviewStatistic", mock.Anything, int64(5)).
+					Return(stat, nil)
+				mockCategoryRepo.On("GetCategoryList", mock.Anything).
+					Return(categories, nil)
+				mockPriceRepo.On("GetProductPriceAt", mock.Anything, int64(5), asOf).
+					Return(model.ProductPrice{}, sql.ErrNoRows)
+			},
+			want:       api.Product{},
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name: "success as of",
+			args: args{
+				id:     5,
+				filter: api.GetProductFilter{AsOf: "2023-12-01T00:00:00Z"},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(5)).
+					Return(product, nil)
+				mockReviewRepo.On("GetReviewStatistic", mock.Anything, int64(5)).
+					Return(stat, nil)
+				mockCategoryRepo.On("GetCategoryList", mock.Anything).
+					Return(categories, nil)
+				mockPriceRepo.On("GetProductPriceAt", mock.Anything, int64(5), asOf).
+					Return(model.ProductPrice{ID: 2, ProductID: 5, Price: 800, EffectiveFrom: asOf.Add(-time.Hour)}, nil)
 			},
 			want: api.Product{
 				ID:          1,
@@ -311,11 +534,15 @@ func Test_service_GetProduct(t *testing.T) {
 				Category: api.Category{
 					ID:   1,
 					Name: "Makanan",
+					Path: path,
 				},
-				ImageURL: "https://foo.bar/image.jpg",
-				Weight:   1,
-				Price:    1000,
-				Rating:   4.5,
+				ImageURL:           "https://foo.bar/image.jpg",
+				Weight:             1,
+				Price:              800,
+				Rating:             4.5,
+				ReviewCount:        &reviewCount,
+				RatingDistribution: distribution,
+				AsOf:               &asOf,
 			},
 			statusCode: http.StatusOK,
 		},
@@ -327,11 +554,14 @@ func Test_service_GetProduct(t *testing.T) {
 				productRepo:  mockProductRepo,
 				categoryRepo: mockCategoryRepo,
 				reviewRepo:   mockReviewRepo,
+				priceRepo:    mockPriceRepo,
+				txManager:    mockTxManager,
+				searcher:     mockSearcher,
 			}
 			if tt.prepare != nil {
 				tt.prepare()
 			}
-			got, err := s.GetProduct(tt.args.ctx, tt.args.id)
+			got, err := s.GetProduct(tt.args.ctx, tt.args.id, tt.args.filter)
 			if errorhelper.GetCode(err) != tt.statusCode {
 				t.Errorf("GetProduct() status code = %v, want %v", errorhelper.GetCode(err), tt.statusCode)
 				return
@@ -352,7 +582,7 @@ func Test_service_GetProductList(t *testing.T) {
 		name       string
 		args       args
 		prepare    func()
-		want       []api.Product
+		want       api.ProductListResponse
 		statusCode int
 	}{
 		{
@@ -360,14 +590,14 @@ func Test_service_GetProductList(t *testing.T) {
 			args: args{
 				ctx: context.Background(),
 				filter: api.GetProductListFilter{
-					Search:     "",
-					CategoryID: 0,
-					SortColumn: "wrong",
-					SortType:   "wrong",
+					Search:      "",
+					CategoryIDs: nil,
+					SortColumn:  "wrong",
+					SortType:    "wrong",
 				},
 			},
 			prepare:    nil,
-			want:       nil,
+			want:       api.ProductListResponse{},
 			statusCode: http.StatusBadRequest,
 		},
 		{
@@ -380,246 +610,3671 @@ func Test_service_GetProductList(t *testing.T) {
 				mockProductRepo.On("GetProductList", mock.Anything, mock.Anything).
 					Return(nil, errors.New("any"))
 			},
-			want:       nil,
+			want:       api.ProductListResponse{},
 			statusCode: http.StatusInternalServerError,
 		},
 		{
-			name: "success",
+			name: "error when count product list",
 			args: args{
 				ctx:    context.Background(),
 				filter: api.GetProductListFilter{},
 			},
 			prepare: func() {
 				mockProductRepo.On("GetProductList", mock.Anything, mock.Anything).
-					Return([]model.Product{
-						{
-							ID:          1,
-							SKU:         "IND001",
-							Title:       "title",
-							Description: "description",
-							Category: model.Category{
-								ID:   1,
-								Name: "name",
-							},
-							ImageURL: "https://foo.bar/image.jpg",
-							Weight:   1,
-							Price:    10000,
-							Rating:   3.2,
-						},
-					}, nil)
-			},
-			want: []api.Product{
-				{
-					ID:          1,
-					SKU:         "IND001",
-					Title:       "title",
-					Description: "description",
-					Category: api.Category{
-						ID:   1,
-						Name: "name",
-					},
-					ImageURL: "https://foo.bar/image.jpg",
-					Weight:   1,
-					Price:    10000,
-					Rating:   3.2,
-				},
+					Return([]model.Product{}, nil)
+				mockProductRepo.On("CountProductList", mock.Anything, mock.Anything).
+					Return(int64(0), errors.New("any"))
 			},
-			statusCode: http.StatusOK,
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			initMock()
-			s := &service{
-				productRepo:  mockProductRepo,
-				categoryRepo: mockCategoryRepo,
-				reviewRepo:   mockReviewRepo,
-			}
-			if tt.prepare != nil {
-				tt.prepare()
-			}
-			got, err := s.GetProductList(tt.args.ctx, tt.args.filter)
-			if errorhelper.GetCode(err) != tt.statusCode {
-				t.Errorf("GetProducList() status code = %v, want %v", errorhelper.GetCode(err), tt.statusCode)
-				return
-			}
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("GetProductList() got = %v, want %v", got, tt.want)
-			}
-		})
-	}
-}
-
-func Test_service_ReviewProduct(t *testing.T) {
-	type args struct {
-		ctx       context.Context
-		productID int64
-		req       api.ReviewProductRequest
-	}
-	tests := []struct {
-		name       string
-		args       args
-		prepare    func()
-		want       api.MutationResponse
-		statusCode int
-	}{
-		{
-			name:       "invalid product id",
-			args:       args{},
-			prepare:    nil,
-			want:       api.MutationResponse{},
-			statusCode: http.StatusBadRequest,
+			want:       api.ProductListResponse{},
+			statusCode: http.StatusInternalServerError,
 		},
 		{
-			name: "invalid payload request",
+			name: "error when get product facets",
 			args: args{
-				ctx:       context.Background(),
-				productID: 2,
-				req:       api.ReviewProductRequest{},
+				ctx:    context.Background(),
+				filter: api.GetProductListFilter{},
 			},
-			prepare:    nil,
-			want:       api.MutationResponse{},
-			statusCode: http.StatusBadRequest,
+			prepare: func() {
+				mockProductRepo.On("GetProductList", mock.Anything, mock.Anything).
+					Return([]model.Product{}, nil)
+				mockProductRepo.On("CountProductList", mock.Anything, mock.Anything).
+					Return(int64(0), nil)
+				mockProductRepo.On("GetProductFacets", mock.Anything, mock.Anything).
+					Return(model.ProductFacets{}, errors.New("any"))
+			},
+			want:       api.ProductListResponse{},
+			statusCode: http.StatusInternalServerError,
 		},
 		{
-			name: "error when get product",
+			name: "error when search product",
 			args: args{
-				ctx:       context.Background(),
-				productID: 4,
-				req: api.ReviewProductRequest{
-					Rating:  4,
-					Comment: "comment",
+				ctx: context.Background(),
+				filter: api.GetProductListFilter{
+					Search: "keju",
 				},
 			},
 			prepare: func() {
-				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
-					Return(model.Product{}, errors.New("any"))
+				mockSearcher.On("Search", mock.Anything, "keju").
+					Return(nil, errors.New("any"))
 			},
-			want:       api.MutationResponse{},
+			want:       api.ProductListResponse{},
 			statusCode: http.StatusInternalServerError,
 		},
 		{
-			name: "product not found",
+			name: "search without hit",
 			args: args{
-				ctx:       context.Background(),
-				productID: 4,
-				req: api.ReviewProductRequest{
-					Rating:  4,
-					Comment: "comment",
+				ctx: context.Background(),
+				filter: api.GetProductListFilter{
+					Search: "keju",
 				},
 			},
 			prepare: func() {
-				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
-					Return(model.Product{}, sql.ErrNoRows)
+				mockSearcher.On("Search", mock.Anything, "keju").
+					Return([]model.SearchHit{}, nil)
 			},
-			want:       api.MutationResponse{},
-			statusCode: http.StatusNotFound,
+			want: api.ProductListResponse{
+				Items: []api.Product{},
+				Pagination: api.Pagination{
+					Page: 1,
+					Size: 10,
+				},
+			},
+			statusCode: http.StatusOK,
 		},
 		{
-			name: "error when get product",
+			name: "literal wildcard searched by repository",
 			args: args{
-				ctx:       context.Background(),
-				productID: 4,
-				req: api.ReviewProductRequest{
-					Rating:  4,
-					Comment: "comment",
+				ctx: context.Background(),
+				filter: api.GetProductListFilter{
+					Search: "50%",
 				},
 			},
 			prepare: func() {
-				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
-					Return(model.Product{}, sql.ErrNoRows)
+				mockSearcher.On("Search", mock.Anything, "50%").
+					Return(nil, model.ErrUnsupportedQuery)
+				productFilter := model.GetProductListFilter{
+					Search: "50%",
+					Limit:  11,
+				}
+				mockProductRepo.On("GetProductList", mock.Anything, productFilter).
+					Return([]model.Product{
+						{ID: 6, SKU: "IND006", Title: "Diskon 50%", Relevance: 1.5},
+					}, nil)
+				mockProductRepo.On("CountProductList", mock.Anything, productFilter).
+					Return(int64(1), nil)
+				mockProductRepo.On("GetProductFacets", mock.Anything, productFilter).
+					Return(model.ProductFacets{}, nil)
+				mockCategoryRepo.On("GetCategoryList", mock.Anything).
+					Return([]model.Category{}, nil)
 			},
-			want:       api.MutationResponse{},
-			statusCode: http.StatusNotFound,
+			want: api.ProductListResponse{
+				Items: []api.Product{
+					{ID: 6, SKU: "IND006", Title: "Diskon 50%", Relevance: 1.5},
+				},
+				Pagination: api.Pagination{
+					Page:       1,
+					Size:       10,
+					TotalItems: 1,
+					TotalPages: 1,
+				},
+			},
+			statusCode: http.StatusOK,
 		},
 		{
-			name: "error when get product review statistic",
+			name: "search capped at max hits",
 			args: args{
-				ctx:       context.Background(),
-				productID: 4,
-				req: api.ReviewProductRequest{
-					Rating:  4,
-					Comment: "comment",
+				ctx: context.Background(),
+				filter: api.GetProductListFilter{
+					Search: "keripik",
 				},
 			},
 			prepare: func() {
-				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
-					Return(model.Product{}, nil)
-				mockReviewRepo.On("GetReviewStatistic", mock.Anything, int64(4)).
-					Return(model.Statistic{}, errors.New("any"))
+				hits := make([]model.SearchHit, maxSearchHits+1)
+				for i := range hits {
+					hits[i] = model.SearchHit{ProductID: int64(i + 1), Score: 1}
+				}
+				mockSearcher.On("Search", mock.Anything, "keripik").
+					Return(hits, nil)
+				mockProductRepo.On("GetProductList", mock.Anything, mock.MatchedBy(func(filter model.GetProductListFilter) bool {
+					return len(filter.IDs) == maxSearchHits
+				})).Return([]model.Product{}, nil)
+				mockProductRepo.On("CountProductList", mock.Anything, mock.Anything).
+					Return(int64(maxSearchHits), nil)
+				mockProductRepo.On("GetProductFacets", mock.Anything, mock.Anything).
+					Return(model.ProductFacets{}, nil)
+				mockCategoryRepo.On("GetCategoryList", mock.Anything).
+					Return([]model.Category{}, nil).Maybe()
 			},
+			want: api.ProductListResponse{
+				Items: []api.Product{},
+				Pagination: api.Pagination{
+					Page:       1,
+					Size:       10,
+					TotalItems: maxSearchHits,
+					TotalPages: maxSearchHits / 10,
+					Next:       "/products?page=2&search=keripik&size=10",
+				},
+				Truncated: true,
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name: "search hydrated from repository",
+			args: args{
+				ctx: context.Background(),
+				filter: api.GetProductListFilter{
+					Search:      "keju",
+					CategoryIDs: []int64{1},
+					SortColumn:  "relevance",
+				},
+			},
+			prepare: func() {
+				productFilter := model.GetProductListFilter{
+					IDs:         []int64{3, 1},
+					CategoryIDs: []int64{1},
+					SortColumn:  "relevance",
+					Limit:       11,
+					Offset:      0,
+				}
+				mockSearcher.On("Search", mock.Anything, "keju").
+					Return([]model.SearchHit{
+						{ProductID: 3, Score: 2.5},
+						{ProductID: 1, Score: 0.6},
+					}, nil)
+				mockProductRepo.On("GetProductList", mock.Anything, productFilter).
+					Return([]model.Product{
+						{
+							ID:    3,
+							SKU:   "IND003",
+							Title: "Pototo Keju",
+						},
+						{
+							ID:    1,
+							SKU:   "IND001",
+							Title: "Kerupuk",
+						},
+					}, nil)
+				mockProductRepo.On("CountProductList", mock.Anything, productFilter).
+					Return(int64(2), nil)
+				mockProductRepo.On("GetProductFacets", mock.Anything, productFilter).
+					Return(model.ProductFacets{}, nil)
+				mockCategoryRepo.On("GetCategoryList", mock.Anything).
+					Return([]model.Category{}, nil)
+			},
+			want: api.ProductListResponse{
+				Items: []api.Product{
+					{
+						ID:        3,
+						SKU:       "IND003",
+						Title:     "Pototo Keju",
+						Relevance: 2.5,
+					},
+					{
+						ID:        1,
+						SKU:       "IND001",
+						Title:     "Kerupuk",
+						Relevance: 0.6,
+					},
+				},
+				Pagination: api.Pagination{
+					Page:       1,
+					Size:       10,
+					TotalItems: 2,
+					TotalPages: 1,
+				},
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name: "success",
+			args: args{
+				ctx: context.Background(),
+				filter: api.GetProductListFilter{
+					CategoryIDs: []int64{1, 3},
+					MinPrice:    5000,
+					MaxPrice:    20000,
+					MinRating:   3,
+					Page:        2,
+					Size:        1,
+				},
+			},
+			prepare: func() {
+				productFilter := model.GetProductListFilter{
+					CategoryIDs: []int64{1, 3},
+					MinPrice:    5000,
+					MaxPrice:    20000,
+					MinRating:   3,
+					Limit:       2,
+					Offset:      1,
+				}
+				mockProductRepo.On("GetProductList", mock.Anything, productFilter).
+					Return([]model.Product{
+						{
+							ID:          1,
+							SKU:         "IND001",
+							Title:       "title",
+							Description: "description",
+							Category: model.Category{
+								ID:   1,
+								Name: "name",
+							},
+							ImageURL: "https://foo.bar/image.jpg",
+							Weight:   1,
+							Price:    10000,
+							Rating:   3.2,
+						},
+					}, nil)
+				mockProductRepo.On("CountProductList", mock.Anything, productFilter).
+					Return(int64(3), nil)
+				mockProductRepo.On("GetProductFacets", mock.Anything, productFilter).
+					Return(model.ProductFacets{
+						Categories: []model.CategoryFacet{
+							{Category: model.Category{ID: 1, Name: "name"}, Count: 1},
+						},
+						Prices: []model.PriceFacet{
+							{Min: 0, Max: 10000, Count: 0},
+							{Min: 10000, Max: 0, Count: 1},
+						},
+						Ratings: []model.RatingFacet{
+							{Min: 3, Max: 4, Count: 1},
+						},
+					}, nil)
+				mockCategoryRepo.On("GetCategoryList", mock.Anything).
+					Return([]model.Category{{ID: 1, Name: "name"}, {ID: 3, Name: "other"}}, nil)
+			},
+			want: api.ProductListResponse{
+				Items: []api.Product{
+					{
+						ID:          1,
+						SKU:         "IND001",
+						Title:       "title",
+						Description: "description",
+						Category: api.Category{
+							ID:   1,
+							Name: "name",
+							Path: []api.Category{{ID: 1, Name: "name"}},
+						},
+						ImageURL: "https://foo.bar/image.jpg",
+						Weight:   1,
+						Price:    10000,
+						Rating:   3.2,
+					},
+				},
+				Pagination: api.Pagination{
+					Page:       2,
+					Size:       1,
+					TotalItems: 3,
+					TotalPages: 3,
+					Next:       "/products?category=1%2C3&max_price=20000&min_price=5000&min_rating=3&page=3&size=1",
+					Prev:       "/products?category=1%2C3&max_price=20000&min_price=5000&min_rating=3&page=1&size=1",
+				},
+				Facets: api.ProductFacets{
+					Categories: []api.CategoryFacet{
+						{ID: 1, Name: "name", Count: 1},
+					},
+					Prices: []api.PriceFacet{
+						{Min: 0, Max: int64Ptr(10000), Count: 0},
+						{Min: 10000, Max: nil, Count: 1},
+					},
+					Ratings: []api.RatingFacet{
+						{Min: 3, Max: 4, Count: 1},
+					},
+				},
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name: "include subcategories",
+			args: args{
+				ctx: context.Background(),
+				filter: api.GetProductListFilter{
+					CategoryIDs:          []int64{3},
+					IncludeSubcategories: true,
+					Page:                 1,
+					Size:                 10,
+				},
+			},
+			prepare: func() {
+				mockCategoryRepo.On("GetCategoryList", mock.Anything).
+					Return([]model.Category{
+						{ID: 3, Name: "Furniture"},
+						{ID: 5, Name: "Office", ParentID: 3},
+						{ID: 6, Name: "Chairs", ParentID: 5},
+						{ID: 7, Name: "Desks", ParentID: 5},
+						{ID: 8, Name: "Art"},
+					}, nil)
+				productFilter := model.GetProductListFilter{
+					CategoryIDs: []int64{3, 5, 6, 7},
+					Limit:       11,
+					Offset:      0,
+				}
+				mockProductRepo.On("GetProductList", mock.Anything, productFilter).
+					Return([]model.Product{
+						{
+							ID:       4,
+							SKU:      "IND004",
+							Title:    "Kursi Kantor",
+							Category: model.Category{ID: 6, Name: "Chairs"},
+						},
+					}, nil)
+				mockProductRepo.On("CountProductList", mock.Anything, productFilter).
+					Return(int64(1), nil)
+				mockProductRepo.On("GetProductFacets", mock.Anything, productFilter).
+					Return(model.ProductFacets{}, nil)
+			},
+			want: api.ProductListResponse{
+				Items: []api.Product{
+					{
+						ID:    4,
+						SKU:   "IND004",
+						Title: "Kursi Kantor",
+						Category: api.Category{
+							ID:   6,
+							Name: "Chairs",
+							Path: []api.Category{
+								{ID: 3, Name: "Furniture"},
+								{ID: 5, Name: "Office"},
+								{ID: 6, Name: "Chairs"},
+							},
+						},
+					},
+				},
+				Pagination: api.Pagination{
+					Page:       1,
+					Size:       10,
+					TotalItems: 1,
+					TotalPages: 1,
+				},
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name: "invalid cursor",
+			args: args{
+				ctx: context.Background(),
+				filter: api.GetProductListFilter{
+					Cursor: "forged",
+				},
+			},
+			prepare:    nil,
+			want:       api.ProductListResponse{},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "cursor issued for another sort",
+			args: args{
+				ctx: context.Background(),
+				filter: api.GetProductListFilter{
+					SortColumn: "rating",
+					Cursor:     mustEncodeCursor(productCursor{SortColumn: "created_at", Value: createdAt.Format(time.RFC3339Nano), ID: 2}),
+				},
+			},
+			prepare:    nil,
+			want:       api.ProductListResponse{},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "page after cursor",
+			args: args{
+				ctx: context.Background(),
+				filter: api.GetProductListFilter{
+					SortColumn: "created_at",
+					SortType:   "desc",
+					Cursor:     mustEncodeCursor(productCursor{SortColumn: "created_at", SortType: "desc", Value: createdAt.Format(time.RFC3339Nano), ID: 2}),
+					Size:       1,
+				},
+			},
+			prepare: func() {
+				productFilter := model.GetProductListFilter{
+					SortColumn: "created_at",
+					SortType:   "desc",
+					After:      &model.ProductCursor{Value: createdAt, ID: 2},
+					Limit:      2,
+				}
+				mockProductRepo.On("GetProductList", mock.Anything, productFilter).
+					Return([]model.Product{
+						{ID: 1, SKU: "IND001", CreatedAt: createdAt.Add(-time.Hour)},
+						{ID: 4, SKU: "IND004", CreatedAt: createdAt.Add(-2 * time.Hour)},
+					}, nil)
+				mockProductRepo.On("CountProductList", mock.Anything, productFilter).
+					Return(int64(4), nil)
+				mockProductRepo.On("GetProductFacets", mock.Anything, productFilter).
+					Return(model.ProductFacets{}, nil)
+				mockCategoryRepo.On("GetCategoryList", mock.Anything).
+					Return([]model.Category{}, nil)
+			},
+			want: api.ProductListResponse{
+				Items: []api.Product{
+					{ID: 1, SKU: "IND001"},
+				},
+				Pagination: api.Pagination{
+					Size:       1,
+					TotalItems: 4,
+					TotalPages: 4,
+					Next: "/products?cursor=" + url.QueryEscape(mustEncodeCursor(productCursor{
+						SortColumn: "created_at", SortType: "desc", Value: createdAt.Add(-time.Hour).Format(time.RFC3339Nano), ID: 1,
+					})) + "&size=1&sort=created_at&sort_type=desc",
+					NextCursor: mustEncodeCursor(productCursor{
+						SortColumn: "created_at", SortType: "desc", Value: createdAt.Add(-time.Hour).Format(time.RFC3339Nano), ID: 1,
+					}),
+				},
+			},
+			statusCode: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			initMock()
+			s := &service{
+				productRepo:  mockProductRepo,
+				categoryRepo: mockCategoryRepo,
+				reviewRepo:   mockReviewRepo,
+				searcher:     mockSearcher,
+				cursorKey:    testCursorKey,
+			}
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+			got, err := s.GetProductList(tt.args.ctx, tt.args.filter)
+			if errorhelper.GetCode(err) != tt.statusCode {
+				t.Errorf("GetProducList() status code = %v, want %v", errorhelper.GetCode(err), tt.statusCode)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetProductList() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_newPagination(t *testing.T) {
+	link := func(page int64) string {
+		return fmt.Sprintf("/products?page=%d", page)
+	}
+	tests := []struct {
+		name  string
+		page  int64
+		size  int64
+		total int64
+		want  api.Pagination
+	}{
+		{
+			name:  "empty list",
+			page:  1,
+			size:  10,
+			total: 0,
+			want:  api.Pagination{Page: 1, Size: 10},
+		},
+		{
+			name:  "first page",
+			page:  1,
+			size:  10,
+			total: 25,
+			want:  api.Pagination{Page: 1, Size: 10, TotalItems: 25, TotalPages: 3, Next: "/products?page=2"},
+		},
+		{
+			name:  "last page",
+			page:  3,
+			size:  10,
+			total: 25,
+			want:  api.Pagination{Page: 3, Size: 10, TotalItems: 25, TotalPages: 3, Prev: "/products?page=2"},
+		},
+		{
+			name:  "page after the last one",
+			page:  7,
+			size:  10,
+			total: 25,
+			want:  api.Pagination{Page: 7, Size: 10, TotalItems: 25, TotalPages: 3, Prev: "/products?page=3"},
+		},
+		{
+			name:  "page after an empty list",
+			page:  2,
+			size:  10,
+			total: 0,
+			want:  api.Pagination{Page: 2, Size: 10, Prev: "/products?page=1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newPagination(tt.page, tt.size, tt.total, link); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newPagination() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+func stringPtr(v string) *string {
+	return &v
+}
+
+var (
+	testCursorKey = []byte("test-cursor-key")
+	createdAt     = time.Date(2023, 12, 1, 10, 0, 0, 0, time.UTC)
+)
+
+func mustEncodeCursor(cursor productCursor) string {
+	token, err := cursorhelper.Encode(testCursorKey, cursor)
+	if err != nil {
+		panic(err)
+	}
+	return token
+}
+
+func Test_service_ReviewProduct(t *testing.T) {
+	ratingPrior := model.RatingPrior{Mean: 3, Weight: 10}
+	reviewerCtx := authhelper.WithActor(context.Background(), authhelper.Actor{ID: "u1", Name: "Budi"})
+
+	type args struct {
+		ctx       context.Context
+		productID int64
+		req       api.ReviewProductRequest
+	}
+	tests := []struct {
+		name       string
+		args       args
+		prepare    func()
+		want       api.MutationResponse
+		statusCode int
+	}{
+		{
+			name:       "invalid product id",
+			args:       args{},
+			prepare:    nil,
+			want:       api.MutationResponse{},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "invalid payload request",
+			args: args{
+				ctx:       reviewerCtx,
+				productID: 2,
+				req:       api.ReviewProductRequest{},
+			},
+			prepare:    nil,
+			want:       api.MutationResponse{},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "anonymous reviewer",
+			args: args{
+				ctx:       context.Background(),
+				productID: 4,
+				req: api.ReviewProductRequest{
+					Rating:  4,
+					Comment: "comment",
+				},
+			},
+			prepare:    nil,
+			want:       api.MutationResponse{},
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name: "reviewer named anonymous",
+			args: args{
+				ctx:       authhelper.WithActor(context.Background(), authhelper.Actor{ID: "anonymous"}),
+				productID: 4,
+				req: api.ReviewProductRequest{
+					Rating:  4,
+					Comment: "comment",
+				},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{}, sql.ErrNoRows)
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name: "error when get product",
+			args: args{
+				ctx:       reviewerCtx,
+				productID: 4,
+				req: api.ReviewProductRequest{
+					Rating:  4,
+					Comment: "comment",
+				},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{}, errors.New("any"))
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name: "product not found",
+			args: args{
+				ctx:       reviewerCtx,
+				productID: 4,
+				req: api.ReviewProductRequest{
+					Rating:  4,
+					Comment: "comment",
+				},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{}, sql.ErrNoRows)
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name: "error when save user",
+			args: args{
+				ctx:       reviewerCtx,
+				productID: 4,
+				req: api.ReviewProductRequest{
+					Rating:  4,
+					Comment: "comment",
+				},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockUserRepo.On("UpsertUser", mock.Anything, mock.Anything).
+					Return(int64(0), errors.New("any"))
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name: "error when get user review",
+			args: args{
+				ctx:       reviewerCtx,
+				productID: 4,
+				req: api.ReviewProductRequest{
+					Rating:  4,
+					Comment: "comment",
+				},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockUserRepo.On("UpsertUser", mock.Anything, model.User{Subject: "u1", Name: "Budi"}).
+					Return(int64(7), nil)
+				mockReviewRepo.On("GetUserReview", mock.Anything, int64(4), int64(7)).
+					Return(model.ProductReview{}, errors.New("any"))
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name: "error when screen review",
+			args: args{
+				ctx:       reviewerCtx,
+				productID: 4,
+				req: api.ReviewProductRequest{
+					Rating:  4,
+					Comment: "comment",
+				},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockUserRepo.On("UpsertUser", mock.Anything, model.User{Subject: "u1", Name: "Budi"}).
+					Return(int64(7), nil)
+				mockReviewRepo.On("GetUserReview", mock.Anything, int64(4), int64(7)).
+					Return(model.ProductReview{}, sql.ErrNoRows)
+				mockScreener.On("Screen", mock.Anything, model.ProductReview{UserID: 7, ProductID: 4, Rating: 4, Comment: "comment"}).
+					Return("", errors.New("any"))
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name: "error when insert product review",
+			args: args{
+				ctx:       reviewerCtx,
+				productID: 4,
+				req: api.ReviewProductRequest{
+					Rating:  4,
+					Comment: "comment",
+				},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockUserRepo.On("UpsertUser", mock.Anything, model.User{Subject: "u1", Name: "Budi"}).
+					Return(int64(7), nil)
+				mockReviewRepo.On("GetUserReview", mock.Anything, int64(4), int64(7)).
+					Return(model.ProductReview{}, sql.ErrNoRows)
+				mockScreener.On("Screen", mock.Anything, mock.Anything).Return("", nil)
+				mockReviewRepo.On("InsertReview", mock.Anything, mock.Anything).Return(errors.New("any"))
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name: "review inserted concurrently",
+			args: args{
+				ctx:       reviewerCtx,
+				productID: 4,
+				req: api.ReviewProductRequest{
+					Rating:  4,
+					Comment: "comment",
+				},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockUserRepo.On("UpsertUser", mock.Anything, model.User{Subject: "u1", Name: "Budi"}).
+					Return(int64(7), nil)
+				mockReviewRepo.On("GetUserReview", mock.Anything, int64(4), int64(7)).
+					Return(model.ProductReview{}, sql.ErrNoRows)
+				mockScreener.On("Screen", mock.Anything, mock.Anything).Return("", nil)
+				mockReviewRepo.On("InsertReview", mock.Anything, mock.Anything).Return(model.ErrDuplicateReview)
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusConflict,
+		},
+		{
+			name: "error when update product review",
+			args: args{
+				ctx:       reviewerCtx,
+				productID: 4,
+				req: api.ReviewProductRequest{
+					Rating:  4,
+					Comment: "comment",
+				},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockUserRepo.On("UpsertUser", mock.Anything, model.User{Subject: "u1", Name: "Budi"}).
+					Return(int64(7), nil)
+				mockReviewRepo.On("GetUserReview", mock.Anything, int64(4), int64(7)).
+					Return(model.ProductReview{ID: 3, UserID: 7, ProductID: 4, Rating: 2, Comment: "old", Status: "approved"}, nil)
+				mockScreener.On("Screen", mock.Anything, mock.Anything).Return("", nil)
+				mockReviewRepo.On("UpdateReview", mock.Anything, mock.Anything).Return(errors.New("any"))
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name: "error when update product rating",
+			args: args{
+				ctx:       reviewerCtx,
+				productID: 4,
+				req: api.ReviewProductRequest{
+					Rating:  4,
+					Comment: "comment",
+				},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockUserRepo.On("UpsertUser", mock.Anything, model.User{Subject: "u1", Name: "Budi"}).
+					Return(int64(7), nil)
+				mockReviewRepo.On("GetUserReview", mock.Anything, int64(4), int64(7)).
+					Return(model.ProductReview{}, sql.ErrNoRows)
+				mockScreener.On("Screen", mock.Anything, mock.Anything).Return("", nil)
+				mockReviewRepo.On("InsertReview", mock.Anything, model.ProductReview{UserID: 7, ProductID: 4, Rating: 4, Comment: "comment", Status: "approved"}).
+					Return(nil)
+				mockProductRepo.On("AddProductRating", mock.Anything, int64(4), model.RatingDelta{Sum: 4, Count: 1}, ratingPrior, mock.Anything).
+					Return(errors.New("any"))
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name: "error when update product rating of replaced review",
+			args: args{
+				ctx:       reviewerCtx,
+				productID: 4,
+				req: api.ReviewProductRequest{
+					Rating:  4,
+					Comment: "comment",
+				},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockUserRepo.On("UpsertUser", mock.Anything, model.User{Subject: "u1", Name: "Budi"}).
+					Return(int64(7), nil)
+				mockReviewRepo.On("GetUserReview", mock.Anything, int64(4), int64(7)).
+					Return(model.ProductReview{ID: 3, UserID: 7, ProductID: 4, Rating: 2, Comment: "old", Status: "approved"}, nil)
+				mockScreener.On("Screen", mock.Anything, mock.Anything).Return("", nil)
+				mockReviewRepo.On("UpdateReview", mock.Anything, model.ProductReview{ID: 3, UserID: 7, ProductID: 4, Rating: 4, Comment: "comment", Status: "approved"}).
+					Return(nil)
+				mockProductRepo.On("AddProductRating", mock.Anything, int64(4), model.RatingDelta{Sum: 2, Count: 0}, ratingPrior, mock.Anything).
+					Return(errors.New("any"))
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name: "error when commit transaction",
+			args: args{
+				ctx:       reviewerCtx,
+				productID: 4,
+				req: api.ReviewProductRequest{
+					Rating:  4,
+					Comment: "comment",
+				},
+			},
+			prepare: func() {
+				mockTxManager.ExpectedCalls = nil
+				mockTxManager.On("WithinTx", mock.Anything, mock.Anything).
+					Return(func(ctx context.Context, fn func(context.Context) error) error {
+						if err := fn(ctx); err != nil {
+							return err
+						}
+						return errors.New("any")
+					})
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockUserRepo.On("UpsertUser", mock.Anything, model.User{Subject: "u1", Name: "Budi"}).
+					Return(int64(7), nil)
+				mockReviewRepo.On("GetUserReview", mock.Anything, int64(4), int64(7)).
+					Return(model.ProductReview{}, sql.ErrNoRows)
+				mockScreener.On("Screen", mock.Anything, mock.Anything).Return("", nil)
+				mockReviewRepo.On("InsertReview", mock.Anything, model.ProductReview{UserID: 7, ProductID: 4, Rating: 4, Comment: "comment", Status: "approved"}).
+					Return(nil)
+				mockProductRepo.On("AddProductRating", mock.Anything, int64(4), model.RatingDelta{Sum: 4, Count: 1}, ratingPrior, mock.Anything).
+					Return(nil)
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name: "success first review",
+			args: args{
+				ctx:       reviewerCtx,
+				productID: 4,
+				req: api.ReviewProductRequest{
+					Rating:  4,
+					Comment: "comment",
+				},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockUserRepo.On("UpsertUser", mock.Anything, model.User{Subject: "u1", Name: "Budi"}).
+					Return(int64(7), nil)
+				mockReviewRepo.On("GetUserReview", mock.Anything, int64(4), int64(7)).
+					Return(model.ProductReview{}, sql.ErrNoRows)
+				mockScreener.On("Screen", mock.Anything, mock.Anything).Return("", nil)
+				mockReviewRepo.On("InsertReview", mock.Anything, model.ProductReview{UserID: 7, ProductID: 4, Rating: 4, Comment: "comment", Status: "approved"}).
+					Return(nil)
+				mockProductRepo.On("AddProductRating", mock.Anything, int64(4), model.RatingDelta{Sum: 4, Count: 1}, ratingPrior, model.User{Subject: "u1", Name: "Budi"}).
+					Return(nil)
+			},
+			want: api.MutationResponse{
+				Success: true,
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name: "success replacing review",
+			args: args{
+				ctx:       reviewerCtx,
+				productID: 4,
+				req: api.ReviewProductRequest{
+					Rating:  4,
+					Comment: "comment",
+				},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockUserRepo.On("UpsertUser", mock.Anything, model.User{Subject: "u1", Name: "Budi"}).
+					Return(int64(7), nil)
+				mockReviewRepo.On("GetUserReview", mock.Anything, int64(4), int64(7)).
+					Return(model.ProductReview{ID: 3, UserID: 7, ProductID: 4, Rating: 2, Comment: "old", Status: "approved"}, nil)
+				mockScreener.On("Screen", mock.Anything, mock.Anything).Return("", nil)
+				mockReviewRepo.On("UpdateReview", mock.Anything, model.ProductReview{ID: 3, UserID: 7, ProductID: 4, Rating: 4, Comment: "comment", Status: "approved"}).
+					Return(nil)
+				mockProductRepo.On("AddProductRating", mock.Anything, int64(4), model.RatingDelta{Sum: 2, Count: 0}, ratingPrior, mock.Anything).
+					Return(nil)
+			},
+			want: api.MutationResponse{
+				Success: true,
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name: "success held for moderation",
+			args: args{
+				ctx:       reviewerCtx,
+				productID: 4,
+				req: api.ReviewProductRequest{
+					Rating:  1,
+					Comment: "visit my shop",
+				},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockUserRepo.On("UpsertUser", mock.Anything, model.User{Subject: "u1", Name: "Budi"}).
+					Return(int64(7), nil)
+				mockReviewRepo.On("GetUserReview", mock.Anything, int64(4), int64(7)).
+					Return(model.ProductReview{}, sql.ErrNoRows)
+				mockScreener.On("Screen", mock.Anything, mock.Anything).Return("spam", nil)
+				mockReviewRepo.On("InsertReview", mock.Anything, model.ProductReview{UserID: 7, ProductID: 4, Rating: 1, Comment: "visit my shop", Status: "pending", FlagReason: "spam"}).
+					Return(nil)
+			},
+			want: api.MutationResponse{
+				Success: true,
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name: "success replacing approved review with a flagged one",
+			args: args{
+				ctx:       reviewerCtx,
+				productID: 4,
+				req: api.ReviewProductRequest{
+					Rating:  1,
+					Comment: "visit my shop",
+				},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockUserRepo.On("UpsertUser", mock.Anything, model.User{Subject: "u1", Name: "Budi"}).
+					Return(int64(7), nil)
+				mockReviewRepo.On("GetUserReview", mock.Anything, int64(4), int64(7)).
+					Return(model.ProductReview{ID: 3, UserID: 7, ProductID: 4, Rating: 2, Comment: "old", Status: "approved"}, nil)
+				mockScreener.On("Screen", mock.Anything, mock.Anything).Return("spam", nil)
+				mockReviewRepo.On("UpdateReview", mock.Anything, model.ProductReview{ID: 3, UserID: 7, ProductID: 4, Rating: 1, Comment: "visit my shop", Status: "pending", FlagReason: "spam"}).
+					Return(nil)
+				mockProductRepo.On("AddProductRating", mock.Anything, int64(4), model.RatingDelta{Sum: -2, Count: -1}, ratingPrior, mock.Anything).
+					Return(nil)
+			},
+			want: api.MutationResponse{
+				Success: true,
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name: "success replacing comment only",
+			args: args{
+				ctx:       reviewerCtx,
+				productID: 4,
+				req: api.ReviewProductRequest{
+					Rating:  4,
+					Comment: "comment",
+				},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockUserRepo.On("UpsertUser", mock.Anything, model.User{Subject: "u1", Name: "Budi"}).
+					Return(int64(7), nil)
+				mockReviewRepo.On("GetUserReview", mock.Anything, int64(4), int64(7)).
+					Return(model.ProductReview{ID: 3, UserID: 7, ProductID: 4, Rating: 4, Comment: "old", Status: "approved"}, nil)
+				mockScreener.On("Screen", mock.Anything, mock.Anything).Return("", nil)
+				mockReviewRepo.On("UpdateReview", mock.Anything, mock.Anything).Return(nil)
+			},
+			want: api.MutationResponse{
+				Success: true,
+			},
+			statusCode: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			initMock()
+			s := &service{
+				productRepo:  mockProductRepo,
+				categoryRepo: mockCategoryRepo,
+				reviewRepo:   mockReviewRepo,
+				userRepo:     mockUserRepo,
+				txManager:    mockTxManager,
+				searcher:     mockSearcher,
+				screener:     mockScreener,
+				ratingPrior:  ratingPrior,
+			}
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+			got, err := s.ReviewProduct(tt.args.ctx, tt.args.productID, tt.args.req)
+			if errorhelper.GetCode(err) != tt.statusCode {
+				t.Errorf("ReviewProduct() status code = %v, want %v", errorhelper.GetCode(err), tt.statusCode)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReviewProduct() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_service_UpdateReview(t *testing.T) {
+	ratingPrior := model.RatingPrior{Mean: 3, Weight: 10}
+	reviewerCtx := authhelper.WithActor(context.Background(), authhelper.Actor{ID: "u1", Name: "Budi"})
+	ownReview := model.ProductReview{ID: 3, UserID: 7, ProductID: 4, Rating: 2, Comment: "old", Status: "approved", User: model.User{ID: 7, Subject: "u1"}}
+
+	type args struct {
+		ctx       context.Context
+		productID int64
+		reviewID  int64
+		req       api.ReviewProductRequest
+	}
+	tests := []struct {
+		name       string
+		args       args
+		prepare    func()
+		want       api.MutationResponse
+		statusCode int
+	}{
+		{
+			name:       "invalid review id",
+			args:       args{productID: 4},
+			prepare:    nil,
+			want:       api.MutationResponse{},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "invalid payload request",
+			args: args{
+				ctx:       reviewerCtx,
+				productID: 4,
+				reviewID:  3,
+				req:       api.ReviewProductRequest{},
+			},
+			prepare:    nil,
+			want:       api.MutationResponse{},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "anonymous reviewer",
+			args: args{
+				ctx:       context.Background(),
+				productID: 4,
+				reviewID:  3,
+				req:       api.ReviewProductRequest{Rating: 4, Comment: "comment"},
+			},
+			prepare:    nil,
+			want:       api.MutationResponse{},
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name: "product not found",
+			args: args{
+				ctx:       reviewerCtx,
+				productID: 4,
+				reviewID:  3,
+				req:       api.ReviewProductRequest{Rating: 4, Comment: "comment"},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{}, sql.ErrNoRows)
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name: "error when get review",
+			args: args{
+				ctx:       reviewerCtx,
+				productID: 4,
+				reviewID:  3,
+				req:       api.ReviewProductRequest{Rating: 4, Comment: "comment"},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockReviewRepo.On("GetReview", mock.Anything, int64(4), int64(3)).
+					Return(model.ProductReview{}, errors.New("any"))
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name: "review not found",
+			args: args{
+				ctx:       reviewerCtx,
+				productID: 4,
+				reviewID:  3,
+				req:       api.ReviewProductRequest{Rating: 4, Comment: "comment"},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockReviewRepo.On("GetReview", mock.Anything, int64(4), int64(3)).
+					Return(model.ProductReview{}, sql.ErrNoRows)
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name: "review of another user",
+			args: args{
+				ctx:       authhelper.WithActor(context.Background(), authhelper.Actor{ID: "u2"}),
+				productID: 4,
+				reviewID:  3,
+				req:       api.ReviewProductRequest{Rating: 4, Comment: "comment"},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockReviewRepo.On("GetReview", mock.Anything, int64(4), int64(3)).
+					Return(ownReview, nil)
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name: "error when screen review",
+			args: args{
+				ctx:       reviewerCtx,
+				productID: 4,
+				reviewID:  3,
+				req:       api.ReviewProductRequest{Rating: 4, Comment: "comment"},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockReviewRepo.On("GetReview", mock.Anything, int64(4), int64(3)).
+					Return(ownReview, nil)
+				mockScreener.On("Screen", mock.Anything, mock.Anything).Return("", errors.New("any"))
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name: "error when update review",
+			args: args{
+				ctx:       reviewerCtx,
+				productID: 4,
+				reviewID:  3,
+				req:       api.ReviewProductRequest{Rating: 4, Comment: "comment"},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockReviewRepo.On("GetReview", mock.Anything, int64(4), int64(3)).
+					Return(ownReview, nil)
+				mockScreener.On("Screen", mock.Anything, mock.Anything).Return("", nil)
+				mockReviewRepo.On("UpdateReview", mock.Anything, mock.Anything).Return(errors.New("any"))
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name: "error when update product rating",
+			args: args{
+				ctx:       reviewerCtx,
+				productID: 4,
+				reviewID:  3,
+				req:       api.ReviewProductRequest{Rating: 4, Comment: "comment"},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockReviewRepo.On("GetReview", mock.Anything, int64(4), int64(3)).
+					Return(ownReview, nil)
+				mockScreener.On("Screen", mock.Anything, mock.Anything).Return("", nil)
+				mockReviewRepo.On("UpdateReview", mock.Anything, mock.Anything).Return(nil)
+				mockProductRepo.On("AddProductRating", mock.Anything, int64(4), model.RatingDelta{Sum: 2, Count: 0}, ratingPrior, mock.Anything).
+					Return(errors.New("any"))
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name: "success",
+			args: args{
+				ctx:       reviewerCtx,
+				productID: 4,
+				reviewID:  3,
+				req:       api.ReviewProductRequest{Rating: 4, Comment: "comment"},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockReviewRepo.On("GetReview", mock.Anything, int64(4), int64(3)).
+					Return(ownReview, nil)
+				mockScreener.On("Screen", mock.Anything, mock.Anything).Return("", nil)
+				mockReviewRepo.On("UpdateReview", mock.Anything, model.ProductReview{ID: 3, UserID: 7, ProductID: 4, Rating: 4, Comment: "comment", Status: "approved", User: model.User{ID: 7, Subject: "u1"}}).
+					Return(nil)
+				mockProductRepo.On("AddProductRating", mock.Anything, int64(4), model.RatingDelta{Sum: 2, Count: 0}, ratingPrior, mock.Anything).
+					Return(nil)
+			},
+			want: api.MutationResponse{
+				Success: true,
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name: "success comment only",
+			args: args{
+				ctx:       reviewerCtx,
+				productID: 4,
+				reviewID:  3,
+				req:       api.ReviewProductRequest{Rating: 2, Comment: "comment"},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockReviewRepo.On("GetReview", mock.Anything, int64(4), int64(3)).
+					Return(ownReview, nil)
+				mockScreener.On("Screen", mock.Anything, mock.Anything).Return("", nil)
+				mockReviewRepo.On("UpdateReview", mock.Anything, mock.Anything).Return(nil)
+			},
+			want: api.MutationResponse{
+				Success: true,
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name: "success editing rejected review",
+			args: args{
+				ctx:       reviewerCtx,
+				productID: 4,
+				reviewID:  3,
+				req:       api.ReviewProductRequest{Rating: 5, Comment: "comment"},
+			},
+			prepare: func() {
+				rejected := ownReview
+				rejected.Status = "rejected"
+				rejected.FlagReason = "spam"
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockReviewRepo.On("GetReview", mock.Anything, int64(4), int64(3)).
+					Return(rejected, nil)
+				mockScreener.On("Screen", mock.Anything, mock.Anything).Return("", nil)
+				mockReviewRepo.On("UpdateReview", mock.Anything, model.ProductReview{ID: 3, UserID: 7, ProductID: 4, Rating: 5, Comment: "comment", Status: "pending", FlagReason: "spam", User: model.User{ID: 7, Subject: "u1"}}).
+					Return(nil)
+			},
+			want: api.MutationResponse{
+				Success: true,
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name: "success editing pending review",
+			args: args{
+				ctx:       reviewerCtx,
+				productID: 4,
+				reviewID:  3,
+				req:       api.ReviewProductRequest{Rating: 5, Comment: "comment"},
+			},
+			prepare: func() {
+				pending := ownReview
+				pending.Status = "pending"
+				pending.FlagReason = "spam"
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockReviewRepo.On("GetReview", mock.Anything, int64(4), int64(3)).
+					Return(pending, nil)
+				mockScreener.On("Screen", mock.Anything, mock.Anything).Return("", nil)
+				mockReviewRepo.On("UpdateReview", mock.Anything, model.ProductReview{ID: 3, UserID: 7, ProductID: 4, Rating: 5, Comment: "comment", Status: "pending", FlagReason: "spam", User: model.User{ID: 7, Subject: "u1"}}).
+					Return(nil)
+			},
+			want: api.MutationResponse{
+				Success: true,
+			},
+			statusCode: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			initMock()
+			s := &service{
+				productRepo: mockProductRepo,
+				reviewRepo:  mockReviewRepo,
+				txManager:   mockTxManager,
+				screener:    mockScreener,
+				ratingPrior: ratingPrior,
+			}
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+			got, err := s.UpdateReview(tt.args.ctx, tt.args.productID, tt.args.reviewID, tt.args.req)
+			if errorhelper.GetCode(err) != tt.statusCode {
+				t.Errorf("UpdateReview() status code = %v, want %v", errorhelper.GetCode(err), tt.statusCode)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UpdateReview() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_service_DeleteReview(t *testing.T) {
+	ratingPrior := model.RatingPrior{Mean: 3, Weight: 10}
+	reviewerCtx := authhelper.WithActor(context.Background(), authhelper.Actor{ID: "u1", Name: "Budi"})
+	ownReview := model.ProductReview{ID: 3, UserID: 7, ProductID: 4, Rating: 2, Comment: "old", Status: "approved", User: model.User{ID: 7, Subject: "u1"}}
+
+	type args struct {
+		ctx       context.Context
+		productID int64
+		reviewID  int64
+	}
+	tests := []struct {
+		name       string
+		args       args
+		prepare    func()
+		want       api.MutationResponse
+		statusCode int
+	}{
+		{
+			name:       "invalid review id",
+			args:       args{productID: 4},
+			prepare:    nil,
+			want:       api.MutationResponse{},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "anonymous reviewer",
+			args: args{
+				ctx:       context.Background(),
+				productID: 4,
+				reviewID:  3,
+			},
+			prepare:    nil,
+			want:       api.MutationResponse{},
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name: "review not found",
+			args: args{
+				ctx:       reviewerCtx,
+				productID: 4,
+				reviewID:  3,
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockReviewRepo.On("GetReview", mock.Anything, int64(4), int64(3)).
+					Return(model.ProductReview{}, sql.ErrNoRows)
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name: "review of another user",
+			args: args{
+				ctx:       authhelper.WithActor(context.Background(), authhelper.Actor{ID: "u2"}),
+				productID: 4,
+				reviewID:  3,
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockReviewRepo.On("GetReview", mock.Anything, int64(4), int64(3)).
+					Return(ownReview, nil)
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name: "error when delete review",
+			args: args{
+				ctx:       reviewerCtx,
+				productID: 4,
+				reviewID:  3,
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockReviewRepo.On("GetReview", mock.Anything, int64(4), int64(3)).
+					Return(ownReview, nil)
+				mockReviewRepo.On("DeleteReview", mock.Anything, int64(3)).Return(errors.New("any"))
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name: "success",
+			args: args{
+				ctx:       reviewerCtx,
+				productID: 4,
+				reviewID:  3,
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockReviewRepo.On("GetReview", mock.Anything, int64(4), int64(3)).
+					Return(ownReview, nil)
+				mockReviewRepo.On("DeleteReview", mock.Anything, int64(3)).Return(nil)
+				mockProductRepo.On("AddProductRating", mock.Anything, int64(4), model.RatingDelta{Sum: -2, Count: -1}, ratingPrior, mock.Anything).
+					Return(nil)
+			},
+			want: api.MutationResponse{
+				Success: true,
+			},
+			statusCode: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			initMock()
+			s := &service{
+				productRepo: mockProductRepo,
+				reviewRepo:  mockReviewRepo,
+				txManager:   mockTxManager,
+				ratingPrior: ratingPrior,
+			}
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+			got, err := s.DeleteReview(tt.args.ctx, tt.args.productID, tt.args.reviewID)
+			if errorhelper.GetCode(err) != tt.statusCode {
+				t.Errorf("DeleteReview() status code = %v, want %v", errorhelper.GetCode(err), tt.statusCode)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DeleteReview() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_service_UpdateProduct(t *testing.T) {
+
+	type args struct {
+		ctx     context.Context
+		req     api.Product
+		id      int64
+		version int64
+	}
+	tests := []struct {
+		name       string
+		args       args
+		prepare    func()
+		want       api.MutationResponse
+		statusCode int
+	}{
+		{
+			name: "invalid id",
+			args: args{
+				ctx: context.Background(),
+				req: api.Product{},
+				id:  0,
+			},
+			prepare:    nil,
+			want:       api.MutationResponse{},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "invalid request",
+			args: args{
+				ctx: context.Background(),
+				req: api.Product{},
+				id:  5,
+			},
+			prepare:    nil,
+			want:       api.MutationResponse{},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "missing if match",
+			args: args{
+				ctx: context.Background(),
+				id:  5,
+				req: api.Product{
+					SKU:         "IND005",
+					Title:       "title",
+					Description: "description",
+					Category: api.Category{
+						ID: 5,
+					},
+					ImageURL: "https://foo.bar/bar.jpg",
+					Weight:   3,
+					Price:    20000,
+				},
+			},
+			prepare:    nil,
+			want:       api.MutationResponse{},
+			statusCode: http.StatusPreconditionRequired,
+		},
+		{
+			name: "stale version",
+			args: args{
+				ctx: context.Background(),
+				id:  5,
+				req: api.Product{
+					SKU:         "IND005",
+					Title:       "title",
+					Description: "description",
+					Category: api.Category{
+						ID: 5,
+					},
+					ImageURL: "https://foo.bar/bar.jpg",
+					Weight:   3,
+					Price:    20000,
+				},
+				version: 1,
+			},
+			prepare: func() {
+				mockCategoryRepo.On("GetCategory", mock.Anything, int64(5)).
+					Return(model.Category{}, nil)
+				mockProductRepo.On("GetProduct", mock.Anything, int64(5)).
+					Return(model.Product{ID: 5, SKU: "IND005", Version: 2}, nil)
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name: "updated concurrently",
+			args: args{
+				ctx: context.Background(),
+				id:  5,
+				req: api.Product{
+					SKU:         "IND005",
+					Title:       "title",
+					Description: "description",
+					Category: api.Category{
+						ID: 5,
+					},
+					ImageURL: "https://foo.bar/bar.jpg",
+					Weight:   3,
+					Price:    20000,
+				},
+				version: 1,
+			},
+			prepare: func() {
+				mockCategoryRepo.On("GetCategory", mock.Anything, int64(5)).
+					Return(model.Category{}, nil)
+				mockProductRepo.On("GetProduct", mock.Anything, int64(5)).
+					Return(model.Product{ID: 5, SKU: "IND005", Version: 1}, nil)
+				mockProductRepo.On("UpdateProduct", mock.Anything, int64(5), mock.Anything, mock.Anything).
+					Return(model.ErrVersionConflict)
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name: "error when get category",
+			args: args{
+				ctx: context.Background(),
+				id:  5,
+				req: api.Product{
+					SKU:         "IND005",
+					Title:       "title",
+					Description: "description",
+					Category: api.Category{
+						ID: 5,
+					},
+					ImageURL: "https://foo.bar/bar.jpg",
+					Weight:   3,
+					Price:    20000,
+				},
+				version: 1,
+			},
+			prepare: func() {
+				mockCategoryRepo.On("GetCategory", mock.Anything, int64(5)).
+					Return(model.Category{}, errors.New("any"))
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name: "category not found",
+			args: args{
+				ctx: context.Background(),
+				id:  5,
+				req: api.Product{
+					SKU:         "IND005",
+					Title:       "title",
+					Description: "description",
+					Category: api.Category{
+						ID: 5,
+					},
+					ImageURL: "https://foo.bar/bar.jpg",
+					Weight:   3,
+					Price:    20000,
+				},
+				version: 1,
+			},
+			prepare: func() {
+				mockCategoryRepo.On("GetCategory", mock.Anything, int64(5)).
+					Return(model.Category{}, sql.ErrNoRows)
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "product not found",
+			args: args{
+				ctx: context.Background(),
+				id:  5,
+				req: api.Product{
+					SKU:         "IND005",
+					Title:       "title",
+					Description: "description",
+					Category: api.Category{
+						ID: 5,
+					},
+					ImageURL: "https://foo.bar/bar.jpg",
+					Weight:   3,
+					Price:    20000,
+				},
+				version: 1,
+			},
+			prepare: func() {
+				mockCategoryRepo.On("GetCategory", mock.Anything, int64(5)).
+					Return(model.Category{}, nil)
+				mockProductRepo.On("GetProduct", mock.Anything, int64(5)).
+					Return(model.Product{}, sql.ErrNoRows)
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name: "sku already exist",
+			args: args{
+				ctx: context.Background(),
+				id:  5,
+				req: api.Product{
+					SKU:         "IND001",
+					Title:       "title",
+					Description: "description",
+					Category: api.Category{
+						ID: 5,
+					},
+					ImageURL: "https://foo.bar/bar.jpg",
+					Weight:   3,
+					Price:    20000,
+				},
+				version: 1,
+			},
+			prepare: func() {
+				mockCategoryRepo.On("GetCategory", mock.Anything, int64(5)).
+					Return(model.Category{}, nil)
+				mockProductRepo.On("GetProduct", mock.Anything, int64(5)).
+					Return(model.Product{ID: 5, SKU: "IND005", Version: 1}, nil)
+				mockProductRepo.On("GetProductBySKU", mock.Anything, "IND001").
+					Return(model.Product{ID: 1, SKU: "IND001"}, nil)
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "product deleted while updating",
+			args: args{
+				ctx: context.Background(),
+				id:  5,
+				req: api.Product{
+					SKU:         "IND005",
+					Title:       "title",
+					Description: "description",
+					Category: api.Category{
+						ID: 5,
+					},
+					ImageURL: "https://foo.bar/bar.jpg",
+					Weight:   3,
+					Price:    20000,
+				},
+				version: 1,
+			},
+			prepare: func() {
+				mockCategoryRepo.On("GetCategory", mock.Anything, int64(5)).
+					Return(model.Category{}, nil)
+				mockProductRepo.On("GetProduct", mock.Anything, int64(5)).
+					Return(model.Product{ID: 5, SKU: "IND005", Version: 1}, nil)
+				mockProductRepo.On("UpdateProduct", mock.Anything, int64(5), mock.Anything, mock.Anything).
+					Return(sql.ErrNoRows)
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name: "error when update product",
+			args: args{
+				ctx: context.Background(),
+				id:  5,
+				req: api.Product{
+					SKU:         "IND005",
+					Title:       "title",
+					Description: "description",
+					Category: api.Category{
+						ID: 5,
+					},
+					ImageURL: "https://foo.bar/bar.jpg",
+					Weight:   3,
+					Price:    20000,
+				},
+				version: 1,
+			},
+			prepare: func() {
+				mockCategoryRepo.On("GetCategory", mock.Anything, int64(5)).
+					Return(model.Category{}, nil)
+				mockProductRepo.On("GetProduct", mock.Anything, int64(5)).
+					Return(model.Product{ID: 5, SKU: "IND005", Version: 1}, nil)
+				mockProductRepo.On("UpdateProduct", mock.Anything, int64(5), mock.Anything, mock.Anything).
+					Return(errors.New("any"))
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name: "success",
+			args: args{
+				ctx: context.Background(),
+				id:  5,
+				req: api.Product{
+					SKU:         "IND005",
+					Title:       "title",
+					Description: "description",
+					Category: api.Category{
+						ID: 5,
+					},
+					ImageURL: "https://foo.bar/bar.jpg",
+					Weight:   3,
+					Price:    20000,
+				},
+				version: 1,
+			},
+			prepare: func() {
+				mockCategoryRepo.On("GetCategory", mock.Anything, int64(5)).
+					Return(model.Category{ID: 5, Name: "Furniture"}, nil)
+				mockProductRepo.On("GetProduct", mock.Anything, int64(5)).
+					Return(model.Product{
+						ID:       5,
+						SKU:      "IND004",
+						ImageURL: "https://foo.bar/foo.jpg",
+						Weight:   2,
+						Price:    15000,
+						Rating:   4.5,
+						Version:  1,
+					}, nil)
+				mockProductRepo.On("GetProductBySKU", mock.Anything, "IND005").
+					Return(model.Product{}, sql.ErrNoRows)
+				mockProductRepo.On("UpdateProduct", mock.Anything, int64(5), model.Product{
+					ID:          5,
+					SKU:         "IND005",
+					Title:       "title",
+					Description: "description",
+					Category:    model.Category{ID: 5, Name: "Furniture"},
+					ImageURL:    "https://foo.bar/bar.jpg",
+					Weight:      3,
+					Price:       20000,
+					Rating:      4.5,
+					Version:     1,
+				}, mock.Anything).Return(nil)
+				mockSearcher.On("IndexProduct", mock.Anything, mock.MatchedBy(func(product model.Product) bool {
+					return product.ID == 5 && product.SKU == "IND005"
+				})).Return(nil)
+			},
+			want: api.MutationResponse{
+				Success: true,
+			},
+			statusCode: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			initMock()
+			s := &service{
+				productRepo:  mockProductRepo,
+				categoryRepo: mockCategoryRepo,
+				reviewRepo:   mockReviewRepo,
+				searcher:     mockSearcher,
+			}
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+			tt.args.req.Version = tt.args.version
+			got, err := s.UpdateProduct(tt.args.ctx, tt.args.id, tt.args.req)
+			if errorhelper.GetCode(err) != tt.statusCode {
+				t.Errorf("UpdateProduct() status code = %v, want %v", errorhelper.GetCode(err), tt.statusCode)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UpdateProduct() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_service_PatchProduct(t *testing.T) {
+	current := model.Product{
+		ID:          5,
+		SKU:         "IND005",
+		Title:       "title",
+		Description: "description",
+		Category:    model.Category{ID: 1, Name: "Food"},
+		ImageURL:    "https://foo.bar/foo.jpg",
+		Weight:      2,
+		Price:       15000,
+		Version:     1,
+	}
+
+	type args struct {
+		ctx   context.Context
+		id    int64
+		patch api.ProductPatch
+	}
+	tests := []struct {
+		name       string
+		args       args
+		prepare    func()
+		want       api.MutationResponse
+		statusCode int
+	}{
+		{
+			name: "invalid id",
+			args: args{
+				ctx: context.Background(),
+				id:  0,
+			},
+			prepare:    nil,
+			want:       api.MutationResponse{},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "invalid patch",
+			args: args{
+				ctx: context.Background(),
+				id:  5,
+				patch: api.ProductPatch{
+					Title: stringPtr(""),
+				},
+			},
+			prepare:    nil,
+			want:       api.MutationResponse{},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "missing if match",
+			args: args{
+				ctx: context.Background(),
+				id:  5,
+				patch: api.ProductPatch{
+					Title: stringPtr("new title"),
+				},
+			},
+			prepare:    nil,
+			want:       api.MutationResponse{},
+			statusCode: http.StatusPreconditionRequired,
+		},
+		{
+			name: "stale version",
+			args: args{
+				ctx: context.Background(),
+				id:  5,
+				patch: api.ProductPatch{
+					Title:   stringPtr("new title"),
+					Version: 1,
+				},
+			},
+			prepare: func() {
+				stale := current
+				stale.Version = 2
+				mockProductRepo.On("GetProduct", mock.Anything, int64(5)).
+					Return(stale, nil)
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name: "product not found",
+			args: args{
+				ctx: context.Background(),
+				id:  5,
+				patch: api.ProductPatch{
+					Title:   stringPtr("new title"),
+					Version: 1,
+				},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(5)).
+					Return(model.Product{}, sql.ErrNoRows)
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name: "category not found",
+			args: args{
+				ctx: context.Background(),
+				id:  5,
+				patch: api.ProductPatch{
+					Category: &api.Category{ID: 9},
+					Version:  1,
+				},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(5)).
+					Return(current, nil)
+				mockCategoryRepo.On("GetCategory", mock.Anything, int64(9)).
+					Return(model.Category{}, sql.ErrNoRows)
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "sku already exist",
+			args: args{
+				ctx: context.Background(),
+				id:  5,
+				patch: api.ProductPatch{
+					SKU:     stringPtr("IND001"),
+					Version: 1,
+				},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(5)).
+					Return(current, nil)
+				mockProductRepo.On("GetProductBySKU", mock.Anything, "IND001").
+					Return(model.Product{ID: 1, SKU: "IND001"}, nil)
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "sku taken by a deleted product",
+			args: args{
+				ctx: context.Background(),
+				id:  5,
+				patch: api.ProductPatch{
+					SKU:     stringPtr("IND001"),
+					Version: 1,
+				},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(5)).
+					Return(current, nil)
+				mockProductRepo.On("GetProductBySKU", mock.Anything, "IND001").
+					Return(model.Product{}, sql.ErrNoRows)
+				mockProductRepo.On("UpdateProduct", mock.Anything, int64(5), mock.Anything, mock.Anything).
+					Return(model.ErrDuplicateSKU)
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "error when update product",
+			args: args{
+				ctx: context.Background(),
+				id:  5,
+				patch: api.ProductPatch{
+					Price:   int64Ptr(20000),
+					Version: 1,
+				},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(5)).
+					Return(current, nil)
+				mockProductRepo.On("UpdateProduct", mock.Anything, int64(5), mock.Anything, mock.Anything).
+					Return(errors.New("any"))
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name: "success",
+			args: args{
+				ctx: context.Background(),
+				id:  5,
+				patch: api.ProductPatch{
+					SKU:      stringPtr("IND005"),
+					Category: &api.Category{ID: 3},
+					Price:    int64Ptr(20000),
+					Version:  1,
+				},
+			},
+			prepare: func() {
+				want := current
+				want.Category = model.Category{ID: 3, Name: "Furniture"}
+				want.Price = 20000
+				mockProductRepo.On("GetProduct", mock.Anything, int64(5)).
+					Return(current, nil)
+				mockCategoryRepo.On("GetCategory", mock.Anything, int64(3)).
+					Return(model.Category{ID: 3, Name: "Furniture"}, nil)
+				mockProductRepo.On("UpdateProduct", mock.Anything, int64(5), want, mock.Anything).
+					Return(nil)
+				mockSearcher.On("IndexProduct", mock.Anything, want).
+					Return(nil)
+			},
+			want: api.MutationResponse{
+				Success: true,
+			},
+			statusCode: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			initMock()
+			s := &service{
+				productRepo:  mockProductRepo,
+				categoryRepo: mockCategoryRepo,
+				reviewRepo:   mockReviewRepo,
+				searcher:     mockSearcher,
+			}
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+			got, err := s.PatchProduct(tt.args.ctx, tt.args.id, tt.args.patch)
+			if errorhelper.GetCode(err) != tt.statusCode {
+				t.Errorf("PatchProduct() status code = %v, want %v", errorhelper.GetCode(err), tt.statusCode)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PatchProduct() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_service_DeleteProduct(t *testing.T) {
+	type args struct {
+		ctx context.Context
+		id  int64
+	}
+	tests := []struct {
+		name       string
+		args       args
+		prepare    func()
+		want       api.MutationResponse
+		statusCode int
+	}{
+		{
+			name: "invalid id",
+			args: args{
+				ctx: context.Background(),
+				id:  0,
+			},
+			prepare:    nil,
+			want:       api.MutationResponse{},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "error when delete product",
+			args: args{
+				ctx: context.Background(),
+				id:  3,
+			},
+			prepare: func() {
+				mockProductRepo.On("DeleteProduct", mock.Anything, int64(3), mock.Anything).
+					Return(errors.New("any"))
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name: "product not found",
+			args: args{
+				ctx: context.Background(),
+				id:  3,
+			},
+			prepare: func() {
+				mockProductRepo.On("DeleteProduct", mock.Anything, int64(3), mock.Anything).
+					Return(sql.ErrNoRows)
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name: "success",
+			args: args{
+				ctx: context.Background(),
+				id:  3,
+			},
+			prepare: func() {
+				mockProductRepo.On("DeleteProduct", mock.Anything, int64(3), mock.Anything).
+					Return(nil)
+				mockSearcher.On("RemoveProduct", mock.Anything, int64(3)).
+					Return(nil)
+			},
+			want: api.MutationResponse{
+				Success: true,
+			},
+			statusCode: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			initMock()
+			s := &service{
+				productRepo:  mockProductRepo,
+				categoryRepo: mockCategoryRepo,
+				reviewRepo:   mockReviewRepo,
+				searcher:     mockSearcher,
+			}
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+			got, err := s.DeleteProduct(tt.args.ctx, tt.args.id)
+			if errorhelper.GetCode(err) != tt.statusCode {
+				t.Errorf("DeleteProduct() status code = %v, want %v", errorhelper.GetCode(err), tt.statusCode)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DeleteProduct() got = %v, want %v", got, tt.want)
+			}
+			mockSearcher.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_service_RestoreProduct(t *testing.T) {
+	type args struct {
+		ctx context.Context
+		id  int64
+	}
+	tests := []struct {
+		name       string
+		args       args
+		prepare    func()
+		want       api.MutationResponse
+		statusCode int
+	}{
+		{
+			name: "invalid id",
+			args: args{
+				ctx: context.Background(),
+				id:  -1,
+			},
+			prepare:    nil,
+			want:       api.MutationResponse{},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "error when restore product",
+			args: args{
+				ctx: context.Background(),
+				id:  3,
+			},
+			prepare: func() {
+				mockProductRepo.On("RestoreProduct", mock.Anything, int64(3), mock.Anything).
+					Return(errors.New("any"))
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name: "deleted product not found",
+			args: args{
+				ctx: context.Background(),
+				id:  3,
+			},
+			prepare: func() {
+				mockProductRepo.On("RestoreProduct", mock.Anything, int64(3), mock.Anything).
+					Return(sql.ErrNoRows)
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name: "success",
+			args: args{
+				ctx: context.Background(),
+				id:  3,
+			},
+			prepare: func() {
+				product := model.Product{
+					ID:    3,
+					SKU:   "IND003",
+					Title: "Pototo Keju",
+				}
+				mockProductRepo.On("RestoreProduct", mock.Anything, int64(3), mock.Anything).
+					Return(nil)
+				mockProductRepo.On("GetProduct", mock.Anything, int64(3)).
+					Return(product, nil)
+				mockSearcher.On("IndexProduct", mock.Anything, product).
+					Return(nil)
+			},
+			want: api.MutationResponse{
+				Success: true,
+			},
+			statusCode: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			initMock()
+			s := &service{
+				productRepo:  mockProductRepo,
+				categoryRepo: mockCategoryRepo,
+				reviewRepo:   mockReviewRepo,
+				searcher:     mockSearcher,
+			}
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+			got, err := s.RestoreProduct(tt.args.ctx, tt.args.id)
+			if errorhelper.GetCode(err) != tt.statusCode {
+				t.Errorf("RestoreProduct() status code = %v, want %v", errorhelper.GetCode(err), tt.statusCode)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RestoreProduct() got = %v, want %v", got, tt.want)
+			}
+			mockSearcher.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_service_GetProductHistory(t *testing.T) {
+	createdAt := time.Date(2023, 12, 8, 10, 0, 0, 0, time.UTC)
+
+	type args struct {
+		ctx       context.Context
+		productID int64
+		filter    api.PageFilter
+	}
+	tests := []struct {
+		name       string
+		args       args
+		prepare    func()
+		want       api.ProductHistoryResponse
+		statusCode int
+	}{
+		{
+			name: "invalid id",
+			args: args{
+				ctx:       context.Background(),
+				productID: 0,
+			},
+			prepare:    nil,
+			want:       api.ProductHistoryResponse{},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "error when count product history",
+			args: args{
+				ctx:       context.Background(),
+				productID: 3,
+			},
+			prepare: func() {
+				mockHistoryRepo.On("CountProductHistory", mock.Anything, int64(3)).
+					Return(int64(0), errors.New("any"))
+			},
+			want:       api.ProductHistoryResponse{},
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name: "product not found",
+			args: args{
+				ctx:       context.Background(),
+				productID: 3,
+			},
+			prepare: func() {
+				mockHistoryRepo.On("CountProductHistory", mock.Anything, int64(3)).
+					Return(int64(0), nil)
+			},
+			want:       api.ProductHistoryResponse{},
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name: "error when get product history",
+			args: args{
+				ctx:       context.Background(),
+				productID: 3,
+			},
+			prepare: func() {
+				mockHistoryRepo.On("CountProductHistory", mock.Anything, int64(3)).
+					Return(int64(2), nil)
+				mockHistoryRepo.On("GetProductHistory", mock.Anything, int64(3), int64(10), int64(0)).
+					Return(nil, errors.New("any"))
+			},
+			want:       api.ProductHistoryResponse{},
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name: "success",
+			args: args{
+				ctx:       context.Background(),
+				productID: 3,
+				filter: api.PageFilter{
+					Page: 1,
+					Size: 1,
+				},
+			},
+			prepare: func() {
+				mockHistoryRepo.On("CountProductHistory", mock.Anything, int64(3)).
+					Return(int64(2), nil)
+				mockHistoryRepo.On("GetProductHistory", mock.Anything, int64(3), int64(1), int64(0)).
+					Return([]model.ProductHistory{
+						{
+							ID:        2,
+							ProductID: 3,
+							Action:    model.HistoryActionUpdate,
+							ActorID:   "u1",
+							ActorName: "Budi",
+							Before:    &model.ProductSnapshot{SKU: "IND003", Price: 10000, Version: 1},
+							After:     &model.ProductSnapshot{SKU: "IND003", Price: 12000, Version: 2},
+							CreatedAt: createdAt,
+						},
+					}, nil)
+			},
+			want: api.ProductHistoryResponse{
+				Items: []api.ProductHistory{
+					{
+						ID:        2,
+						Action:    "update",
+						Actor:     api.Actor{ID: "u1", Name: "Budi"},
+						Before:    &api.ProductSnapshot{SKU: "IND003", Price: 10000, Version: 1},
+						After:     &api.ProductSnapshot{SKU: "IND003", Price: 12000, Version: 2},
+						CreatedAt: createdAt,
+					},
+				},
+				Pagination: api.Pagination{
+					Page:       1,
+					Size:       1,
+					TotalItems: 2,
+					TotalPages: 2,
+					Next:       "/products/3/history?page=2&size=1",
+				},
+			},
+			statusCode: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			initMock()
+			s := &service{
+				productRepo:  mockProductRepo,
+				categoryRepo: mockCategoryRepo,
+				reviewRepo:   mockReviewRepo,
+				historyRepo:  mockHistoryRepo,
+				searcher:     mockSearcher,
+			}
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+			got, err := s.GetProductHistory(tt.args.ctx, tt.args.productID, tt.args.filter)
+			if errorhelper.GetCode(err) != tt.statusCode {
+				t.Errorf("GetProductHistory() status code = %v, want %v", errorhelper.GetCode(err), tt.statusCode)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetProductHistory() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_service_GetProductPrices(t *testing.T) {
+	effectiveFrom := time.Date(2023, 12, 1, 10, 0, 0, 0, time.UTC)
+	effectiveTo := time.Date(2023, 12, 9, 10, 0, 0, 0, time.UTC)
+
+	type args struct {
+		ctx       context.Context
+		productID int64
+		filter    api.PageFilter
+	}
+	tests := []struct {
+		name       string
+		args       args
+		prepare    func()
+		want       api.ProductPriceResponse
+		statusCode int
+	}{
+		{
+			name: "invalid id",
+			args: args{
+				ctx:       context.Background(),
+				productID: 0,
+			},
+			prepare:    nil,
+			want:       api.ProductPriceResponse{},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "error when count product prices",
+			args: args{
+				ctx:       context.Background(),
+				productID: 3,
+			},
+			prepare: func() {
+				mockPriceRepo.On("CountProductPrices", mock.Anything, int64(3)).
+					Return(int64(0), errors.New("any"))
+			},
+			want:       api.ProductPriceResponse{},
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name: "product not found",
+			args: args{
+				ctx:       context.Background(),
+				productID: 3,
+			},
+			prepare: func() {
+				mockPriceRepo.On("CountProductPrices", mock.Anything, int64(3)).
+					Return(int64(0), nil)
+			},
+			want:       api.ProductPriceResponse{},
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name: "error when get product prices",
+			args: args{
+				ctx:       context.Background(),
+				productID: 3,
+			},
+			prepare: func() {
+				mockPriceRepo.On("CountProductPrices", mock.Anything, int64(3)).
+					Return(int64(2), nil)
+				mockPriceRepo.On("GetProductPrices", mock.Anything, int64(3), int64(10), int64(0)).
+					Return(nil, errors.New("any"))
+			},
+			want:       api.ProductPriceResponse{},
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name: "success",
+			args: args{
+				ctx:       context.Background(),
+				productID: 3,
+				filter: api.PageFilter{
+					Page: 2,
+					Size: 1,
+				},
+			},
+			prepare: func() {
+				mockPriceRepo.On("CountProductPrices", mock.Anything, int64(3)).
+					Return(int64(2), nil)
+				mockPriceRepo.On("GetProductPrices", mock.Anything, int64(3), int64(1), int64(1)).
+					Return([]model.ProductPrice{
+						{
+							ID:            1,
+							ProductID:     3,
+							Price:         10000,
+							EffectiveFrom: effectiveFrom,
+							EffectiveTo:   &effectiveTo,
+						},
+					}, nil)
+			},
+			want: api.ProductPriceResponse{
+				Items: []api.ProductPrice{
+					{
+						Price:         10000,
+						EffectiveFrom: effectiveFrom,
+						EffectiveTo:   &effectiveTo,
+					},
+				},
+				Pagination: api.Pagination{
+					Page:       2,
+					Size:       1,
+					TotalItems: 2,
+					TotalPages: 2,
+					Prev:       "/products/3/prices?page=1&size=1",
+				},
+			},
+			statusCode: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			initMock()
+			s := &service{
+				productRepo:  mockProductRepo,
+				categoryRepo: mockCategoryRepo,
+				reviewRepo:   mockReviewRepo,
+				priceRepo:    mockPriceRepo,
+				searcher:     mockSearcher,
+			}
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+			got, err := s.GetProductPrices(tt.args.ctx, tt.args.productID, tt.args.filter)
+			if errorhelper.GetCode(err) != tt.statusCode {
+				t.Errorf("GetProductPrices() status code = %v, want %v", errorhelper.GetCode(err), tt.statusCode)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetProductPrices() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_service_GetProductReviews(t *testing.T) {
+	createdAt := time.Date(2023, 12, 12, 10, 0, 0, 0, time.UTC)
+
+	type args struct {
+		ctx       context.Context
+		productID int64
+		filter    api.GetReviewListFilter
+	}
+	tests := []struct {
+		name       string
+		args       args
+		prepare    func()
+		want       api.ProductReviewListResponse
+		statusCode int
+	}{
+		{
+			name: "invalid id",
+			args: args{
+				ctx:       context.Background(),
+				productID: 0,
+			},
+			prepare:    nil,
+			want:       api.ProductReviewListResponse{},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "invalid filter",
+			args: args{
+				ctx:       context.Background(),
+				productID: 4,
+				filter:    api.GetReviewListFilter{Sort: "oldest"},
+			},
+			prepare:    nil,
+			want:       api.ProductReviewListResponse{},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "product not found",
+			args: args{
+				ctx:       context.Background(),
+				productID: 4,
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{}, sql.ErrNoRows)
+			},
+			want:       api.ProductReviewListResponse{},
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name: "error when count product reviews",
+			args: args{
+				ctx:       context.Background(),
+				productID: 4,
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockReviewRepo.On("CountReviewList", mock.Anything, mock.Anything).
+					Return(int64(0), errors.New("any"))
+			},
+			want:       api.ProductReviewListResponse{},
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name: "error when get product reviews",
+			args: args{
+				ctx:       context.Background(),
+				productID: 4,
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockReviewRepo.On("CountReviewList", mock.Anything, mock.Anything).
+					Return(int64(3), nil)
+				mockReviewRepo.On("GetReviewList", mock.Anything, mock.Anything).
+					Return(nil, errors.New("any"))
+			},
+			want:       api.ProductReviewListResponse{},
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name: "no reviews",
+			args: args{
+				ctx:       context.Background(),
+				productID: 4,
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockReviewRepo.On("CountReviewList", mock.Anything, mock.Anything).
+					Return(int64(0), nil)
+				mockReviewRepo.On("GetReviewList", mock.Anything, mock.Anything).
+					Return(nil, nil)
+			},
+			want: api.ProductReviewListResponse{
+				Items: []api.ProductReview{},
+				Pagination: api.Pagination{
+					Page: 1,
+					Size: 10,
+				},
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name: "success",
+			args: args{
+				ctx:       context.Background(),
+				productID: 4,
+				filter: api.GetReviewListFilter{
+					Rating: 5,
+					Sort:   "highest",
+					Page:   1,
+					Size:   1,
+				},
+			},
+			prepare: func() {
+				filter := model.GetReviewListFilter{
+					ProductID: 4,
+					Rating:    5,
+					Status:    "approved",
+					Sort:      "highest",
+					Limit:     1,
+					Offset:    0,
+				}
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockReviewRepo.On("CountReviewList", mock.Anything, filter).
+					Return(int64(2), nil)
+				mockReviewRepo.On("GetReviewList", mock.Anything, filter).
+					Return([]model.ProductReview{
+						{
+							ID:        3,
+							UserID:    7,
+							ProductID: 4,
+							Rating:    5,
+							Comment:   "enak",
+							User:      model.User{ID: 7, Subject: "u1", Name: "Budi"},
+							CreatedAt: createdAt,
+							UpdatedAt: createdAt,
+						},
+					}, nil)
+			},
+			want: api.ProductReviewListResponse{
+				Items: []api.ProductReview{
+					{
+						ID:        3,
+						Rating:    5,
+						Comment:   "enak",
+						Reviewer:  api.Actor{ID: "u1", Name: "Budi"},
+						CreatedAt: createdAt,
+						UpdatedAt: createdAt,
+					},
+				},
+				Pagination: api.Pagination{
+					Page:       1,
+					Size:       1,
+					TotalItems: 2,
+					TotalPages: 2,
+					Next:       "/products/4/reviews?page=2&rating=5&size=1&sort=highest",
+				},
+			},
+			statusCode: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			initMock()
+			s := &service{
+				productRepo:  mockProductRepo,
+				categoryRepo: mockCategoryRepo,
+				reviewRepo:   mockReviewRepo,
+				searcher:     mockSearcher,
+			}
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+			got, err := s.GetProductReviews(tt.args.ctx, tt.args.productID, tt.args.filter)
+			if errorhelper.GetCode(err) != tt.statusCode {
+				t.Errorf("GetProductReviews() status code = %v, want %v", errorhelper.GetCode(err), tt.statusCode)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetProductReviews() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_service_VoteReview(t *testing.T) {
+	helpful, unhelpful := true, false
+	voterCtx := authhelper.WithActor(context.Background(), authhelper.Actor{ID: "u2", Name: "Siti"})
+	review := model.ProductReview{ID: 3, UserID: 7, ProductID: 4, Rating: 2, Status: "approved", User: model.User{ID: 7, Subject: "u1"}}
+
+	type args struct {
+		ctx       context.Context
+		productID int64
+		reviewID  int64
+		req       api.VoteReviewRequest
+	}
+	tests := []struct {
+		name       string
+		args       args
+		prepare    func()
+		want       api.MutationResponse
+		statusCode int
+	}{
+		{
+			name:       "invalid review id",
+			args:       args{productID: 4, req: api.VoteReviewRequest{Helpful: &helpful}},
+			prepare:    nil,
+			want:       api.MutationResponse{},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "missing vote",
+			args: args{
+				ctx:       voterCtx,
+				productID: 4,
+				reviewID:  3,
+			},
+			prepare:    nil,
+			want:       api.MutationResponse{},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "anonymous voter",
+			args: args{
+				ctx:       context.Background(),
+				productID: 4,
+				reviewID:  3,
+				req:       api.VoteReviewRequest{Helpful: &helpful},
+			},
+			prepare:    nil,
+			want:       api.MutationResponse{},
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name: "review held for moderation",
+			args: args{
+				ctx:       voterCtx,
+				productID: 4,
+				reviewID:  3,
+				req:       api.VoteReviewRequest{Helpful: &helpful},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockReviewRepo.On("GetReview", mock.Anything, int64(4), int64(3)).
+					Return(model.ProductReview{ID: 3, ProductID: 4, Status: "pending"}, nil)
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name: "vote on own review",
+			args: args{
+				ctx:       authhelper.WithActor(context.Background(), authhelper.Actor{ID: "u1"}),
+				productID: 4,
+				reviewID:  3,
+				req:       api.VoteReviewRequest{Helpful: &helpful},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockReviewRepo.On("GetReview", mock.Anything, int64(4), int64(3)).
+					Return(review, nil)
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name: "concurrent first vote",
+			args: args{
+				ctx:       voterCtx,
+				productID: 4,
+				reviewID:  3,
+				req:       api.VoteReviewRequest{Helpful: &helpful},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockReviewRepo.On("GetReview", mock.Anything, int64(4), int64(3)).
+					Return(review, nil)
+				mockUserRepo.On("UpsertUser", mock.Anything, model.User{Subject: "u2", Name: "Siti"}).
+					Return(int64(8), nil)
+				mockReviewRepo.On("GetReviewVote", mock.Anything, int64(3), int64(8)).
+					Return(model.ReviewVote{}, sql.ErrNoRows)
+				mockReviewRepo.On("InsertReviewVote", mock.Anything, mock.Anything).
+					Return(model.ErrDuplicateVote)
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusConflict,
+		},
+		{
+			name: "error when update review votes",
+			args: args{
+				ctx:       voterCtx,
+				productID: 4,
+				reviewID:  3,
+				req:       api.VoteReviewRequest{Helpful: &helpful},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockReviewRepo.On("GetReview", mock.Anything, int64(4), int64(3)).
+					Return(review, nil)
+				mockUserRepo.On("UpsertUser", mock.Anything, model.User{Subject: "u2", Name: "Siti"}).
+					Return(int64(8), nil)
+				mockReviewRepo.On("GetReviewVote", mock.Anything, int64(3), int64(8)).
+					Return(model.ReviewVote{}, sql.ErrNoRows)
+				mockReviewRepo.On("InsertReviewVote", mock.Anything, mock.Anything).Return(nil)
+				mockReviewRepo.On("AddReviewVotes", mock.Anything, int64(3), mock.Anything).
+					Return(errors.New("any"))
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name: "success first vote",
+			args: args{
+				ctx:       voterCtx,
+				productID: 4,
+				reviewID:  3,
+				req:       api.VoteReviewRequest{Helpful: &helpful},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockReviewRepo.On("GetReview", mock.Anything, int64(4), int64(3)).
+					Return(review, nil)
+				mockUserRepo.On("UpsertUser", mock.Anything, model.User{Subject: "u2", Name: "Siti"}).
+					Return(int64(8), nil)
+				mockReviewRepo.On("GetReviewVote", mock.Anything, int64(3), int64(8)).
+					Return(model.ReviewVote{}, sql.ErrNoRows)
+				mockReviewRepo.On("InsertReviewVote", mock.Anything, model.ReviewVote{ReviewID: 3, UserID: 8, Helpful: true}).
+					Return(nil)
+				mockReviewRepo.On("AddReviewVotes", mock.Anything, int64(3), model.VoteDelta{Helpful: 1, Unhelpful: 0}).
+					Return(nil)
+			},
+			want: api.MutationResponse{
+				Success: true,
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name: "success changing vote",
+			args: args{
+				ctx:       voterCtx,
+				productID: 4,
+				reviewID:  3,
+				req:       api.VoteReviewRequest{Helpful: &unhelpful},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockReviewRepo.On("GetReview", mock.Anything, int64(4), int64(3)).
+					Return(review, nil)
+				mockUserRepo.On("UpsertUser", mock.Anything, model.User{Subject: "u2", Name: "Siti"}).
+					Return(int64(8), nil)
+				mockReviewRepo.On("GetReviewVote", mock.Anything, int64(3), int64(8)).
+					Return(model.ReviewVote{ReviewID: 3, UserID: 8, Helpful: true}, nil)
+				mockReviewRepo.On("UpdateReviewVote", mock.Anything, model.ReviewVote{ReviewID: 3, UserID: 8, Helpful: false}).
+					Return(nil)
+				mockReviewRepo.On("AddReviewVotes", mock.Anything, int64(3), model.VoteDelta{Helpful: -1, Unhelpful: 1}).
+					Return(nil)
+			},
+			want: api.MutationResponse{
+				Success: true,
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name: "success same vote again",
+			args: args{
+				ctx:       voterCtx,
+				productID: 4,
+				reviewID:  3,
+				req:       api.VoteReviewRequest{Helpful: &helpful},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockReviewRepo.On("GetReview", mock.Anything, int64(4), int64(3)).
+					Return(review, nil)
+				mockUserRepo.On("UpsertUser", mock.Anything, model.User{Subject: "u2", Name: "Siti"}).
+					Return(int64(8), nil)
+				mockReviewRepo.On("GetReviewVote", mock.Anything, int64(3), int64(8)).
+					Return(model.ReviewVote{ReviewID: 3, UserID: 8, Helpful: true}, nil)
+			},
+			want: api.MutationResponse{
+				Success: true,
+			},
+			statusCode: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			initMock()
+			s := &service{
+				productRepo: mockProductRepo,
+				reviewRepo:  mockReviewRepo,
+				userRepo:    mockUserRepo,
+				txManager:   mockTxManager,
+			}
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+			got, err := s.VoteReview(tt.args.ctx, tt.args.productID, tt.args.reviewID, tt.args.req)
+			if errorhelper.GetCode(err) != tt.statusCode {
+				t.Errorf("VoteReview() status code = %v, want %v", errorhelper.GetCode(err), tt.statusCode)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("VoteReview() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_service_ReplyReview(t *testing.T) {
+	vendorCtx := authhelper.WithActor(context.Background(), authhelper.Actor{ID: "v1", Name: "Toko Budi"})
+	review := model.ProductReview{ID: 3, UserID: 7, ProductID: 4, Rating: 2, Status: "approved"}
+
+	type args struct {
+		ctx       context.Context
+		productID int64
+		reviewID  int64
+		req       api.ReplyReviewRequest
+	}
+	tests := []struct {
+		name       string
+		args       args
+		prepare    func()
+		want       api.MutationResponse
+		statusCode int
+	}{
+		{
+			name: "empty reply",
+			args: args{
+				ctx:       vendorCtx,
+				productID: 4,
+				reviewID:  3,
+				req:       api.ReplyReviewRequest{Comment: "  "},
+			},
+			prepare:    nil,
+			want:       api.MutationResponse{},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "anonymous vendor",
+			args: args{
+				ctx:       context.Background(),
+				productID: 4,
+				reviewID:  3,
+				req:       api.ReplyReviewRequest{Comment: "terima kasih"},
+			},
+			prepare:    nil,
+			want:       api.MutationResponse{},
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name: "review held for moderation",
+			args: args{
+				ctx:       vendorCtx,
+				productID: 4,
+				reviewID:  3,
+				req:       api.ReplyReviewRequest{Comment: "terima kasih"},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4, VendorID: 9}, nil)
+				mockReviewRepo.On("GetReview", mock.Anything, int64(4), int64(3)).
+					Return(model.ProductReview{ID: 3, ProductID: 4, Status: "pending"}, nil)
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name: "user is not the vendor",
+			args: args{
+				ctx:       vendorCtx,
+				productID: 4,
+				reviewID:  3,
+				req:       api.ReplyReviewRequest{Comment: "terima kasih"},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4, VendorID: 10}, nil)
+				mockReviewRepo.On("GetReview", mock.Anything, int64(4), int64(3)).
+					Return(review, nil)
+				mockUserRepo.On("UpsertUser", mock.Anything, model.User{Subject: "v1", Name: "Toko Budi"}).
+					Return(int64(9), nil)
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name: "product without vendor",
+			args: args{
+				ctx:       vendorCtx,
+				productID: 4,
+				reviewID:  3,
+				req:       api.ReplyReviewRequest{Comment: "terima kasih"},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4}, nil)
+				mockReviewRepo.On("GetReview", mock.Anything, int64(4), int64(3)).
+					Return(review, nil)
+				mockUserRepo.On("UpsertUser", mock.Anything, mock.Anything).
+					Return(int64(9), nil)
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name: "error when save review reply",
+			args: args{
+				ctx:       vendorCtx,
+				productID: 4,
+				reviewID:  3,
+				req:       api.ReplyReviewRequest{Comment: "terima kasih"},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4, VendorID: 9}, nil)
+				mockReviewRepo.On("GetReview", mock.Anything, int64(4), int64(3)).
+					Return(review, nil)
+				mockUserRepo.On("UpsertUser", mock.Anything, mock.Anything).
+					Return(int64(9), nil)
+				mockReviewRepo.On("UpsertReviewReply", mock.Anything, mock.Anything).
+					Return(errors.New("any"))
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name: "success",
+			args: args{
+				ctx:       vendorCtx,
+				productID: 4,
+				reviewID:  3,
+				req:       api.ReplyReviewRequest{Comment: "terima kasih"},
+			},
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4, VendorID: 9}, nil)
+				mockReviewRepo.On("GetReview", mock.Anything, int64(4), int64(3)).
+					Return(review, nil)
+				mockUserRepo.On("UpsertUser", mock.Anything, model.User{Subject: "v1", Name: "Toko Budi"}).
+					Return(int64(9), nil)
+				mockReviewRepo.On("UpsertReviewReply", mock.Anything, model.ReviewReply{ReviewID: 3, VendorID: 9, Comment: "terima kasih"}).
+					Return(nil)
+			},
+			want: api.MutationResponse{
+				Success: true,
+			},
+			statusCode: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			initMock()
+			s := &service{
+				productRepo: mockProductRepo,
+				reviewRepo:  mockReviewRepo,
+				userRepo:    mockUserRepo,
+				txManager:   mockTxManager,
+			}
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+			got, err := s.ReplyReview(tt.args.ctx, tt.args.productID, tt.args.reviewID, tt.args.req)
+			if errorhelper.GetCode(err) != tt.statusCode {
+				t.Errorf("ReplyReview() status code = %v, want %v", errorhelper.GetCode(err), tt.statusCode)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReplyReview() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_service_DeleteReviewReply(t *testing.T) {
+	vendorCtx := authhelper.WithActor(context.Background(), authhelper.Actor{ID: "v1", Name: "Toko Budi"})
+	review := model.ProductReview{ID: 3, UserID: 7, ProductID: 4, Rating: 2, Status: "approved"}
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		prepare    func()
+		want       api.MutationResponse
+		statusCode int
+	}{
+		{
+			name: "user is not the vendor",
+			ctx:  authhelper.WithActor(context.Background(), authhelper.Actor{ID: "u1"}),
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4, VendorID: 9}, nil)
+				mockReviewRepo.On("GetReview", mock.Anything, int64(4), int64(3)).
+					Return(review, nil)
+				mockUserRepo.On("UpsertUser", mock.Anything, mock.Anything).
+					Return(int64(7), nil)
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name: "success",
+			ctx:  vendorCtx,
+			prepare: func() {
+				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
+					Return(model.Product{ID: 4, VendorID: 9}, nil)
+				mockReviewRepo.On("GetReview", mock.Anything, int64(4), int64(3)).
+					Return(review, nil)
+				mockUserRepo.On("UpsertUser", mock.Anything, mock.Anything).
+					Return(int64(9), nil)
+				mockReviewRepo.On("DeleteReviewReply", mock.Anything, int64(3)).Return(nil)
+			},
+			want: api.MutationResponse{
+				Success: true,
+			},
+			statusCode: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			initMock()
+			s := &service{
+				productRepo: mockProductRepo,
+				reviewRepo:  mockReviewRepo,
+				userRepo:    mockUserRepo,
+				txManager:   mockTxManager,
+			}
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+			got, err := s.DeleteReviewReply(tt.ctx, 4, 3)
+			if errorhelper.GetCode(err) != tt.statusCode {
+				t.Errorf("DeleteReviewReply() status code = %v, want %v", errorhelper.GetCode(err), tt.statusCode)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DeleteReviewReply() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_service_GetPendingReviews(t *testing.T) {
+	createdAt := time.Date(2023, 12, 14, 10, 0, 0, 0, time.UTC)
+	adminCtx := authhelper.WithActor(context.Background(), authhelper.Actor{ID: "a1", Roles: []string{"admin"}})
+
+	type args struct {
+		ctx    context.Context
+		filter api.GetPendingReviewListFilter
+	}
+	tests := []struct {
+		name       string
+		args       args
+		prepare    func()
+		want       api.PendingReviewListResponse
+		statusCode int
+	}{
+		{
+			name:       "anonymous user",
+			args:       args{ctx: context.Background()},
+			prepare:    nil,
+			want:       api.PendingReviewListResponse{},
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "user is not an admin",
+			args:       args{ctx: authhelper.WithActor(context.Background(), authhelper.Actor{ID: "u1"})},
+			prepare:    nil,
+			want:       api.PendingReviewListResponse{},
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name: "error when count pending reviews",
+			args: args{ctx: adminCtx},
+			prepare: func() {
+				mockReviewRepo.On("CountReviewList", mock.Anything, mock.Anything).
+					Return(int64(0), errors.New("any"))
+			},
+			want:       api.PendingReviewListResponse{},
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name: "success",
+			args: args{
+				ctx:    adminCtx,
+				filter: api.GetPendingReviewListFilter{Page: 1, Size: 1},
+			},
+			prepare: func() {
+				filter := model.GetReviewListFilter{
+					Status: "pending",
+					Sort:   "oldest",
+					Limit:  1,
+					Offset: 0,
+				}
+				mockReviewRepo.On("CountReviewList", mock.Anything, filter).
+					Return(int64(2), nil)
+				mockReviewRepo.On("GetReviewList", mock.Anything, filter).
+					Return([]model.ProductReview{
+						{
+							ID:         5,
+							UserID:     8,
+							ProductID:  2,
+							Rating:     1,
+							Comment:    "visit my shop",
+							Status:     "pending",
+							FlagReason: "spam",
+							User:       model.User{ID: 8, Subject: "u2"},
+							CreatedAt:  createdAt,
+							UpdatedAt:  createdAt,
+						},
+					}, nil)
+			},
+			want: api.PendingReviewListResponse{
+				Items: []api.PendingReview{
+					{
+						ProductReview: api.ProductReview{
+							ID:        5,
+							Rating:    1,
+							Comment:   "visit my shop",
+							Reviewer:  api.Actor{ID: "u2"},
+							CreatedAt: createdAt,
+							UpdatedAt: createdAt,
+						},
+						ProductID:  2,
+						FlagReason: "spam",
+					},
+				},
+				Pagination: api.Pagination{
+					Page:       1,
+					Size:       1,
+					TotalItems: 2,
+					TotalPages: 2,
+					Next:       "/admin/reviews/pending?page=2&size=1",
+				},
+			},
+			statusCode: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			initMock()
+			s := &service{
+				reviewRepo: mockReviewRepo,
+			}
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+			got, err := s.GetPendingReviews(tt.args.ctx, tt.args.filter)
+			if errorhelper.GetCode(err) != tt.statusCode {
+				t.Errorf("GetPendingReviews() status code = %v, want %v", errorhelper.GetCode(err), tt.statusCode)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetPendingReviews() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_service_ModerateReview(t *testing.T) {
+	ratingPrior := model.RatingPrior{Mean: 3, Weight: 10}
+	adminCtx := authhelper.WithActor(context.Background(), authhelper.Actor{ID: "a1", Roles: []string{"admin"}})
+	pendingReview := model.ProductReview{ID: 5, UserID: 8, ProductID: 2, Rating: 1, Comment: "visit my shop", Status: "pending", FlagReason: "spam"}
+
+	type args struct {
+		ctx      context.Context
+		reviewID int64
+		req      api.ModerateReviewRequest
+	}
+	tests := []struct {
+		name       string
+		args       args
+		prepare    func()
+		want       api.MutationResponse
+		statusCode int
+	}{
+		{
+			name: "user is not an admin",
+			args: args{
+				ctx:      authhelper.WithActor(context.Background(), authhelper.Actor{ID: "u1"}),
+				reviewID: 5,
+				req:      api.ModerateReviewRequest{Status: "approved"},
+			},
+			prepare:    nil,
+			want:       api.MutationResponse{},
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name: "invalid status",
+			args: args{
+				ctx:      adminCtx,
+				reviewID: 5,
+				req:      api.ModerateReviewRequest{Status: "pending"},
+			},
+			prepare:    nil,
+			want:       api.MutationResponse{},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "review not found",
+			args: args{
+				ctx:      adminCtx,
+				reviewID: 5,
+				req:      api.ModerateReviewRequest{Status: "approved"},
+			},
+			prepare: func() {
+				mockReviewRepo.On("GetReviewByID", mock.Anything, int64(5)).
+					Return(model.ProductReview{}, sql.ErrNoRows)
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name: "review already moderated",
+			args: args{
+				ctx:      adminCtx,
+				reviewID: 5,
+				req:      api.ModerateReviewRequest{Status: "rejected"},
+			},
+			prepare: func() {
+				mockReviewRepo.On("GetReviewByID", mock.Anything, int64(5)).
+					Return(model.ProductReview{ID: 5, ProductID: 2, Rating: 1, Status: "approved"}, nil)
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusConflict,
+		},
+		{
+			name: "product not found",
+			args: args{
+				ctx:      adminCtx,
+				reviewID: 5,
+				req:      api.ModerateReviewRequest{Status: "approved"},
+			},
+			prepare: func() {
+				mockReviewRepo.On("GetReviewByID", mock.Anything, int64(5)).
+					Return(pendingReview, nil)
+				mockProductRepo.On("GetProduct", mock.Anything, int64(2)).
+					Return(model.Product{}, sql.ErrNoRows)
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name: "error when update product rating",
+			args: args{
+				ctx:      adminCtx,
+				reviewID: 5,
+				req:      api.ModerateReviewRequest{Status: "approved"},
+			},
+			prepare: func() {
+				mockReviewRepo.On("GetReviewByID", mock.Anything, int64(5)).
+					Return(pendingReview, nil)
+				mockProductRepo.On("GetProduct", mock.Anything, int64(2)).
+					Return(model.Product{ID: 2}, nil)
+				mockReviewRepo.On("UpdateReview", mock.Anything, mock.Anything).Return(nil)
+				mockProductRepo.On("AddProductRating", mock.Anything, int64(2), model.RatingDelta{Sum: 1, Count: 1}, ratingPrior, mock.Anything).
+					Return(errors.New("any"))
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name: "success approve",
+			args: args{
+				ctx:      adminCtx,
+				reviewID: 5,
+				req:      api.ModerateReviewRequest{Status: "approved"},
+			},
+			prepare: func() {
+				mockReviewRepo.On("GetReviewByID", mock.Anything, int64(5)).
+					Return(pendingReview, nil)
+				mockProductRepo.On("GetProduct", mock.Anything, int64(2)).
+					Return(model.Product{ID: 2}, nil)
+				mockReviewRepo.On("UpdateReview", mock.Anything, model.ProductReview{ID: 5, UserID: 8, ProductID: 2, Rating: 1, Comment: "visit my shop", Status: "approved", FlagReason: "spam"}).
+					Return(nil)
+				mockProductRepo.On("AddProductRating", mock.Anything, int64(2), model.RatingDelta{Sum: 1, Count: 1}, ratingPrior, mock.Anything).
+					Return(nil)
+			},
+			want: api.MutationResponse{
+				Success: true,
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name: "success reject",
+			args: args{
+				ctx:      adminCtx,
+				reviewID: 5,
+				req:      api.ModerateReviewRequest{Status: "rejected"},
+			},
+			prepare: func() {
+				mockReviewRepo.On("GetReviewByID", mock.Anything, int64(5)).
+					Return(pendingReview, nil)
+				mockProductRepo.On("GetProduct", mock.Anything, int64(2)).
+					Return(model.Product{ID: 2}, nil)
+				mockReviewRepo.On("UpdateReview", mock.Anything, model.ProductReview{ID: 5, UserID: 8, ProductID: 2, Rating: 1, Comment: "visit my shop", Status: "rejected", FlagReason: "spam"}).
+					Return(nil)
+			},
+			want: api.MutationResponse{
+				Success: true,
+			},
+			statusCode: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			initMock()
+			s := &service{
+				productRepo: mockProductRepo,
+				reviewRepo:  mockReviewRepo,
+				txManager:   mockTxManager,
+				ratingPrior: ratingPrior,
+			}
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+			got, err := s.ModerateReview(tt.args.ctx, tt.args.reviewID, tt.args.req)
+			if errorhelper.GetCode(err) != tt.statusCode {
+				t.Errorf("ModerateReview() status code = %v, want %v", errorhelper.GetCode(err), tt.statusCode)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ModerateReview() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_service_GetCategoryTree(t *testing.T) {
+	initMock()
+	s := &service{
+		categoryRepo: mockCategoryRepo,
+	}
+	mockCategoryRepo.On("GetCategoryList", mock.Anything).
+		Return([]model.Category{
+			{ID: 1, Name: "Food"},
+			{ID: 3, Name: "Furniture"},
+			{ID: 5, Name: "Office", ParentID: 3},
+			{ID: 6, Name: "Chairs", ParentID: 5},
+			{ID: 7, Name: "Desks", ParentID: 5},
+		}, nil)
+
+	got, err := s.GetCategoryTree(context.Background())
+	if err != nil {
+		t.Fatalf("GetCategoryTree() error = %v", err)
+	}
+	want := api.CategoryTreeResponse{
+		Items: []api.CategoryTree{
+			{ID: 1, Name: "Food", Children: []api.CategoryTree{}},
+			{ID: 3, Name: "Furniture", Children: []api.CategoryTree{
+				{ID: 5, Name: "Office", Children: []api.CategoryTree{
+					{ID: 6, Name: "Chairs", Children: []api.CategoryTree{}},
+					{ID: 7, Name: "Desks", Children: []api.CategoryTree{}},
+				}},
+			}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetCategoryTree() got = %v, want %v", got, want)
+	}
+}
+
+func Test_service_GetCategoryBreadcrumbs(t *testing.T) {
+	categories := []model.Category{
+		{ID: 3, Name: "Furniture"},
+		{ID: 5, Name: "Office", ParentID: 3},
+		{ID: 6, Name: "Chairs", ParentID: 5},
+	}
+
+	tests := []struct {
+		name       string
+		id         int64
+		want       api.CategoryListResponse
+		statusCode int
+	}{
+		{
+			name:       "category not found",
+			id:         9,
+			want:       api.CategoryListResponse{},
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name: "root category",
+			id:   3,
+			want: api.CategoryListResponse{
+				Items: []api.Category{{ID: 3, Name: "Furniture"}},
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name: "nested category",
+			id:   6,
+			want: api.CategoryListResponse{
+				Items: []api.Category{
+					{ID: 3, Name: "Furniture"},
+					{ID: 5, Name: "Office"},
+					{ID: 6, Name: "Chairs"},
+				},
+			},
+			statusCode: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			initMock()
+			s := &service{
+				categoryRepo: mockCategoryRepo,
+			}
+			mockCategoryRepo.On("GetCategoryList", mock.Anything).Return(categories, nil)
+			got, err := s.GetCategoryBreadcrumbs(context.Background(), tt.id)
+			if errorhelper.GetCode(err) != tt.statusCode {
+				t.Errorf("GetCategoryBreadcrumbs() status code = %v, want %v", errorhelper.GetCode(err), tt.statusCode)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetCategoryBreadcrumbs() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_service_GetCategory(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         int64
+		prepare    func()
+		want       api.Category
+		statusCode int
+	}{
+		{
+			name: "category not found",
+			id:   9,
+			prepare: func() {
+				mockCategoryRepo.On("GetCategory", mock.Anything, int64(9)).
+					Return(model.Category{}, sql.ErrNoRows)
+			},
+			want:       api.Category{},
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name: "success",
+			id:   2,
+			prepare: func() {
+				mockCategoryRepo.On("GetCategory", mock.Anything, int64(2)).
+					Return(model.Category{ID: 2, Name: "Pet", RatingPrior: &model.RatingPrior{Mean: 4, Weight: 5}}, nil)
+			},
+			want: api.Category{
+				ID:          2,
+				Name:        "Pet",
+				RatingPrior: &api.RatingPrior{Mean: 4, Weight: 5},
+			},
+			statusCode: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			initMock()
+			s := &service{
+				categoryRepo: mockCategoryRepo,
+			}
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+			got, err := s.GetCategory(context.Background(), tt.id)
+			if errorhelper.GetCode(err) != tt.statusCode {
+				t.Errorf("GetCategory() status code = %v, want %v", errorhelper.GetCode(err), tt.statusCode)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetCategory() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_service_CreateCategory(t *testing.T) {
+	adminCtx := authhelper.WithActor(context.Background(), authhelper.Actor{ID: "a1", Roles: []string{"admin"}})
+
+	type args struct {
+		ctx context.Context
+		req api.Category
+	}
+	tests := []struct {
+		name       string
+		args       args
+		prepare    func()
+		want       api.MutationResponse
+		statusCode int
+	}{
+		{
+			name: "user is not an admin",
+			args: args{
+				ctx: authhelper.WithActor(context.Background(), authhelper.Actor{ID: "u1"}),
+				req: api.Category{Name: "Toys"},
+			},
+			prepare:    nil,
 			want:       api.MutationResponse{},
-			statusCode: http.StatusInternalServerError,
+			statusCode: http.StatusForbidden,
 		},
 		{
-			name: "error when insert product review",
+			name: "invalid rating prior",
 			args: args{
-				ctx:       context.Background(),
-				productID: 4,
-				req: api.ReviewProductRequest{
-					Rating:  4,
-					Comment: "comment",
-				},
+				ctx: adminCtx,
+				req: api.Category{Name: "Toys", RatingPrior: &api.RatingPrior{Mean: 6, Weight: 5}},
+			},
+			prepare:    nil,
+			want:       api.MutationResponse{},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "parent not found",
+			args: args{
+				ctx: adminCtx,
+				req: api.Category{Name: "Chairs", ParentID: 9},
 			},
 			prepare: func() {
-				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
-					Return(model.Product{}, nil)
-				mockReviewRepo.On("GetReviewStatistic", mock.Anything, int64(4)).
-					Return(model.Statistic{
-						Count:   10,
-						Average: 3,
-					}, nil)
-				mockReviewRepo.On("InsertReview", mock.Anything, mock.Anything).Return(errors.New("any"))
+				mockCategoryRepo.On("LockCategory", mock.Anything, int64(9)).
+					Return(model.Category{}, sql.ErrNoRows)
 			},
 			want:       api.MutationResponse{},
-			statusCode: http.StatusInternalServerError,
+			statusCode: http.StatusBadRequest,
 		},
 		{
-			name: "error when update product rating",
+			name: "duplicate name",
 			args: args{
-				ctx:       context.Background(),
-				productID: 4,
-				req: api.ReviewProductRequest{
-					Rating:  4,
-					Comment: "comment",
-				},
+				ctx: adminCtx,
+				req: api.Category{Name: "food"},
 			},
 			prepare: func() {
-				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
-					Return(model.Product{}, nil)
-				mockReviewRepo.On("GetReviewStatistic", mock.Anything, int64(4)).
-					Return(model.Statistic{
-						Count:   10,
-						Average: 3,
-					}, nil)
-				mockReviewRepo.On("InsertReview", mock.Anything, mock.Anything).Return(nil)
-				mockProductRepo.On("UpdateProductRating", mock.Anything, int64(4), float64((3*10)+4)/float64(11)).
-					Return(errors.New("any"))
+				mockCategoryRepo.On("InsertCategory", mock.Anything, model.Category{Name: "food"}).
+					Return(int64(0), model.ErrDuplicateCategoryName)
 			},
 			want:       api.MutationResponse{},
-			statusCode: http.StatusInternalServerError,
+			statusCode: http.StatusBadRequest,
 		},
 		{
 			name: "success",
 			args: args{
-				ctx:       context.Background(),
-				productID: 4,
-				req: api.ReviewProductRequest{
-					Rating:  4,
-					Comment: "comment",
-				},
+				ctx: adminCtx,
+				req: api.Category{Name: " Toys ", RatingPrior: &api.RatingPrior{Mean: 4, Weight: 20}},
 			},
 			prepare: func() {
-				mockProductRepo.On("GetProduct", mock.Anything, int64(4)).
-					Return(model.Product{}, nil)
-				mockReviewRepo.On("GetReviewStatistic", mock.Anything, int64(4)).
-					Return(model.Statistic{
-						Count:   10,
-						Average: 3,
-					}, nil)
-				mockReviewRepo.On("InsertReview", mock.Anything, mock.Anything).Return(nil)
-				mockProductRepo.On("UpdateProductRating", mock.Anything, int64(4), float64((3*10)+4)/float64(11)).
-					Return(nil)
+				mockCategoryRepo.On("InsertCategory", mock.Anything, model.Category{Name: "Toys", RatingPrior: &model.RatingPrior{Mean: 4, Weight: 20}}).
+					Return(int64(5), nil)
 			},
 			want: api.MutationResponse{
 				Success: true,
@@ -631,144 +4286,275 @@ func Test_service_ReviewProduct(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			initMock()
 			s := &service{
-				productRepo:  mockProductRepo,
 				categoryRepo: mockCategoryRepo,
-				reviewRepo:   mockReviewRepo,
 			}
 			if tt.prepare != nil {
 				tt.prepare()
 			}
-			got, err := s.ReviewProduct(tt.args.ctx, tt.args.productID, tt.args.req)
+			got, err := s.CreateCategory(tt.args.ctx, tt.args.req)
 			if errorhelper.GetCode(err) != tt.statusCode {
-				t.Errorf("ReviewProduct() status code = %v, want %v", errorhelper.GetCode(err), tt.statusCode)
+				t.Errorf("CreateCategory() status code = %v, want %v", errorhelper.GetCode(err), tt.statusCode)
 				return
 			}
 			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("ReviewProduct() got = %v, want %v", got, tt.want)
+				t.Errorf("CreateCategory() got = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
-func Test_service_UpdateProduct(t *testing.T) {
+func Test_service_UpdateCategory(t *testing.T) {
+	ratingPrior := model.RatingPrior{Mean: 3, Weight: 10}
+	adminCtx := authhelper.WithActor(context.Background(), authhelper.Actor{ID: "a1", Roles: []string{"admin"}})
 
-	type args struct {
-		ctx context.Context
-		req api.Product
-		id  int64
-	}
 	tests := []struct {
 		name       string
-		args       args
+		req        api.Category
 		prepare    func()
 		want       api.MutationResponse
 		statusCode int
 	}{
 		{
-			name: "invalid id",
-			args: args{
-				ctx: context.Background(),
-				req: api.Product{},
-				id:  0,
+			name: "category not found",
+			req:  api.Category{Name: "Pets"},
+			prepare: func() {
+				mockCategoryRepo.On("LockCategory", mock.Anything, int64(2)).
+					Return(model.Category{}, sql.ErrNoRows)
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name: "parent not found",
+			req:  api.Category{Name: "Pet", ParentID: 9},
+			prepare: func() {
+				mockCategoryRepo.On("LockCategory", mock.Anything, int64(2)).
+					Return(model.Category{ID: 2, Name: "Pet"}, nil)
+				mockCategoryRepo.On("LockCategory", mock.Anything, int64(9)).
+					Return(model.Category{}, sql.ErrNoRows)
 			},
-			prepare:    nil,
 			want:       api.MutationResponse{},
 			statusCode: http.StatusBadRequest,
 		},
 		{
-			name: "invalid request",
-			args: args{
-				ctx: context.Background(),
-				req: api.Product{},
-				id:  5,
+			name: "moved under itself",
+			req:  api.Category{Name: "Pet", ParentID: 2},
+			prepare: func() {
+				mockCategoryRepo.On("LockCategory", mock.Anything, int64(2)).
+					Return(model.Category{ID: 2, Name: "Pet"}, nil)
 			},
-			prepare:    nil,
 			want:       api.MutationResponse{},
 			statusCode: http.StatusBadRequest,
 		},
 		{
-			name: "error when get category",
-			args: args{
-				ctx: context.Background(),
-				id:  5,
-				req: api.Product{
-					SKU:         "IND005",
-					Title:       "title",
-					Description: "description",
-					Category: api.Category{
-						ID: 5,
-					},
-				},
+			name: "moved under its subcategory",
+			req:  api.Category{Name: "Pet", ParentID: 6},
+			prepare: func() {
+				mockCategoryRepo.On("LockCategory", mock.Anything, int64(2)).
+					Return(model.Category{ID: 2, Name: "Pet"}, nil)
+				mockCategoryRepo.On("LockCategory", mock.Anything, int64(6)).
+					Return(model.Category{ID: 6, Name: "Cat food", ParentID: 5}, nil)
+				mockCategoryRepo.On("LockCategory", mock.Anything, int64(5)).
+					Return(model.Category{ID: 5, Name: "Cat", ParentID: 2}, nil)
 			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "moved under another category",
+			req:  api.Category{Name: "Pet", ParentID: 5},
 			prepare: func() {
-				mockCategoryRepo.On("GetCategory", mock.Anything, int64(5)).
-					Return(model.Category{}, errors.New("any"))
+				mockCategoryRepo.On("LockCategory", mock.Anything, int64(2)).
+					Return(model.Category{ID: 2, Name: "Pet"}, nil)
+				mockCategoryRepo.On("LockCategory", mock.Anything, int64(5)).
+					Return(model.Category{ID: 5, Name: "Animals", ParentID: 1}, nil)
+				mockCategoryRepo.On("LockCategory", mock.Anything, int64(1)).
+					Return(model.Category{ID: 1, Name: "Living"}, nil)
+				mockCategoryRepo.On("UpdateCategory", mock.Anything, model.Category{ID: 2, Name: "Pet", ParentID: 5}).
+					Return(nil)
+			},
+			want: api.MutationResponse{
+				Success: true,
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name: "duplicate name",
+			req:  api.Category{Name: "Food"},
+			prepare: func() {
+				mockCategoryRepo.On("LockCategory", mock.Anything, int64(2)).
+					Return(model.Category{ID: 2, Name: "Pet"}, nil)
+				mockCategoryRepo.On("UpdateCategory", mock.Anything, model.Category{ID: 2, Name: "Food"}).
+					Return(model.ErrDuplicateCategoryName)
 			},
 			want:       api.MutationResponse{},
-			statusCode: http.StatusInternalServerError,
+			statusCode: http.StatusBadRequest,
 		},
 		{
-			name: "category not found",
-			args: args{
-				ctx: context.Background(),
-				id:  5,
-				req: api.Product{
-					SKU:         "IND005",
-					Title:       "title",
-					Description: "description",
-					Category: api.Category{
-						ID: 5,
-					},
-				},
+			name: "renamed category reindexes its products",
+			req:  api.Category{Name: "Pets"},
+			prepare: func() {
+				mockCategoryRepo.On("LockCategory", mock.Anything, int64(2)).
+					Return(model.Category{ID: 2, Name: "Pet"}, nil)
+				mockCategoryRepo.On("UpdateCategory", mock.Anything, model.Category{ID: 2, Name: "Pets"}).
+					Return(nil)
+				mockProductRepo.On("GetProductList", mock.Anything, model.GetProductListFilter{
+					CategoryIDs: []int64{2},
+					Limit:       reindexBatchSize,
+				}).Return([]model.Product{{ID: 3, Category: model.Category{ID: 2, Name: "Pets"}}}, nil)
+				mockSearcher.On("IndexProduct", mock.Anything, model.Product{ID: 3, Category: model.Category{ID: 2, Name: "Pets"}}).
+					Return(nil)
+			},
+			want: api.MutationResponse{
+				Success: true,
 			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name: "changed prior rescores the products",
+			req:  api.Category{Name: "Pet", RatingPrior: &api.RatingPrior{Mean: 4, Weight: 5}},
 			prepare: func() {
-				mockCategoryRepo.On("GetCategory", mock.Anything, int64(5)).
-					Return(model.Category{}, sql.ErrNoRows)
+				mockCategoryRepo.On("LockCategory", mock.Anything, int64(2)).
+					Return(model.Category{ID: 2, Name: "Pet"}, nil)
+				mockCategoryRepo.On("UpdateCategory", mock.Anything, model.Category{ID: 2, Name: "Pet", RatingPrior: &model.RatingPrior{Mean: 4, Weight: 5}}).
+					Return(nil)
+				mockProductRepo.On("RecomputeRatingScores", mock.Anything, ratingPrior).Return(nil)
+			},
+			want: api.MutationResponse{
+				Success: true,
 			},
+			statusCode: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			initMock()
+			s := &service{
+				productRepo:  mockProductRepo,
+				categoryRepo: mockCategoryRepo,
+				txManager:    mockTxManager,
+				searcher:     mockSearcher,
+				ratingPrior:  ratingPrior,
+			}
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+			got, err := s.UpdateCategory(adminCtx, 2, tt.req)
+			if errorhelper.GetCode(err) != tt.statusCode {
+				t.Errorf("UpdateCategory() status code = %v, want %v", errorhelper.GetCode(err), tt.statusCode)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UpdateCategory() got = %v, want %v", got, tt.want)
+			}
+			mockProductRepo.AssertExpectations(t)
+			mockSearcher.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_service_DeleteCategory(t *testing.T) {
+	ratingPrior := model.RatingPrior{Mean: 3, Weight: 10}
+	adminCtx := authhelper.WithActor(context.Background(), authhelper.Actor{ID: "a1", Roles: []string{"admin"}})
+	categories := []model.Category{{ID: 1, Name: "Food"}, {ID: 2, Name: "Pet"}}
+
+	tests := []struct {
+		name       string
+		req        api.DeleteCategoryRequest
+		prepare    func()
+		want       api.MutationResponse
+		statusCode int
+	}{
+		{
+			name:       "reassign to itself",
+			req:        api.DeleteCategoryRequest{ReassignTo: 2},
+			prepare:    nil,
 			want:       api.MutationResponse{},
 			statusCode: http.StatusBadRequest,
 		},
 		{
-			name: "error when update product",
-			args: args{
-				ctx: context.Background(),
-				id:  5,
-				req: api.Product{
-					SKU:         "IND005",
-					Title:       "title",
-					Description: "description",
-					Category: api.Category{
-						ID: 5,
-					},
-				},
+			name: "category not found",
+			req:  api.DeleteCategoryRequest{},
+			prepare: func() {
+				mockCategoryRepo.On("LockCategory", mock.Anything, int64(2)).
+					Return(model.Category{}, sql.ErrNoRows)
 			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name: "category with subcategories",
+			req:  api.DeleteCategoryRequest{ReassignTo: 1},
 			prepare: func() {
-				mockCategoryRepo.On("GetCategory", mock.Anything, int64(5)).
-					Return(model.Category{}, nil)
-				mockProductRepo.On("UpdateProduct", mock.Anything, int64(5), mock.Anything).
-					Return(errors.New("any"))
+				mockCategoryRepo.On("LockCategory", mock.Anything, int64(2)).
+					Return(model.Category{ID: 2, Name: "Pet"}, nil)
+				mockCategoryRepo.On("GetCategoryList", mock.Anything).
+					Return(append(categories, model.Category{ID: 3, Name: "Cat", ParentID: 2}), nil)
 			},
 			want:       api.MutationResponse{},
-			statusCode: http.StatusInternalServerError,
+			statusCode: http.StatusConflict,
 		},
 		{
-			name: "success",
-			args: args{
-				ctx: context.Background(),
-				id:  5,
-				req: api.Product{
-					SKU:         "IND005",
-					Title:       "title",
-					Description: "description",
-					Category: api.Category{
-						ID: 5,
-					},
-				},
+			name: "category with products",
+			req:  api.DeleteCategoryRequest{},
+			prepare: func() {
+				mockCategoryRepo.On("LockCategory", mock.Anything, int64(2)).
+					Return(model.Category{ID: 2, Name: "Pet"}, nil)
+				mockCategoryRepo.On("GetCategoryList", mock.Anything).
+					Return(categories, nil)
+				mockCategoryRepo.On("DeleteCategory", mock.Anything, int64(2)).
+					Return(model.ErrCategoryInUse)
 			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusConflict,
+		},
+		{
+			name: "reassign category not found",
+			req:  api.DeleteCategoryRequest{ReassignTo: 9},
 			prepare: func() {
-				mockCategoryRepo.On("GetCategory", mock.Anything, int64(5)).
-					Return(model.Category{}, nil)
-				mockProductRepo.On("UpdateProduct", mock.Anything, int64(5), mock.Anything).
+				mockCategoryRepo.On("LockCategory", mock.Anything, int64(2)).
+					Return(model.Category{ID: 2, Name: "Pet"}, nil)
+				mockCategoryRepo.On("GetCategoryList", mock.Anything).
+					Return(categories, nil)
+				mockCategoryRepo.On("LockCategory", mock.Anything, int64(9)).
+					Return(model.Category{}, sql.ErrNoRows)
+			},
+			want:       api.MutationResponse{},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "success without products",
+			req:  api.DeleteCategoryRequest{},
+			prepare: func() {
+				mockCategoryRepo.On("LockCategory", mock.Anything, int64(2)).
+					Return(model.Category{ID: 2, Name: "Pet"}, nil)
+				mockCategoryRepo.On("GetCategoryList", mock.Anything).
+					Return(categories, nil)
+				mockCategoryRepo.On("DeleteCategory", mock.Anything, int64(2)).Return(nil)
+			},
+			want: api.MutationResponse{
+				Success: true,
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name: "success reassigning the products",
+			req:  api.DeleteCategoryRequest{ReassignTo: 1},
+			prepare: func() {
+				mockCategoryRepo.On("LockCategory", mock.Anything, int64(2)).
+					Return(model.Category{ID: 2, Name: "Pet"}, nil)
+				mockCategoryRepo.On("GetCategoryList", mock.Anything).
+					Return(categories, nil)
+				mockCategoryRepo.On("LockCategory", mock.Anything, int64(1)).
+					Return(model.Category{ID: 1, Name: "Food"}, nil)
+				mockProductRepo.On("MoveCategoryProducts", mock.Anything, int64(2), int64(1), mock.Anything).Return(nil)
+				mockProductRepo.On("RecomputeRatingScores", mock.Anything, ratingPrior).Return(nil)
+				mockCategoryRepo.On("DeleteCategory", mock.Anything, int64(2)).Return(nil)
+				mockProductRepo.On("GetProductList", mock.Anything, model.GetProductListFilter{
+					CategoryIDs: []int64{1},
+					Limit:       reindexBatchSize,
+				}).Return([]model.Product{{ID: 3, Category: model.Category{ID: 1, Name: "Food"}}}, nil)
+				mockSearcher.On("IndexProduct", mock.Anything, model.Product{ID: 3, Category: model.Category{ID: 1, Name: "Food"}}).
 					Return(nil)
 			},
 			want: api.MutationResponse{
@@ -783,19 +4569,23 @@ func Test_service_UpdateProduct(t *testing.T) {
 			s := &service{
 				productRepo:  mockProductRepo,
 				categoryRepo: mockCategoryRepo,
-				reviewRepo:   mockReviewRepo,
+				txManager:    mockTxManager,
+				searcher:     mockSearcher,
+				ratingPrior:  ratingPrior,
 			}
 			if tt.prepare != nil {
 				tt.prepare()
 			}
-			got, err := s.UpdateProduct(tt.args.ctx, tt.args.id, tt.args.req)
+			got, err := s.DeleteCategory(adminCtx, 2, tt.req)
 			if errorhelper.GetCode(err) != tt.statusCode {
-				t.Errorf("UpdateProduct() status code = %v, want %v", errorhelper.GetCode(err), tt.statusCode)
+				t.Errorf("DeleteCategory() status code = %v, want %v", errorhelper.GetCode(err), tt.statusCode)
 				return
 			}
 			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("UpdateProduct() got = %v, want %v", got, tt.want)
+				t.Errorf("DeleteCategory() got = %v, want %v", got, tt.want)
 			}
+			mockCategoryRepo.AssertExpectations(t)
+			mockProductRepo.AssertExpectations(t)
 		})
 	}
 }
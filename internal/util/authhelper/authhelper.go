@@ -0,0 +1,95 @@
+package authhelper
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// Headers set by the gateway in front of the service once the caller is
+// authenticated, see Middleware.
+const (
+	userIDHeader    = "X-User-ID"
+	userNameHeader  = "X-User-Name"
+	userRolesHeader = "X-User-Roles"
+)
+
+// RoleAdmin is the role of users allowed to moderate reviews.
+const RoleAdmin = "admin"
+
+// Anonymous is the actor of requests without identity headers. It has no ID,
+// which the gateway never sends, so no caller can be mistaken for it.
+var Anonymous = Actor{}
+
+// Actor is the user performing a request.
+type Actor struct {
+	ID    string
+	Name  string
+	Roles []string
+}
+
+// IsAnonymous reports whether the actor is not identified.
+func (actor Actor) IsAnonymous() bool {
+	return actor.ID == ""
+}
+
+// HasRole reports whether the actor has role.
+func (actor Actor) HasRole(role string) bool {
+	for _, r := range actor.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx carrying actor.
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor of ctx, or Anonymous when there is none.
+func ActorFromContext(ctx context.Context) Actor {
+	actor, ok := ctx.Value(actorKey{}).(Actor)
+	if !ok {
+		return Anonymous
+	}
+	return actor
+}
+
+// Middleware stores the actor identified by the request headers in the
+// request context. Any client can send these headers, so they are only read
+// when trustHeaders is set, and the service must then sit behind a gateway
+// that authenticates the caller and strips them from incoming requests.
+// Otherwise every request is anonymous.
+func Middleware(trustHeaders bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			actor := Anonymous
+			if trustHeaders {
+				actor = Actor{
+					ID:    strings.TrimSpace(request.Header.Get(userIDHeader)),
+					Name:  strings.TrimSpace(request.Header.Get(userNameHeader)),
+					Roles: readRoles(request.Header.Get(userRolesHeader)),
+				}
+			}
+			if actor.ID == "" {
+				actor = Anonymous
+			}
+			next.ServeHTTP(writer, request.WithContext(WithActor(request.Context(), actor)))
+		})
+	}
+}
+
+// readRoles parses a comma separated list of roles.
+func readRoles(header string) []string {
+	var roles []string
+	for _, role := range strings.Split(header, ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
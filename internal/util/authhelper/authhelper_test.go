@@ -0,0 +1,53 @@
+package authhelper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name         string
+		trustHeaders bool
+		headers      map[string]string
+		want         Actor
+	}{
+		{
+			name:         "trusted headers",
+			trustHeaders: true,
+			headers:      map[string]string{"X-User-ID": "u1", "X-User-Name": "Budi", "X-User-Roles": "admin, vendor"},
+			want:         Actor{ID: "u1", Name: "Budi", Roles: []string{"admin", "vendor"}},
+		},
+		{
+			name:         "trusted without identity",
+			trustHeaders: true,
+			headers:      map[string]string{"X-User-Roles": "admin"},
+			want:         Anonymous,
+		},
+		{
+			name:    "untrusted headers",
+			headers: map[string]string{"X-User-ID": "u1", "X-User-Roles": "admin"},
+			want:    Anonymous,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Actor
+			handler := Middleware(tt.trustHeaders)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = ActorFromContext(r.Context())
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/admin/reviews/pending", nil)
+			for key, value := range tt.headers {
+				r.Header.Set(key, value)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Middleware() actor = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package cursorhelper
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var encoding = base64.RawURLEncoding
+
+// Encode serializes data into an opaque token signed with key.
+func Encode(key []byte, data interface{}) (string, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+
+	return encoding.EncodeToString(payload) + "." + encoding.EncodeToString(sign(key, payload)), nil
+}
+
+// Decode verifies that token was created by Encode with the same key and
+// deserializes its data into result.
+func Decode(key []byte, token string, result interface{}) error {
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return errors.New("malformed cursor")
+	}
+
+	payload, err := encoding.DecodeString(encodedPayload)
+	if err != nil {
+		return errors.New("malformed cursor")
+	}
+	signature, err := encoding.DecodeString(encodedSignature)
+	if err != nil {
+		return errors.New("malformed cursor")
+	}
+	if !hmac.Equal(signature, sign(key, payload)) {
+		return errors.New("invalid cursor signature")
+	}
+
+	return json.Unmarshal(payload, result)
+}
+
+func sign(key, payload []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
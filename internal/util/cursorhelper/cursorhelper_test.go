@@ -0,0 +1,74 @@
+package cursorhelper
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type payload struct {
+	Value float64 `json:"v"`
+	ID    int64   `json:"i"`
+}
+
+func TestEncodeDecode(t *testing.T) {
+	key := []byte("secret")
+	token, err := Encode(key, payload{Value: 4.5, ID: 12})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	tamperedPayload, _ := Encode(key, payload{Value: 4.5, ID: 13})
+
+	tests := []struct {
+		name    string
+		key     []byte
+		token   string
+		want    payload
+		wantErr bool
+	}{
+		{
+			name:  "valid token",
+			key:   key,
+			token: token,
+			want:  payload{Value: 4.5, ID: 12},
+		},
+		{
+			name:    "other key",
+			key:     []byte("other"),
+			token:   token,
+			wantErr: true,
+		},
+		{
+			name:    "payload swapped",
+			key:     key,
+			token:   strings.Split(tamperedPayload, ".")[0] + "." + strings.Split(token, ".")[1],
+			wantErr: true,
+		},
+		{
+			name:    "no signature",
+			key:     key,
+			token:   strings.Split(token, ".")[0],
+			wantErr: true,
+		},
+		{
+			name:    "not base64",
+			key:     key,
+			token:   "!!!.???",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got payload
+			err := Decode(tt.key, tt.token, &got)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Decode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Decode() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
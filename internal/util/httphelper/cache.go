@@ -0,0 +1,148 @@
+package httphelper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Validators identify the representation sent to the client.
+type Validators struct {
+	// ETag is a strong entity tag, a hash of the body is used when empty.
+	ETag string
+	// LastModified is not sent when zero.
+	LastModified time.Time
+}
+
+// VersionETag returns the strong entity tag of a resource version. The
+// modification time is part of the tag because some writes change the
+// representation without incrementing the version.
+func VersionETag(version int64, modified time.Time) string {
+	if modified.IsZero() {
+		return fmt.Sprintf("%q", strconv.FormatInt(version, 10))
+	}
+	return fmt.Sprintf(`"%d.%d"`, version, modified.UnixMicro())
+}
+
+// BodyETag returns a strong entity tag computed from a response body.
+func BodyETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// Values returned by ReadIfMatchVersion instead of a version.
+const (
+	// IfMatchMissing is returned when there is no If-Match header.
+	IfMatchMissing int64 = 0
+	// IfMatchNone is returned when no entity tag of the header is a version,
+	// such as a weak tag, so the header never matches.
+	IfMatchNone int64 = -1
+	// IfMatchAny is returned for If-Match: *, which matches any version.
+	IfMatchAny int64 = -2
+)
+
+// ReadIfMatchVersion returns the resource version of the If-Match header. Only
+// the version part of a VersionETag is read.
+func ReadIfMatchVersion(request *http.Request) int64 {
+	header := strings.TrimSpace(request.Header.Get("If-Match"))
+	if header == "" {
+		return IfMatchMissing
+	}
+	if header == "*" {
+		return IfMatchAny
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			continue
+		}
+		str, _, _ := strings.Cut(tag[1:len(tag)-1], ".")
+		version, err := strconv.ParseInt(str, 10, 64)
+		if err == nil && version > 0 {
+			return version
+		}
+	}
+
+	return IfMatchNone
+}
+
+// NotModified reports whether the conditional headers of a GET or HEAD request
+// show the client already has the representation. If-None-Match takes
+// precedence over If-Modified-Since.
+func NotModified(request *http.Request, etag string, lastModified time.Time) bool {
+	if request.Method != http.MethodGet && request.Method != http.MethodHead {
+		return false
+	}
+
+	if header := request.Header.Get("If-None-Match"); header != "" {
+		return matchETag(header, etag)
+	}
+
+	if header := request.Header.Get("If-Modified-Since"); header != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(header)
+		// HTTP dates have a one second resolution.
+		return err == nil && !lastModified.Truncate(time.Second).After(since)
+	}
+
+	return false
+}
+
+// WriteConditional writes data together with its validators, or only
+// 304 Not Modified when the client already has it.
+func WriteConditional(writer http.ResponseWriter, request *http.Request, validators Validators, data interface{}) {
+	resp, err := json.Marshal(data)
+	if err != nil {
+		panic(fmt.Sprintf("failed marshal http response: %s", err))
+	}
+
+	etag := validators.ETag
+	if etag == "" {
+		etag = BodyETag(resp)
+	}
+	writer.Header().Set("ETag", etag)
+	if !validators.LastModified.IsZero() {
+		writer.Header().Set("Last-Modified", validators.LastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if NotModified(request, etag, validators.LastModified) {
+		writer.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	_, err = writer.Write(resp)
+	if err != nil {
+		panic(fmt.Sprintf("failed write http response: %s", err))
+	}
+}
+
+// CacheControl wraps a handler so its responses carry the given Cache-Control
+// header. An empty value leaves the handler unchanged.
+func CacheControl(value string, next http.HandlerFunc) http.Handler {
+	if value == "" {
+		return next
+	}
+
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Cache-Control", value)
+		next(writer, request)
+	})
+}
+
+// matchETag compares the entity tags of an If-None-Match header with etag
+// using the weak comparison required for that header.
+func matchETag(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
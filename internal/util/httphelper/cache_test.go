@@ -0,0 +1,129 @@
+package httphelper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNotModified(t *testing.T) {
+	modified := time.Date(2023, 12, 7, 10, 0, 0, 500, time.UTC)
+	tests := []struct {
+		name    string
+		method  string
+		headers map[string]string
+		want    bool
+	}{
+		{
+			name:   "no conditional header",
+			method: http.MethodGet,
+			want:   false,
+		},
+		{
+			name:    "matching etag",
+			method:  http.MethodGet,
+			headers: map[string]string{"If-None-Match": `"1", "3.7"`},
+			want:    true,
+		},
+		{
+			name:    "weak comparison",
+			method:  http.MethodGet,
+			headers: map[string]string{"If-None-Match": `W/"3.7"`},
+			want:    true,
+		},
+		{
+			name:    "other etag",
+			method:  http.MethodGet,
+			headers: map[string]string{"If-None-Match": `"3.6"`},
+			want:    false,
+		},
+		{
+			name:    "etag takes precedence over date",
+			method:  http.MethodGet,
+			headers: map[string]string{"If-None-Match": `"3.6"`, "If-Modified-Since": modified.Add(time.Hour).Format(http.TimeFormat)},
+			want:    false,
+		},
+		{
+			name:    "not modified since",
+			method:  http.MethodGet,
+			headers: map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)},
+			want:    true,
+		},
+		{
+			name:    "modified since",
+			method:  http.MethodGet,
+			headers: map[string]string{"If-Modified-Since": modified.Add(-time.Second).Format(http.TimeFormat)},
+			want:    false,
+		},
+		{
+			name:    "unsafe method",
+			method:  http.MethodPut,
+			headers: map[string]string{"If-None-Match": "*"},
+			want:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/products/3", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			if got := NotModified(r, `"3.7"`, modified); got != tt.want {
+				t.Errorf("NotModified() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteConditional(t *testing.T) {
+	data := map[string]int{"id": 3}
+
+	w := httptest.NewRecorder()
+	WriteConditional(w, httptest.NewRequest(http.MethodGet, "/products", nil), Validators{}, data)
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" || w.Body.String() != `{"id":3}` {
+		t.Fatalf("WriteConditional() = %v %q etag %q, want 200 with body and etag", w.Code, w.Body.String(), etag)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/products", nil)
+	r.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	WriteConditional(w, r, Validators{}, data)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("WriteConditional() = %v %q, want 304 without body", w.Code, w.Body.String())
+	}
+}
+
+func TestReadIfMatchVersion(t *testing.T) {
+	tests := []struct {
+		header string
+		want   int64
+	}{
+		{header: "", want: IfMatchMissing},
+		{header: `"4"`, want: 4},
+		{header: `"4.1701943200000000"`, want: 4},
+		{header: `"abc", "4"`, want: 4},
+		{header: `W/"4"`, want: IfMatchNone},
+		{header: `"abc"`, want: IfMatchNone},
+		{header: "*", want: IfMatchAny},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, "/products/3", nil)
+			r.Header.Set("If-Match", tt.header)
+			if got := ReadIfMatchVersion(r); got != tt.want {
+				t.Errorf("ReadIfMatchVersion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCacheControl(t *testing.T) {
+	handler := CacheControl("public, no-cache", func(w http.ResponseWriter, r *http.Request) {})
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/products", nil))
+	if got := w.Header().Get("Cache-Control"); got != "public, no-cache" {
+		t.Errorf("Cache-Control = %q, want %q", got, "public, no-cache")
+	}
+}
@@ -9,8 +9,10 @@ import (
 	"github.com/gorilla/mux"
 	"io"
 	"log"
+	"mime"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
 const internalServerErrorMessage = "INTERNAL_SERVER_ERROR"
@@ -30,6 +32,27 @@ func ReadBody(request *http.Request, result interface{}) error {
 	return nil
 }
 
+// RequireContentType rejects a request whose body is not one of the given
+// media types. A request without Content-Type is accepted.
+func RequireContentType(request *http.Request, mediaTypes ...string) error {
+	header := request.Header.Get("Content-Type")
+	if header == "" {
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(header)
+	if err != nil {
+		return errorhelper.WrapWithCode(err, "invalid content type", http.StatusUnsupportedMediaType)
+	}
+	for _, v := range mediaTypes {
+		if mediaType == v {
+			return nil
+		}
+	}
+
+	return errorhelper.NewWithCode(fmt.Sprintf("unsupported content type %q", mediaType), http.StatusUnsupportedMediaType)
+}
+
 func ReadPathVarInt(request *http.Request, name string) int64 {
 	str := mux.Vars(request)[name]
 	res, _ := strconv.ParseInt(str, 10, 64)
@@ -42,6 +65,36 @@ func ReadQueryParamInt(request *http.Request, name string) int64 {
 	return res
 }
 
+// ReadQueryParamIntList reads a comma separated list of integers, the param
+// may also be repeated. Values that are not integers are skipped.
+func ReadQueryParamIntList(request *http.Request, name string) []int64 {
+	var res []int64
+	for _, param := range request.URL.Query()[name] {
+		for _, str := range strings.Split(param, ",") {
+			val, err := strconv.ParseInt(strings.TrimSpace(str), 10, 64)
+			if err != nil {
+				continue
+			}
+			res = append(res, val)
+		}
+	}
+	return res
+}
+
+func ReadQueryParamFloat(request *http.Request, name string) float64 {
+	str := request.URL.Query().Get(name)
+	res, _ := strconv.ParseFloat(str, 64)
+	return res
+}
+
+// ReadQueryParamBool reads a param as strconv.ParseBool does, a missing or
+// invalid value is false.
+func ReadQueryParamBool(request *http.Request, name string) bool {
+	str := request.URL.Query().Get(name)
+	res, _ := strconv.ParseBool(str)
+	return res
+}
+
 func Write(writer http.ResponseWriter, data interface{}) {
 	resp, err := json.Marshal(data)
 	if err != nil {
@@ -61,6 +114,8 @@ func WriteError(writer http.ResponseWriter, err error) {
 		errMsg = internalServerErrorMessage
 	}
 
+	// Errors must never be served from a cache set up for the route.
+	writer.Header().Set("Cache-Control", "no-store")
 	writer.WriteHeader(errorhelper.GetCode(err))
 
 	resp, err := json.Marshal(api.ErrorResponse{
@@ -14,6 +14,20 @@ type CategoryRepository struct {
 	mock.Mock
 }
 
+// DeleteCategory provides a mock function with given fields: ctx, id
+func (_m *CategoryRepository) DeleteCategory(ctx context.Context, id int64) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // GetCategory provides a mock function with given fields: ctx, id
 func (_m *CategoryRepository) GetCategory(ctx context.Context, id int64) (model.Category, error) {
 	ret := _m.Called(ctx, id)
@@ -38,6 +52,94 @@ func (_m *CategoryRepository) GetCategory(ctx context.Context, id int64) (model.
 	return r0, r1
 }
 
+// GetCategoryList provides a mock function with given fields: ctx
+func (_m *CategoryRepository) GetCategoryList(ctx context.Context) ([]model.Category, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []model.Category
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]model.Category, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []model.Category); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Category)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InsertCategory provides a mock function with given fields: ctx, category
+func (_m *CategoryRepository) InsertCategory(ctx context.Context, category model.Category) (int64, error) {
+	ret := _m.Called(ctx, category)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.Category) (int64, error)); ok {
+		return rf(ctx, category)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, model.Category) int64); ok {
+		r0 = rf(ctx, category)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, model.Category) error); ok {
+		r1 = rf(ctx, category)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LockCategory provides a mock function with given fields: ctx, id
+func (_m *CategoryRepository) LockCategory(ctx context.Context, id int64) (model.Category, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 model.Category
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (model.Category, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) model.Category); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(model.Category)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateCategory provides a mock function with given fields: ctx, category
+func (_m *CategoryRepository) UpdateCategory(ctx context.Context, category model.Category) error {
+	ret := _m.Called(ctx, category)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.Category) error); ok {
+		r0 = rf(ctx, category)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewCategoryRepository creates a new instance of CategoryRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewCategoryRepository(t interface {
@@ -0,0 +1,79 @@
+// Code generated by mockery v2.37.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	model "github.com/alam/govtech/internal/model"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ProductHistoryRepository is an autogenerated mock type for the ProductHistoryRepository type
+type ProductHistoryRepository struct {
+	mock.Mock
+}
+
+// CountProductHistory provides a mock function with given fields: ctx, productID
+func (_m *ProductHistoryRepository) CountProductHistory(ctx context.Context, productID int64) (int64, error) {
+	ret := _m.Called(ctx, productID)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (int64, error)); ok {
+		return rf(ctx, productID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) int64); ok {
+		r0 = rf(ctx, productID)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, productID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetProductHistory provides a mock function with given fields: ctx, productID, limit, offset
+func (_m *ProductHistoryRepository) GetProductHistory(ctx context.Context, productID int64, limit int64, offset int64) ([]model.ProductHistory, error) {
+	ret := _m.Called(ctx, productID, limit, offset)
+
+	var r0 []model.ProductHistory
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int64) ([]model.ProductHistory, error)); ok {
+		return rf(ctx, productID, limit, offset)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int64) []model.ProductHistory); ok {
+		r0 = rf(ctx, productID, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.ProductHistory)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, int64) error); ok {
+		r1 = rf(ctx, productID, limit, offset)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewProductHistoryRepository creates a new instance of ProductHistoryRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewProductHistoryRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ProductHistoryRepository {
+	mock := &ProductHistoryRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
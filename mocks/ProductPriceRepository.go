@@ -0,0 +1,104 @@
+// Code generated by mockery v2.37.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	time "time"
+
+	model "github.com/alam/govtech/internal/model"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ProductPriceRepository is an autogenerated mock type for the ProductPriceRepository type
+type ProductPriceRepository struct {
+	mock.Mock
+}
+
+// CountProductPrices provides a mock function with given fields: ctx, productID
+func (_m *ProductPriceRepository) CountProductPrices(ctx context.Context, productID int64) (int64, error) {
+	ret := _m.Called(ctx, productID)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (int64, error)); ok {
+		return rf(ctx, productID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) int64); ok {
+		r0 = rf(ctx, productID)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, productID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetProductPriceAt provides a mock function with given fields: ctx, productID, at
+func (_m *ProductPriceRepository) GetProductPriceAt(ctx context.Context, productID int64, at time.Time) (model.ProductPrice, error) {
+	ret := _m.Called(ctx, productID, at)
+
+	var r0 model.ProductPrice
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, time.Time) (model.ProductPrice, error)); ok {
+		return rf(ctx, productID, at)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, time.Time) model.ProductPrice); ok {
+		r0 = rf(ctx, productID, at)
+	} else {
+		r0 = ret.Get(0).(model.ProductPrice)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, time.Time) error); ok {
+		r1 = rf(ctx, productID, at)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetProductPrices provides a mock function with given fields: ctx, productID, limit, offset
+func (_m *ProductPriceRepository) GetProductPrices(ctx context.Context, productID int64, limit int64, offset int64) ([]model.ProductPrice, error) {
+	ret := _m.Called(ctx, productID, limit, offset)
+
+	var r0 []model.ProductPrice
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int64) ([]model.ProductPrice, error)); ok {
+		return rf(ctx, productID, limit, offset)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int64) []model.ProductPrice); ok {
+		r0 = rf(ctx, productID, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.ProductPrice)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, int64) error); ok {
+		r1 = rf(ctx, productID, limit, offset)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewProductPriceRepository creates a new instance of ProductPriceRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewProductPriceRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ProductPriceRepository {
+	mock := &ProductPriceRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
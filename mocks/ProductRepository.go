@@ -14,6 +14,58 @@ type ProductRepository struct {
 	mock.Mock
 }
 
+// AddProductRating provides a mock function with given fields: ctx, id, delta, prior, actor
+func (_m *ProductRepository) AddProductRating(ctx context.Context, id int64, delta model.RatingDelta, prior model.RatingPrior, actor model.User) error {
+	ret := _m.Called(ctx, id, delta, prior, actor)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, model.RatingDelta, model.RatingPrior, model.User) error); ok {
+		r0 = rf(ctx, id, delta, prior, actor)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CountProductList provides a mock function with given fields: ctx, filter
+func (_m *ProductRepository) CountProductList(ctx context.Context, filter model.GetProductListFilter) (int64, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.GetProductListFilter) (int64, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, model.GetProductListFilter) int64); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, model.GetProductListFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteProduct provides a mock function with given fields: ctx, id, actor
+func (_m *ProductRepository) DeleteProduct(ctx context.Context, id int64, actor model.User) error {
+	ret := _m.Called(ctx, id, actor)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, model.User) error); ok {
+		r0 = rf(ctx, id, actor)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // GetProduct provides a mock function with given fields: ctx, id
 func (_m *ProductRepository) GetProduct(ctx context.Context, id int64) (model.Product, error) {
 	ret := _m.Called(ctx, id)
@@ -62,6 +114,30 @@ func (_m *ProductRepository) GetProductBySKU(ctx context.Context, sku string) (m
 	return r0, r1
 }
 
+// GetProductFacets provides a mock function with given fields: ctx, filter
+func (_m *ProductRepository) GetProductFacets(ctx context.Context, filter model.GetProductListFilter) (model.ProductFacets, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 model.ProductFacets
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.GetProductListFilter) (model.ProductFacets, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, model.GetProductListFilter) model.ProductFacets); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		r0 = ret.Get(0).(model.ProductFacets)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, model.GetProductListFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetProductList provides a mock function with given fields: ctx, filter
 func (_m *ProductRepository) GetProductList(ctx context.Context, filter model.GetProductListFilter) ([]model.Product, error) {
 	ret := _m.Called(ctx, filter)
@@ -88,13 +164,51 @@ func (_m *ProductRepository) GetProductList(ctx context.Context, filter model.Ge
 	return r0, r1
 }
 
-// InsertProduct provides a mock function with given fields: ctx, product
-func (_m *ProductRepository) InsertProduct(ctx context.Context, product model.Product) error {
-	ret := _m.Called(ctx, product)
+// InsertProduct provides a mock function with given fields: ctx, product, actor
+func (_m *ProductRepository) InsertProduct(ctx context.Context, product model.Product, actor model.User) (int64, error) {
+	ret := _m.Called(ctx, product, actor)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.Product, model.User) (int64, error)); ok {
+		return rf(ctx, product, actor)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, model.Product, model.User) int64); ok {
+		r0 = rf(ctx, product, actor)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, model.Product, model.User) error); ok {
+		r1 = rf(ctx, product, actor)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MoveCategoryProducts provides a mock function with given fields: ctx, fromCategoryID, toCategoryID, actor
+func (_m *ProductRepository) MoveCategoryProducts(ctx context.Context, fromCategoryID int64, toCategoryID int64, actor model.User) error {
+	ret := _m.Called(ctx, fromCategoryID, toCategoryID, actor)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, model.User) error); ok {
+		r0 = rf(ctx, fromCategoryID, toCategoryID, actor)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RecomputeRatingScores provides a mock function with given fields: ctx, prior
+func (_m *ProductRepository) RecomputeRatingScores(ctx context.Context, prior model.RatingPrior) error {
+	ret := _m.Called(ctx, prior)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, model.Product) error); ok {
-		r0 = rf(ctx, product)
+	if rf, ok := ret.Get(0).(func(context.Context, model.RatingPrior) error); ok {
+		r0 = rf(ctx, prior)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -102,13 +216,13 @@ func (_m *ProductRepository) InsertProduct(ctx context.Context, product model.Pr
 	return r0
 }
 
-// UpdateProduct provides a mock function with given fields: ctx, id, product
-func (_m *ProductRepository) UpdateProduct(ctx context.Context, id int64, product model.Product) error {
-	ret := _m.Called(ctx, id, product)
+// RestoreProduct provides a mock function with given fields: ctx, id, actor
+func (_m *ProductRepository) RestoreProduct(ctx context.Context, id int64, actor model.User) error {
+	ret := _m.Called(ctx, id, actor)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64, model.Product) error); ok {
-		r0 = rf(ctx, id, product)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, model.User) error); ok {
+		r0 = rf(ctx, id, actor)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -116,13 +230,13 @@ func (_m *ProductRepository) UpdateProduct(ctx context.Context, id int64, produc
 	return r0
 }
 
-// UpdateProductRating provides a mock function with given fields: ctx, id, rating
-func (_m *ProductRepository) UpdateProductRating(ctx context.Context, id int64, rating float64) error {
-	ret := _m.Called(ctx, id, rating)
+// UpdateProduct provides a mock function with given fields: ctx, id, product, actor
+func (_m *ProductRepository) UpdateProduct(ctx context.Context, id int64, product model.Product, actor model.User) error {
+	ret := _m.Called(ctx, id, product, actor)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64, float64) error); ok {
-		r0 = rf(ctx, id, rating)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, model.Product, model.User) error); ok {
+		r0 = rf(ctx, id, product, actor)
 	} else {
 		r0 = ret.Error(0)
 	}
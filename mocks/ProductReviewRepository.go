@@ -4,6 +4,7 @@ package mocks
 
 import (
 	context "context"
+	time "time"
 
 	model "github.com/alam/govtech/internal/model"
 	mock "github.com/stretchr/testify/mock"
@@ -14,6 +15,194 @@ type ProductReviewRepository struct {
 	mock.Mock
 }
 
+// AddReviewVotes provides a mock function with given fields: ctx, reviewID, delta
+func (_m *ProductReviewRepository) AddReviewVotes(ctx context.Context, reviewID int64, delta model.VoteDelta) error {
+	ret := _m.Called(ctx, reviewID, delta)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, model.VoteDelta) error); ok {
+		r0 = rf(ctx, reviewID, delta)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CountReviewList provides a mock function with given fields: ctx, filter
+func (_m *ProductReviewRepository) CountReviewList(ctx context.Context, filter model.GetReviewListFilter) (int64, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.GetReviewListFilter) (int64, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, model.GetReviewListFilter) int64); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, model.GetReviewListFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CountReviewsWithComment provides a mock function with given fields: ctx, comment, userID
+func (_m *ProductReviewRepository) CountReviewsWithComment(ctx context.Context, comment string, userID int64) (int64, error) {
+	ret := _m.Called(ctx, comment, userID)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) (int64, error)); ok {
+		return rf(ctx, comment, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) int64); ok {
+		r0 = rf(ctx, comment, userID)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int64) error); ok {
+		r1 = rf(ctx, comment, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CountUserReviewsSince provides a mock function with given fields: ctx, userID, since
+func (_m *ProductReviewRepository) CountUserReviewsSince(ctx context.Context, userID int64, since time.Time) (int64, error) {
+	ret := _m.Called(ctx, userID, since)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, time.Time) (int64, error)); ok {
+		return rf(ctx, userID, since)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, time.Time) int64); ok {
+		r0 = rf(ctx, userID, since)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, time.Time) error); ok {
+		r1 = rf(ctx, userID, since)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteReview provides a mock function with given fields: ctx, id
+func (_m *ProductReviewRepository) DeleteReview(ctx context.Context, id int64) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteReviewReply provides a mock function with given fields: ctx, reviewID
+func (_m *ProductReviewRepository) DeleteReviewReply(ctx context.Context, reviewID int64) error {
+	ret := _m.Called(ctx, reviewID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, reviewID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetReview provides a mock function with given fields: ctx, productID, id
+func (_m *ProductReviewRepository) GetReview(ctx context.Context, productID int64, id int64) (model.ProductReview, error) {
+	ret := _m.Called(ctx, productID, id)
+
+	var r0 model.ProductReview
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (model.ProductReview, error)); ok {
+		return rf(ctx, productID, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) model.ProductReview); ok {
+		r0 = rf(ctx, productID, id)
+	} else {
+		r0 = ret.Get(0).(model.ProductReview)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, productID, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetReviewByID provides a mock function with given fields: ctx, id
+func (_m *ProductReviewRepository) GetReviewByID(ctx context.Context, id int64) (model.ProductReview, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 model.ProductReview
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (model.ProductReview, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) model.ProductReview); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(model.ProductReview)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetReviewList provides a mock function with given fields: ctx, filter
+func (_m *ProductReviewRepository) GetReviewList(ctx context.Context, filter model.GetReviewListFilter) ([]model.ProductReview, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []model.ProductReview
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.GetReviewListFilter) ([]model.ProductReview, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, model.GetReviewListFilter) []model.ProductReview); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.ProductReview)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, model.GetReviewListFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetReviewStatistic provides a mock function with given fields: ctx, productID
 func (_m *ProductReviewRepository) GetReviewStatistic(ctx context.Context, productID int64) (model.Statistic, error) {
 	ret := _m.Called(ctx, productID)
@@ -38,6 +227,54 @@ func (_m *ProductReviewRepository) GetReviewStatistic(ctx context.Context, produ
 	return r0, r1
 }
 
+// GetReviewVote provides a mock function with given fields: ctx, reviewID, userID
+func (_m *ProductReviewRepository) GetReviewVote(ctx context.Context, reviewID int64, userID int64) (model.ReviewVote, error) {
+	ret := _m.Called(ctx, reviewID, userID)
+
+	var r0 model.ReviewVote
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (model.ReviewVote, error)); ok {
+		return rf(ctx, reviewID, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) model.ReviewVote); ok {
+		r0 = rf(ctx, reviewID, userID)
+	} else {
+		r0 = ret.Get(0).(model.ReviewVote)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, reviewID, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserReview provides a mock function with given fields: ctx, productID, userID
+func (_m *ProductReviewRepository) GetUserReview(ctx context.Context, productID int64, userID int64) (model.ProductReview, error) {
+	ret := _m.Called(ctx, productID, userID)
+
+	var r0 model.ProductReview
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (model.ProductReview, error)); ok {
+		return rf(ctx, productID, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) model.ProductReview); ok {
+		r0 = rf(ctx, productID, userID)
+	} else {
+		r0 = ret.Get(0).(model.ProductReview)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, productID, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // InsertReview provides a mock function with given fields: ctx, review
 func (_m *ProductReviewRepository) InsertReview(ctx context.Context, review model.ProductReview) error {
 	ret := _m.Called(ctx, review)
@@ -52,6 +289,62 @@ func (_m *ProductReviewRepository) InsertReview(ctx context.Context, review mode
 	return r0
 }
 
+// InsertReviewVote provides a mock function with given fields: ctx, vote
+func (_m *ProductReviewRepository) InsertReviewVote(ctx context.Context, vote model.ReviewVote) error {
+	ret := _m.Called(ctx, vote)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.ReviewVote) error); ok {
+		r0 = rf(ctx, vote)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateReview provides a mock function with given fields: ctx, review
+func (_m *ProductReviewRepository) UpdateReview(ctx context.Context, review model.ProductReview) error {
+	ret := _m.Called(ctx, review)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.ProductReview) error); ok {
+		r0 = rf(ctx, review)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateReviewVote provides a mock function with given fields: ctx, vote
+func (_m *ProductReviewRepository) UpdateReviewVote(ctx context.Context, vote model.ReviewVote) error {
+	ret := _m.Called(ctx, vote)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.ReviewVote) error); ok {
+		r0 = rf(ctx, vote)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpsertReviewReply provides a mock function with given fields: ctx, reply
+func (_m *ProductReviewRepository) UpsertReviewReply(ctx context.Context, reply model.ReviewReply) error {
+	ret := _m.Called(ctx, reply)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.ReviewReply) error); ok {
+		r0 = rf(ctx, reply)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewProductReviewRepository creates a new instance of ProductReviewRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewProductReviewRepository(t interface {
@@ -0,0 +1,83 @@
+// Code generated by mockery v2.37.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	model "github.com/alam/govtech/internal/model"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ProductSearcher is an autogenerated mock type for the ProductSearcher type
+type ProductSearcher struct {
+	mock.Mock
+}
+
+// IndexProduct provides a mock function with given fields: ctx, product
+func (_m *ProductSearcher) IndexProduct(ctx context.Context, product model.Product) error {
+	ret := _m.Called(ctx, product)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.Product) error); ok {
+		r0 = rf(ctx, product)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveProduct provides a mock function with given fields: ctx, id
+func (_m *ProductSearcher) RemoveProduct(ctx context.Context, id int64) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Search provides a mock function with given fields: ctx, query
+func (_m *ProductSearcher) Search(ctx context.Context, query string) ([]model.SearchHit, error) {
+	ret := _m.Called(ctx, query)
+
+	var r0 []model.SearchHit
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]model.SearchHit, error)); ok {
+		return rf(ctx, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []model.SearchHit); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.SearchHit)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewProductSearcher creates a new instance of ProductSearcher. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewProductSearcher(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ProductSearcher {
+	mock := &ProductSearcher{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,53 @@
+// Code generated by mockery v2.37.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	model "github.com/alam/govtech/internal/model"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ReviewScreener is an autogenerated mock type for the ReviewScreener type
+type ReviewScreener struct {
+	mock.Mock
+}
+
+// Screen provides a mock function with given fields: ctx, review
+func (_m *ReviewScreener) Screen(ctx context.Context, review model.ProductReview) (string, error) {
+	ret := _m.Called(ctx, review)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.ProductReview) (string, error)); ok {
+		return rf(ctx, review)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, model.ProductReview) string); ok {
+		r0 = rf(ctx, review)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, model.ProductReview) error); ok {
+		r1 = rf(ctx, review)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewReviewScreener creates a new instance of ReviewScreener. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewReviewScreener(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ReviewScreener {
+	mock := &ReviewScreener{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}